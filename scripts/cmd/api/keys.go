@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"healthcare-saver-ingest/internal/auth"
+	"healthcare-saver-ingest/internal/config"
+)
+
+// runKeys manages API keys: keys create|revoke|list
+func runKeys(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: api keys create|revoke|list [flags]")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+	db, err := cfg.Open()
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	keys := auth.NewKeys(db)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		return createKey(ctx, keys, args[1:])
+	case "revoke":
+		return revokeKey(ctx, keys, args[1:])
+	case "list":
+		return listKeys(ctx, keys)
+	default:
+		return fmt.Errorf("unknown keys command %q, expected create, revoke or list", args[0])
+	}
+}
+
+// createKey issues a key and prints it
+func createKey(ctx context.Context, keys *auth.Keys, args []string) error {
+	fs := flag.NewFlagSet("keys create", flag.ExitOnError)
+	var (
+		name  = fs.String("name", "", "Name of the client the key is for")
+		rate  = fs.Float64("rate", 5, "Sustained requests per second")
+		burst = fs.Int("burst", 20, "Requests allowed at once")
+		quota = fs.Int("daily-quota", 10000, "Requests per UTC day (0 for unlimited)")
+	)
+	fs.Parse(args)
+
+	if *name == "" {
+		return fmt.Errorf("please specify -name")
+	}
+
+	token, key, err := keys.Create(ctx, *name, auth.Limits{RatePerSecond: *rate, Burst: *burst, DailyQuota: *quota})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🔑 Created API key %d for %s", key.ID, key.Name)
+	fmt.Println(token)
+	log.Println("⚠️ Store the key now, it cannot be shown again")
+	return nil
+}
+
+// revokeKey revokes a key by id
+func revokeKey(ctx context.Context, keys *auth.Keys, args []string) error {
+	fs := flag.NewFlagSet("keys revoke", flag.ExitOnError)
+	id := fs.Int64("id", 0, "Id of the key to revoke")
+	fs.Parse(args)
+
+	if *id == 0 {
+		return fmt.Errorf("please specify -id")
+	}
+	if err := keys.Revoke(ctx, *id); err != nil {
+		return err
+	}
+	log.Printf("🔒 Revoked API key %d", *id)
+	return nil
+}
+
+// listKeys prints all keys with today's usage
+func listKeys(ctx context.Context, keys *auth.Keys) error {
+	list, err := keys.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tPREFIX\tRATE\tBURST\tDAILY QUOTA\tTODAY\tCREATED\tLAST USED\tSTATUS")
+	for _, key := range list {
+		quota := "unlimited"
+		if key.DailyQuota > 0 {
+			quota = fmt.Sprint(key.DailyQuota)
+		}
+		lastUsed := "never"
+		if key.LastUsedAt != nil {
+			lastUsed = key.LastUsedAt.Format(time.DateTime)
+		}
+		status := "active"
+		if key.RevokedAt != nil {
+			status = "revoked " + key.RevokedAt.Format(time.DateOnly)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s…\t%g/s\t%d\t%s\t%d\t%s\t%s\t%s\n",
+			key.ID, key.Name, key.Prefix, key.RatePerSecond, key.Burst, quota, key.RequestsToday,
+			key.CreatedAt.Format(time.DateOnly), lastUsed, status)
+	}
+	return tw.Flush()
+}
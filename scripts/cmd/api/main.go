@@ -0,0 +1,51 @@
+// Command api serves the rate API over HTTP and gRPC, and runs the modes
+// that manage API keys and write the OpenAPI document. Without a mode it
+// serves the API.
+package main
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// mode is a subcommand, selected by the first argument
+type mode struct {
+	run func(args []string) error
+	// failure is logged before the error the mode fails with
+	failure string
+}
+
+// modes are the subcommands by name
+var modes = map[string]mode{
+	// Keys mode manages API keys
+	"keys": {runKeys, "Failed to manage API keys"},
+	// OpenAPI mode writes the API description and TypeScript client
+	"openapi": {runOpenAPI, "Failed to generate OpenAPI document"},
+}
+
+// modeNames returns the names of the modes in order
+func modeNames() []string {
+	names := make([]string, 0, len(modes))
+	for name := range modes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func main() {
+	m, args := mode{runServe, "Failed to serve the API"}, os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		var ok bool
+		if m, ok = modes[args[0]]; !ok {
+			log.Fatalf("❌ Unknown mode %q, expected one of: %s", args[0], strings.Join(modeNames(), ", "))
+		}
+		args = args[1:]
+	}
+
+	if err := m.run(args); err != nil {
+		log.Fatalf("❌ %s: %v", m.failure, err)
+	}
+}
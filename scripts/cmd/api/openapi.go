@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"healthcare-saver-ingest/internal/api"
+)
+
+// runOpenAPI writes the OpenAPI document of the API and optionally the
+// TypeScript client generated from it
+func runOpenAPI(args []string) error {
+	fs := flag.NewFlagSet("openapi", flag.ExitOnError)
+	var (
+		output = fs.String("o", "", "File to write the OpenAPI document to (default: stdout)")
+		ts     = fs.String("ts", "", "File to write the TypeScript client to")
+	)
+	fs.Parse(args)
+
+	doc := api.Spec()
+
+	if *ts != "" {
+		var client bytes.Buffer
+		if err := doc.TypeScript(&client); err != nil {
+			return fmt.Errorf("failed to generate TypeScript client: %v", err)
+		}
+		if err := os.WriteFile(*ts, client.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("failed to write TypeScript client: %v", err)
+		}
+		log.Printf("📝 Wrote TypeScript client to %s", *ts)
+		if *output == "" {
+			return nil
+		}
+	}
+
+	spec, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode OpenAPI document: %v", err)
+	}
+	spec = append(spec, '\n')
+	if *output == "" {
+		_, err := os.Stdout.Write(spec)
+		return err
+	}
+	if err := os.WriteFile(*output, spec, 0o644); err != nil {
+		return fmt.Errorf("failed to write OpenAPI document: %v", err)
+	}
+	log.Printf("📝 Wrote OpenAPI document to %s", *output)
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"healthcare-saver-ingest/internal/api"
+	"healthcare-saver-ingest/internal/auth"
+	"healthcare-saver-ingest/internal/cache"
+	"healthcare-saver-ingest/internal/config"
+	"healthcare-saver-ingest/internal/episode"
+	"healthcare-saver-ingest/internal/grpcapi"
+	"healthcare-saver-ingest/internal/store"
+)
+
+// usageFlushInterval is how often API key usage is recorded for daily quotas
+const usageFlushInterval = 15 * time.Second
+
+// runServe implements the default mode: it serves the HTTP API, and the
+// gRPC rate service with -grpc-addr, until SIGINT or SIGTERM
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("api", flag.ExitOnError)
+	var (
+		addr          = fs.String("addr", config.GetEnv("API_ADDR", ":8080"), "Address to listen on")
+		allowedOrigin = fs.String("allowed-origin", config.GetEnv("API_ALLOWED_ORIGIN", "*"), "Access-Control-Allow-Origin value (empty disables CORS)")
+		reload        = fs.Duration("reload-interval", 15*time.Minute, "How often to rebuild the in-memory search indexes (0 disables)")
+		grpcAddr      = fs.String("grpc-addr", config.GetEnv("API_GRPC_ADDR", ""), "Address to serve the gRPC rate service on (empty disables)")
+		cacheSize     = fs.Int("cache-size", 10000, "Maximum number of responses in the in-process cache (0 disables caching)")
+		cacheTTL      = fs.Duration("cache-ttl", 10*time.Minute, "How long a cached response is served at most")
+		redisURL      = fs.String("redis-url", config.GetEnv("REDIS_URL", ""), "Redis URL of a cache shared between API instances, instead of the in-process cache")
+		requireKey    = fs.Bool("require-api-key", config.GetEnv("API_REQUIRE_KEY", "false") == "true", "Require an API key on every endpoint but /healthz and on gRPC calls")
+		validate      = fs.Bool("validate-openapi", config.GetEnv("API_VALIDATE_OPENAPI", "false") == "true", "Log requests and responses that do not match the OpenAPI document")
+		ledgerPoll    = fs.Duration("ledger-poll-interval", 30*time.Second, "How often to check the run ledger for runs and loads invalidating cached responses")
+		episodesPath  = fs.String("episodes", config.GetEnv("API_EPISODES", "data/episodes.yaml"), "Episode definitions (YAML) priced by /v1/episodes (empty disables)")
+	)
+	fs.Parse(args)
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	db, err := cfg.Open()
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %v", err)
+	}
+	st := store.New(db)
+	defer st.Close()
+
+	// Build the in-memory search indexes; search still works without them,
+	// just without typo correction
+	start := time.Now()
+	if err := st.Reload(context.Background()); err != nil {
+		log.Printf("⚠️ Failed to build search indexes: %v", err)
+	} else {
+		log.Printf("📚 Built search indexes in %v", time.Since(start).Round(time.Millisecond))
+	}
+
+	// Cache responses in Redis when configured, else in process
+	var responseCache cache.Cache
+	switch {
+	case *redisURL != "":
+		redisCache, err := cache.OpenRedis(context.Background(), *redisURL, "hcs:cache:")
+		if err != nil {
+			return err
+		}
+		defer redisCache.Close()
+		responseCache = redisCache
+		log.Println("🔧 Caching responses in Redis")
+	case *cacheSize > 0:
+		responseCache = cache.NewLRU(*cacheSize)
+		log.Printf("🔧 Caching up to %d responses in process", *cacheSize)
+	}
+
+	// Episodes are optional; without the definitions /v1/episodes is empty
+	var episodes []episode.Episode
+	if _, err := os.Stat(*episodesPath); *episodesPath != "" && os.IsNotExist(err) {
+		log.Printf("⚠️ No episode definitions at %s, serving no episodes", *episodesPath)
+	} else if *episodesPath != "" {
+		if episodes, err = episode.Load(*episodesPath); err != nil {
+			return err
+		}
+		log.Printf("📚 Loaded %d episode definitions", len(episodes))
+	}
+
+	var limiter *auth.Limiter
+	if *requireKey {
+		limiter = auth.NewLimiter(auth.NewKeys(db))
+		log.Println("🔧 Requiring API keys")
+	}
+
+	apiServer := api.NewServer(st, api.Options{
+		AllowedOrigin: *allowedOrigin,
+		Cache:         responseCache,
+		CacheTTL:      *cacheTTL,
+		Limiter:       limiter,
+		Episodes:      episodes,
+
+		ValidateOpenAPI: *validate,
+	})
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           apiServer,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      60 * time.Second,
+	}
+
+	// Shut down gracefully on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *reload > 0 {
+		go reloadLoop(ctx, st, *reload)
+	}
+	// Record API key usage until shutdown, then wait for the last flush
+	if limiter != nil {
+		flushed := make(chan struct{})
+		go func() {
+			limiter.Run(ctx, usageFlushInterval)
+			close(flushed)
+		}()
+		defer func() { <-flushed }()
+	}
+	if responseCache != nil && *ledgerPoll > 0 {
+		go apiServer.WatchRuns(ctx, *ledgerPoll)
+	}
+
+	// The gRPC rate service shares the store and the API keys with the HTTP
+	// API
+	if *grpcAddr != "" {
+		listener, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %v", *grpcAddr, err)
+		}
+		grpcServer := grpcapi.NewServer(st, limiter)
+		go func() {
+			<-ctx.Done()
+			grpcServer.GracefulStop()
+		}()
+		go func() {
+			log.Printf("🚀 gRPC listening on %s", *grpcAddr)
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Printf("❌ gRPC server failed: %v", err)
+			}
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("⚠️ Failed to shut down cleanly: %v", err)
+		}
+	}()
+
+	log.Printf("🚀 API listening on %s", *addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Println("👋 API stopped")
+	return nil
+}
+
+// reloadLoop rebuilds the search indexes every interval until ctx is done
+func reloadLoop(ctx context.Context, st *store.Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := st.Reload(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("⚠️ Failed to reload search indexes: %v", err)
+			}
+		}
+	}
+}
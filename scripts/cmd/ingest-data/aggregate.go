@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	"healthcare-saver-ingest/internal/aggregate"
+	"healthcare-saver-ingest/internal/config"
+	"healthcare-saver-ingest/internal/outlier"
+)
+
+// aggregateProgressInterval is how many groups RebuildAggregates refreshes
+// between progress messages
+const aggregateProgressInterval = 1000
+
+// RebuildAggregates recomputes the rate aggregates of every billing code, or
+// of one billing code, and deletes those of codes without current rates
+func (s *DataIngestionService) RebuildAggregates(codeType, code string, workers int) error {
+	start := time.Now()
+	aggregates := aggregate.New(s.db)
+
+	groups, err := aggregates.AllGroups(context.Background(), codeType, code)
+	if err != nil {
+		return err
+	}
+	log.Printf("📊 Aggregating the rates of %d billing code and payer groups...", len(groups))
+
+	err = aggregates.Refresh(context.Background(), groups, workers, func(done int) {
+		if done%aggregateProgressInterval == 0 {
+			log.Printf("📊 Aggregated %d of %d groups", done, len(groups))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🎉 Aggregated %d groups in %v", len(groups), time.Since(start).Round(time.Second))
+	return nil
+}
+
+// RefreshMedicareBenchmarks recomputes the Medicare amounts of the rate
+// aggregates after the fee schedules change, which changes every billing code
+func (s *DataIngestionService) RefreshMedicareBenchmarks() error {
+	updated, err := aggregate.New(s.db).RefreshBenchmarks(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Printf("📊 Updated the Medicare amounts of %d rate aggregates", updated)
+	return s.recordLoad("medicare", true, nil)
+}
+
+// backfillAggregates builds the rate aggregates of data ingested before they existed
+func (s *DataIngestionService) backfillAggregates() error {
+	log.Println("🔧 Aggregating existing rates, this may take a while on large tables...")
+	return s.RebuildAggregates("", "", 4)
+}
+
+// runAggregate implements the aggregate mode: it rebuilds the rate aggregates
+func runAggregate(args []string) error {
+	fs := flag.NewFlagSet("aggregate", flag.ExitOnError)
+	var (
+		codeType = fs.String("code-type", "CPT", "Billing code type of -code")
+		code     = fs.String("code", "", "Only rebuild the aggregates of this billing code")
+		workers  = fs.Int("workers", 4, "Number of billing code and payer groups aggregated concurrently")
+	)
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+
+	if err := service.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create tables: %v", err)
+	}
+	if err := service.RunMigrations(); err != nil {
+		return fmt.Errorf("failed to run migrations: %v", err)
+	}
+
+	if err := service.RebuildAggregates(*codeType, *code, *workers); err != nil {
+		return err
+	}
+	if *code == "" {
+		return service.recordLoad("aggregate", true, nil)
+	}
+	return service.recordLoad("aggregate", false, []outlier.Code{{BillingCodeType: *codeType, BillingCode: *code}})
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"healthcare-saver-ingest/internal/config"
+	"healthcare-saver-ingest/internal/diff"
+)
+
+// runRatesCondition is the condition on negotiated_rates (aliased r, with the
+// run that inserted it aliased first) for the rates a run published: those
+// it inserted and, in history mode, those it extended, whose period runs
+// from an earlier run of the same reporting entity to it or a later one.
+// Its arguments are the run id three times and the reporting entity.
+const runRatesCondition = `r.rate_key IS NOT NULL AND (r.run_id = ? OR
+	(r.run_id < ? AND r.last_seen_run_id >= ? AND COALESCE(first.reporting_entity_name, '') = ?))`
+
+// DiffRuns compares the rates and providers of two ingestion runs by rate
+// key. Runs must have kept their data: staged runs replaced without
+// -keep-previous are deleted.
+func (s *DataIngestionService) DiffRuns(oldRunID, newRunID int64, d *diff.Diff) error {
+	var oldEntity, newEntity string
+	for _, run := range []struct {
+		id     int64
+		entity *string
+	}{{oldRunID, &oldEntity}, {newRunID, &newEntity}} {
+		err := s.db.QueryRow("SELECT COALESCE(reporting_entity_name, '') FROM ingestion_runs WHERE id = ?", run.id).Scan(run.entity)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("unknown run %d", run.id)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find run %d: %v", run.id, err)
+		}
+	}
+	if oldEntity != newEntity {
+		log.Printf("⚠️ Runs %d (%q) and %d (%q) are of different reporting entities, whose rate keys never match",
+			oldRunID, oldEntity, newRunID, newEntity)
+	}
+
+	oldCount, err := s.scanRunRates(oldRunID, oldEntity, func(r diff.Rate) error {
+		d.AddOld(r)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.scanRunNPIs(oldRunID, d.AddOldProvider); err != nil {
+		return err
+	}
+	if err := s.scanRunNPIs(newRunID, d.AddNewProvider); err != nil {
+		return err
+	}
+	newCount, err := s.scanRunRates(newRunID, newEntity, d.AddNew)
+	if err != nil {
+		return err
+	}
+
+	for _, run := range []struct {
+		id    int64
+		count int
+	}{{oldRunID, oldCount}, {newRunID, newCount}} {
+		if run.count == 0 {
+			log.Printf("⚠️ Run %d has no rates: it failed or was replaced in staging mode", run.id)
+		}
+	}
+	return nil
+}
+
+// scanRunRates calls handle for every rate a run of a reporting entity
+// published, returning the number of rates
+func (s *DataIngestionService) scanRunRates(runID int64, reportingEntity string, handle func(diff.Rate) error) (int, error) {
+	rows, err := s.db.Query(`
+		SELECT r.rate_key, s.billing_code_type, s.billing_code, r.billing_class, r.negotiated_type,
+			r.provider_references, r.negotiated_rate
+		FROM negotiated_rates r
+		JOIN insurance_services s ON s.id = r.service_id
+		LEFT JOIN ingestion_runs first ON first.id = r.run_id
+		WHERE `+runRatesCondition, runID, runID, runID, reportingEntity)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query rates of run %d: %v", runID, err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var r diff.Rate
+		var key []byte
+		var refsJSON string
+		if err := rows.Scan(&key, &r.BillingCodeType, &r.BillingCode, &r.BillingClass, &r.NegotiatedType, &refsJSON, &r.Rate); err != nil {
+			return count, fmt.Errorf("failed to scan rate: %v", err)
+		}
+		copy(r.Key[:], key)
+
+		var refs []int
+		if err := json.Unmarshal([]byte(refsJSON), &refs); err != nil {
+			return count, fmt.Errorf("failed to parse provider references %s: %v", refsJSON, err)
+		}
+		r.ProviderReferences = joinReferences(refs)
+
+		if err := handle(r); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("failed to read rates of run %d: %v", runID, err)
+	}
+	return count, nil
+}
+
+// scanRunNPIs calls handle for every NPI of the provider references of a run
+func (s *DataIngestionService) scanRunNPIs(runID int64, handle func(npi string)) error {
+	rows, err := s.db.Query("SELECT DISTINCT npi FROM provider_group_npis WHERE run_id = ?", runID)
+	if err != nil {
+		return fmt.Errorf("failed to query providers of run %d: %v", runID, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var npi string
+		if err := rows.Scan(&npi); err != nil {
+			return fmt.Errorf("failed to scan provider: %v", err)
+		}
+		handle(npi)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read providers of run %d: %v", runID, err)
+	}
+	return nil
+}
+
+// diffFiles compares the rates and providers of two files by rate key,
+// holding only the old file in memory. Keys leave out the reporting entity,
+// so files whose header names it differently still match.
+func diffFiles(oldFile, newFile string, d *diff.Diff) error {
+	for _, side := range []struct {
+		path     string
+		rate     func(diff.Rate) error
+		provider func(npi string)
+	}{
+		{oldFile, func(r diff.Rate) error { d.AddOld(r); return nil }, d.AddOldProvider},
+		{newFile, d.AddNew, d.AddNewProvider},
+	} {
+		log.Printf("📁 Reading file: %s", side.path)
+		rosters := newRosters()
+		_, _, err := scanFile(side.path, fileHandlers{
+			ProviderReference: func(ref ProviderReference) error {
+				rosters.add(ref)
+				for _, group := range ref.ProviderGroups {
+					for _, npi := range group.NPI {
+						side.provider(strconv.FormatInt(npi, 10))
+					}
+				}
+				return nil
+			},
+			Service: func(service InsuranceService) error {
+				for _, rate := range service.NegotiatedRates {
+					refs := joinReferences(rate.ProviderReferences)
+					for _, price := range rate.NegotiatedPrices {
+						r := diff.Rate{
+							BillingCodeType:    service.BillingCodeType,
+							BillingCode:        service.BillingCode,
+							BillingClass:       price.BillingClass,
+							NegotiatedType:     price.NegotiatedType,
+							ProviderReferences: refs,
+							Rate:               price.NegotiatedRate,
+						}
+						copy(r.Key[:], rateKey("", service, rosters.key(rate.ProviderReferences), price))
+						if err := side.rate(r); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", side.path, err)
+		}
+	}
+	return nil
+}
+
+// joinReferences renders provider references for the change log
+func joinReferences(refs []int) string {
+	parts := make([]string, len(refs))
+	for i, ref := range refs {
+		parts[i] = strconv.Itoa(ref)
+	}
+	return strings.Join(parts, "|")
+}
+
+// changeColumns are the columns of the CSV change log
+var changeColumns = []string{"kind", "billing_code_type", "billing_code", "billing_class", "negotiated_type",
+	"provider_references", "npi", "old_rate", "new_rate", "pct_change"}
+
+// newChangeWriter returns a function writing change log entries to w as csv
+// or ndjson, and one flushing them
+func newChangeWriter(w io.Writer, format string) (func(diff.Change) error, func() error, error) {
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		if err := writer.Write(changeColumns); err != nil {
+			return nil, nil, fmt.Errorf("failed to write change log: %v", err)
+		}
+		write := func(c diff.Change) error {
+			pct := ""
+			if c.PctChange != nil {
+				pct = strconv.FormatFloat(*c.PctChange, 'f', 1, 64)
+			}
+			return writer.Write([]string{c.Kind, c.BillingCodeType, c.BillingCode, c.BillingClass, c.NegotiatedType,
+				c.ProviderReferences, c.NPI, formatCSVValue(c.OldRate), formatCSVValue(c.NewRate), pct})
+		}
+		flush := func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+		return write, flush, nil
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		return func(c diff.Change) error { return encoder.Encode(c) }, func() error { return nil }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown change log format %q: use csv or ndjson", format)
+	}
+}
+
+// logDiffSummary logs the summary and alerts of a diff
+func logDiffSummary(summary diff.Summary, alerts []diff.Alert) {
+	log.Printf("📊 Rates: %d → %d, %d added, %d removed, %d increased, %d decreased, %d unchanged",
+		summary.OldRates, summary.NewRates, summary.RatesAdded, summary.RatesRemoved,
+		summary.RatesIncreased, summary.RatesDecreased, summary.RatesUnchanged)
+	if summary.RatesIncreased+summary.RatesDecreased > 0 {
+		log.Printf("📊 Changed rates: median %+.1f%%, largest increase %+.1f%%, largest decrease %+.1f%%",
+			summary.MedianPctChange, summary.MaxIncreasePct, summary.MaxDecreasePct)
+	}
+	log.Printf("📊 Billing codes: %d → %d, %d added, %d removed",
+		summary.OldCodes, summary.NewCodes, summary.CodesAdded, summary.CodesRemoved)
+	log.Printf("📊 Providers: %d → %d NPIs, %d added, %d dropped",
+		summary.OldProviders, summary.NewProviders, summary.ProvidersAdded, summary.ProvidersDropped)
+	if summary.DuplicateKeys > 0 {
+		log.Printf("⚠️ Ignored %d rates whose rate key repeats within a version", summary.DuplicateKeys)
+	}
+	for _, alert := range alerts {
+		log.Printf("🚨 %s", alert.Message)
+	}
+}
+
+// runDiff implements the diff mode: it compares two runs or two files and
+// writes the change log, logs the summary and raises alerts
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	var (
+		oldRun  = fs.Int64("old-run", 0, "Ingestion run id of the old version")
+		newRun  = fs.Int64("new-run", 0, "Ingestion run id of the new version")
+		oldFile = fs.String("old-file", "", "Old version file (.json or .json.gz), compared without a database")
+		newFile = fs.String("new-file", "", "New version file (.json or .json.gz)")
+		format  = fs.String("format", "csv", "Change log format: csv or ndjson")
+		out     = fs.String("out", "", "Change log file (default: stdout)")
+		summary = fs.String("summary", "", "Write the summary and alerts to this JSON file")
+
+		rateChangePct       = fs.Float64("alert-rate-change-pct", 0, "Alert when rates change by more than this percent (0 disables)")
+		removedRatesPct     = fs.Float64("alert-removed-rates-pct", 0, "Alert when more than this percent of the old rates are removed (0 disables)")
+		removedCodesPct     = fs.Float64("alert-removed-codes-pct", 0, "Alert when more than this percent of the old billing codes are removed (0 disables)")
+		droppedProvidersPct = fs.Float64("alert-dropped-providers-pct", 0, "Alert when more than this percent of the old providers are dropped (0 disables)")
+		webhook             = fs.String("alert-webhook", "", "POST alerts as JSON to this URL (Slack and Teams incoming webhooks work)")
+		failOnAlert         = fs.Bool("fail-on-alert", false, "Exit with an error when any alert is raised")
+	)
+	fs.Parse(args)
+
+	byRun := *oldRun != 0 || *newRun != 0
+	byFile := *oldFile != "" || *newFile != ""
+	if byRun == byFile || (byRun && (*oldRun == 0 || *newRun == 0)) || (byFile && (*oldFile == "" || *newFile == "")) {
+		return fmt.Errorf("please specify either -old-run and -new-run or -old-file and -new-file")
+	}
+
+	output, err := createOutput(*out)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	write, flush, err := newChangeWriter(output, *format)
+	if err != nil {
+		return err
+	}
+	d := diff.New(diff.Thresholds{
+		RateChangePct:       *rateChangePct,
+		RemovedRatesPct:     *removedRatesPct,
+		RemovedCodesPct:     *removedCodesPct,
+		DroppedProvidersPct: *droppedProvidersPct,
+	}, write)
+
+	title := fmt.Sprintf("Rate changes from %s to %s", *oldFile, *newFile)
+	if byRun {
+		title = fmt.Sprintf("Rate changes from run %d to run %d", *oldRun, *newRun)
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %v", err)
+		}
+		service, err := NewDataIngestionService(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create ingestion service: %v", err)
+		}
+		defer service.Close()
+
+		if err := service.DiffRuns(*oldRun, *newRun, d); err != nil {
+			return err
+		}
+	} else if err := diffFiles(*oldFile, *newFile, d); err != nil {
+		return err
+	}
+
+	result, alerts, err := d.Finish()
+	if err != nil {
+		return fmt.Errorf("failed to write change log: %v", err)
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf("failed to write change log: %v", err)
+	}
+	if err := output.Close(); err != nil {
+		return fmt.Errorf("failed to write change log: %v", err)
+	}
+	log.Printf("🔎 %s", title)
+	logDiffSummary(result, alerts)
+
+	if *summary != "" {
+		data, err := json.MarshalIndent(map[string]interface{}{"title": title, "summary": result, "alerts": alerts}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode summary: %v", err)
+		}
+		if err := os.WriteFile(*summary, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write summary: %v", err)
+		}
+	}
+
+	if len(alerts) == 0 {
+		return nil
+	}
+	if *webhook != "" {
+		if err := diff.PostAlerts(context.Background(), *webhook, title, result, alerts); err != nil {
+			return err
+		}
+		log.Printf("📣 Posted %d alerts", len(alerts))
+	}
+	if *failOnAlert {
+		return fmt.Errorf("%d alerts raised", len(alerts))
+	}
+	return nil
+}
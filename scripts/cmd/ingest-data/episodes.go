@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"healthcare-saver-ingest/internal/config"
+	"healthcare-saver-ingest/internal/episode"
+	"healthcare-saver-ingest/internal/store"
+)
+
+// episodeColumns is the header of CSV episode prices
+var episodeColumns = []string{"episode_id", "episode_name", "payer_name", "tin", "provider_name", "total", "complete", "estimated", "missing", "components"}
+
+// formatEpisodeLines describes the priced components of an episode price in
+// one CSV cell, such as "CPT 27447 professional x1 = 1830.00 (provider)"
+func formatEpisodeLines(lines []store.EpisodeLine) string {
+	parts := make([]string, len(lines))
+	for i, line := range lines {
+		amount := "n/a"
+		if line.Amount != nil {
+			amount = strconv.FormatFloat(*line.Amount, 'f', 2, 64)
+		}
+		parts[i] = fmt.Sprintf("%s %s %s x%d = %s (%s)", line.BillingCodeType, line.BillingCode, line.BillingClass, line.Units, amount, line.Source)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// WriteEpisodePrices writes the prices of episodes for every payer and
+// provider organization in a market as csv or json
+func (s *DataIngestionService) WriteEpisodePrices(w io.Writer, episodes []episode.Episode, market store.Market, payer, format string) error {
+	if format != "csv" && format != "json" {
+		return fmt.Errorf("unknown episode format %q: use csv or json", format)
+	}
+
+	st := store.New(s.db)
+	writer := csv.NewWriter(w)
+	if format == "csv" {
+		if err := writer.Write(episodeColumns); err != nil {
+			return fmt.Errorf("failed to write episode prices: %v", err)
+		}
+	}
+
+	var all []*store.EpisodePrices
+	priced := 0
+	for _, ep := range episodes {
+		prices, err := st.EpisodePrices(context.Background(), ep, market, payer, 1, 0)
+		if err != nil {
+			return err
+		}
+		log.Printf("🔎 %s: %d prices (%d complete) for %s", ep.ID, prices.Total, prices.Stats.Count, market)
+		priced += prices.Total
+
+		if format == "json" {
+			all = append(all, prices)
+			continue
+		}
+		for _, price := range prices.Prices {
+			record := []string{
+				ep.ID, ep.Name, price.PayerName, price.TIN, price.ProviderName,
+				strconv.FormatFloat(price.Total, 'f', 2, 64), strconv.FormatBool(price.Complete),
+				strings.Join(price.Estimated, "; "), strings.Join(price.Missing, "; "), formatEpisodeLines(price.Lines),
+			}
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write episode prices: %v", err)
+			}
+		}
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(all); err != nil {
+			return fmt.Errorf("failed to write episode prices: %v", err)
+		}
+	} else {
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("failed to write episode prices: %v", err)
+		}
+	}
+
+	log.Printf("📊 Priced %d episodes (%d payer and provider prices) for %s", len(episodes), priced, market)
+	return nil
+}
+
+// PrintEpisodes lists episode definitions with their components
+func PrintEpisodes(w io.Writer, episodes []episode.Episode) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCATEGORY\tNAME\tCOMPONENTS")
+	for _, ep := range episodes {
+		components := make([]string, 0, len(ep.Ancillaries)+1)
+		for _, component := range ep.Components() {
+			name := component.String()
+			if component.Units != 1 {
+				name += fmt.Sprintf(" x%d", component.Units)
+			}
+			if component.Optional {
+				name += " optional"
+			}
+			components = append(components, name)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", ep.ID, ep.Category, ep.Name, strings.Join(components, " + "))
+	}
+	return tw.Flush()
+}
+
+// runEpisodes implements the episodes mode: it prices episodes of care,
+// bundles of billing codes, per payer and provider organization
+func runEpisodes(args []string) error {
+	fs := flag.NewFlagSet("episodes", flag.ExitOnError)
+	var (
+		definitions = fs.String("episodes", "data/episodes.yaml", "Episode definitions (YAML)")
+		ids         = fs.String("episode", "", "Only price these episode ids (comma-separated)")
+		list        = fs.Bool("list", false, "List the episode definitions instead of pricing them")
+		payer       = fs.String("payer", "", "Only price episodes under this reporting entity")
+		state       = fs.String("state", "", "Market of providers in this two-letter state")
+		zip         = fs.String("zip", "", "Market of providers within -radius of this ZIP code")
+		radius      = fs.Float64("radius", 25, "Radius in miles around -zip")
+		format      = fs.String("format", "csv", "Output format: csv or json")
+		out         = fs.String("out", "", "Output file (default: stdout)")
+	)
+	fs.Parse(args)
+
+	episodes, err := episode.Load(*definitions)
+	if err != nil {
+		return err
+	}
+	if *ids != "" {
+		var selected []episode.Episode
+		for _, id := range splitList(*ids) {
+			ep, ok := episode.Find(episodes, id)
+			if !ok {
+				return fmt.Errorf("unknown episode %q in %s", id, *definitions)
+			}
+			selected = append(selected, ep)
+		}
+		episodes = selected
+	}
+	if *list {
+		return PrintEpisodes(os.Stdout, episodes)
+	}
+
+	market := store.Market{State: strings.ToUpper(*state)}
+	if *zip != "" {
+		if *radius <= 0 || *radius > store.MaxRadiusMiles {
+			return fmt.Errorf("-radius must be between 0 and %d miles", store.MaxRadiusMiles)
+		}
+		market.Near = &store.GeoFilter{ZIP: *zip, RadiusMiles: *radius}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+
+	output, err := createOutput(*out)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	if err := service.WriteEpisodePrices(output, episodes, market, *payer, *format); err != nil {
+		return err
+	}
+	if err := output.Close(); err != nil {
+		return fmt.Errorf("failed to write episode prices: %v", err)
+	}
+	return nil
+}
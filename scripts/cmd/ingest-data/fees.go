@@ -0,0 +1,179 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"healthcare-saver-ingest/internal/config"
+	"healthcare-saver-ingest/internal/outlier"
+)
+
+// feeColumns are the accepted header names of the billing code, amount,
+// billing code type, billing class, schedule and modifier columns of a
+// reference fee file, covering plain CSV files and the CMS PFS and OPPS
+// (Addendum B) downloads. Only the first two are required.
+var feeColumns = [][]string{
+	{"billing_code", "hcpcs", "hcpcs_code", "cpt", "cpt_code", "code"},
+	{"amount", "fee", "payment_rate", "payment", "non_facility_price", "price", "rate"},
+	{"billing_code_type", "code_type"},
+	{"billing_class"},
+	{"schedule", "fee_schedule"},
+	{"mod", "modifier"},
+}
+
+// ReferenceFee is the dollar amount a fee schedule pays for a billing code,
+// which percentage rates of the same billing class apply to
+type ReferenceFee struct {
+	BillingCodeType string
+	BillingCode     string
+	BillingClass    string
+	Amount          float64
+	Schedule        string
+}
+
+// feeKey identifies the reference fee of a billing code and billing class
+type feeKey struct {
+	billingCodeType, billingCode, billingClass string
+}
+
+// readReferenceFees calls handle for every fee in a comma or tab separated
+// file with a header row. Columns missing from the file take the values of
+// defaults; a missing billing code type is inferred from the code. Rows
+// with a modifier (e.g. the 26 and TC rows of the PFS) or without a positive
+// amount are skipped. It returns the number of fees read.
+func readReferenceFees(filePath string, defaults ReferenceFee, handle func(ReferenceFee) error) (int, error) {
+	read, skipped := 0, 0
+	err := readTable(filePath, feeColumns, 2, func(lineNumber int, values []string) error {
+		fee := defaults
+		fee.BillingCode = strings.ToUpper(values[0])
+		if values[2] != "" {
+			fee.BillingCodeType = strings.ToUpper(values[2])
+		} else if fee.BillingCodeType == "" {
+			fee.BillingCodeType = inferCodeType(fee.BillingCode)
+		}
+		if values[3] != "" {
+			fee.BillingClass = strings.ToLower(values[3])
+		}
+		if values[4] != "" {
+			fee.Schedule = values[4]
+		}
+		amount, amountErr := parseAmount(values[1])
+
+		if values[5] != "" || (amountErr == nil && amount <= 0) {
+			skipped++
+			return nil
+		}
+		if fee.BillingCode == "" || amountErr != nil ||
+			(fee.BillingClass != "professional" && fee.BillingClass != "institutional") {
+			log.Printf("⚠️ Skipping invalid reference fee on line %d", lineNumber)
+			return nil
+		}
+		fee.Amount = math.Round(amount*100) / 100
+
+		if err := handle(fee); err != nil {
+			return err
+		}
+		read++
+		return nil
+	})
+	if err != nil {
+		return read, err
+	}
+
+	if skipped > 0 {
+		log.Printf("📊 Skipped %d reference fees with a modifier or without an amount", skipped)
+	}
+	return read, nil
+}
+
+// inferCodeType returns CPT for five-character codes starting with a digit
+// (including Category II and III codes such as 0191T) and HCPCS otherwise
+func inferCodeType(code string) string {
+	if len(code) == 5 && code[0] >= '0' && code[0] <= '9' {
+		return "CPT"
+	}
+	return "HCPCS"
+}
+
+// LoadReferenceFees upserts a reference fee schedule, e.g. a CSV export of
+// the Medicare PFS or OPPS, which the API applies percentage rates to
+func (s *DataIngestionService) LoadReferenceFees(filePath string, defaults ReferenceFee) error {
+	log.Printf("📁 Loading reference fees: %s", filePath)
+
+	stmt, err := s.db.Prepare(`
+		INSERT INTO reference_fees (billing_code_type, billing_code, billing_class, amount, schedule)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE amount = VALUES(amount), schedule = VALUES(schedule)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare reference fee statement: %v", err)
+	}
+	defer stmt.Close()
+
+	var codes []outlier.Code
+	seen := make(map[outlier.Code]bool)
+	loaded, err := readReferenceFees(filePath, defaults, func(fee ReferenceFee) error {
+		if _, err := stmt.Exec(fee.BillingCodeType, fee.BillingCode, fee.BillingClass, fee.Amount, fee.Schedule); err != nil {
+			return fmt.Errorf("failed to upsert reference fee of %s %s: %v", fee.BillingCodeType, fee.BillingCode, err)
+		}
+		if code := (outlier.Code{BillingCodeType: fee.BillingCodeType, BillingCode: fee.BillingCode}); !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🎉 Loaded %d reference fees from %s", loaded, filePath)
+	return s.recordLoad("fees", false, codes)
+}
+
+// sampleReferenceFees holds approximate reference fees for development
+const sampleReferenceFees = "data/reference-fees.csv"
+
+// runFees implements the fees mode: it loads the reference fee schedule
+// percentage rates are resolved against
+func runFees(args []string) error {
+	fs := flag.NewFlagSet("fees", flag.ExitOnError)
+	var (
+		file         = fs.String("file", "", "Reference fee file (CSV or tab separated, optionally .gz)")
+		schedule     = fs.String("schedule", "", "Schedule name for rows without a schedule column (e.g. \"Medicare PFS 2025\")")
+		billingClass = fs.String("billing-class", "professional", "Billing class for rows without a billing_class column: professional or institutional")
+		sample       = fs.Bool("sample", false, "Load the approximate sample fees of "+sampleReferenceFees+", for development only")
+	)
+	fs.Parse(args)
+
+	// The sample amounts are rough, and effective rates computed from them
+	// would be published as if they were real
+	switch {
+	case *sample && *file != "":
+		return fmt.Errorf("-sample and -file cannot be combined")
+	case *sample:
+		log.Printf("⚠️ Loading the approximate sample fees of %s; do not use them in production", sampleReferenceFees)
+		*file = sampleReferenceFees
+	case *file == "":
+		return fmt.Errorf("-file is required (or -sample for the approximate development fees)")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+
+	if err := service.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create tables: %v", err)
+	}
+
+	return service.LoadReferenceFees(*file, ReferenceFee{BillingClass: *billingClass, Schedule: *schedule})
+}
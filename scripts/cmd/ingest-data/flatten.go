@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// flattenColumns maps the columns available to the flatten mode to the
+// extractor that renders that column of a flat row
+var flattenColumns = map[string]func(row flatRow) interface{}{
+	"negotiation_arrangement":   func(r flatRow) interface{} { return r.Service.NegotiationArrangement },
+	"name":                      func(r flatRow) interface{} { return r.Service.Name },
+	"billing_code_type":         func(r flatRow) interface{} { return r.Service.BillingCodeType },
+	"billing_code_type_version": func(r flatRow) interface{} { return r.Service.BillingCodeTypeVersion },
+	"billing_code":              func(r flatRow) interface{} { return r.Service.BillingCode },
+	"description":               func(r flatRow) interface{} { return r.Service.Description },
+	"provider_reference":        func(r flatRow) interface{} { return r.ProviderReference },
+	"negotiated_type":           func(r flatRow) interface{} { return r.Price.NegotiatedType },
+	"negotiated_rate":           func(r flatRow) interface{} { return r.Price.NegotiatedRate },
+	"expiration_date":           func(r flatRow) interface{} { return r.Price.ExpirationDate },
+	"service_codes":             func(r flatRow) interface{} { return r.Price.ServiceCode },
+	"billing_class":             func(r flatRow) interface{} { return r.Price.BillingClass },
+	"billing_code_modifiers":    func(r flatRow) interface{} { return r.Price.BillingCodeModifier },
+	"effective_rate":            func(r flatRow) interface{} { return r.EffectiveRate },
+	"comparable":                func(r flatRow) interface{} { return r.EffectiveRate != nil },
+}
+
+const defaultFlattenColumns = "billing_code,provider_reference,negotiated_type,negotiated_rate,billing_class,service_codes"
+
+// flatRow is one denormalized price for a single provider reference
+type flatRow struct {
+	Service           *InsuranceService
+	ProviderReference int
+	Price             *NegotiatedPrice
+	EffectiveRate     *float64 // dollar amount, nil for percentage rates without a reference fee
+}
+
+// FlattenOptions configures the flatten mode
+type FlattenOptions struct {
+	Format  string             // "csv" or "ndjson"
+	Columns []string           // output columns, see flattenColumns
+	Codes   []string           // only emit these billing codes (empty = all)
+	Fees    map[feeKey]float64 // reference fees percentage rates apply to
+}
+
+// Flattener writes services as denormalized CSV or NDJSON rows,
+// one row per negotiated price × provider reference
+type Flattener struct {
+	opts    FlattenOptions
+	codes   map[string]bool
+	csv     *csv.Writer
+	json    *json.Encoder
+	rows    int
+	skipped int
+}
+
+// NewFlattener validates the options and creates a flattener writing to w
+func NewFlattener(w io.Writer, opts FlattenOptions) (*Flattener, error) {
+	for _, col := range opts.Columns {
+		if _, ok := flattenColumns[col]; !ok {
+			return nil, fmt.Errorf("unknown column %q", col)
+		}
+	}
+
+	f := &Flattener{opts: opts}
+	if len(opts.Codes) > 0 {
+		f.codes = make(map[string]bool, len(opts.Codes))
+		for _, code := range opts.Codes {
+			f.codes[code] = true
+		}
+	}
+
+	switch opts.Format {
+	case "csv":
+		f.csv = csv.NewWriter(w)
+		if err := f.csv.Write(opts.Columns); err != nil {
+			return nil, fmt.Errorf("failed to write CSV header: %v", err)
+		}
+	case "ndjson":
+		f.json = json.NewEncoder(w)
+	default:
+		return nil, fmt.Errorf("unsupported format %q (use csv or ndjson)", opts.Format)
+	}
+
+	return f, nil
+}
+
+// Write emits all rows for a single service
+func (f *Flattener) Write(service InsuranceService) error {
+	if f.codes != nil && !f.codes[service.BillingCode] {
+		f.skipped++
+		return nil
+	}
+
+	for _, rate := range service.NegotiatedRates {
+		for i := range rate.NegotiatedPrices {
+			price := &rate.NegotiatedPrices[i]
+			effective := f.effectiveRate(service, price)
+			for _, ref := range rate.ProviderReferences {
+				if err := f.writeRow(flatRow{Service: &service, ProviderReference: ref, Price: price, EffectiveRate: effective}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// effectiveRate returns the dollar amount of a price: the rate of a dollar
+// price, a percentage rate applied to its reference fee, or nil
+func (f *Flattener) effectiveRate(service InsuranceService, price *NegotiatedPrice) *float64 {
+	switch price.NegotiatedType {
+	case "negotiated":
+		rate := price.NegotiatedRate
+		return &rate
+	case "percentage":
+		fee, ok := f.opts.Fees[feeKey{service.BillingCodeType, service.BillingCode, price.BillingClass}]
+		if ok {
+			amount := math.Round(fee*price.NegotiatedRate) / 100
+			return &amount
+		}
+	}
+	return nil
+}
+
+// writeRow renders the configured columns of a single row
+func (f *Flattener) writeRow(row flatRow) error {
+	f.rows++
+
+	if f.csv != nil {
+		record := make([]string, len(f.opts.Columns))
+		for i, col := range f.opts.Columns {
+			record[i] = formatCSVValue(flattenColumns[col](row))
+		}
+		if err := f.csv.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV row: %v", err)
+		}
+		return nil
+	}
+
+	record := make(map[string]interface{}, len(f.opts.Columns))
+	for _, col := range f.opts.Columns {
+		record[col] = flattenColumns[col](row)
+	}
+	if err := f.json.Encode(record); err != nil {
+		return fmt.Errorf("failed to write NDJSON row: %v", err)
+	}
+	return nil
+}
+
+// Flush flushes any buffered output
+func (f *Flattener) Flush() error {
+	if f.csv != nil {
+		f.csv.Flush()
+		return f.csv.Error()
+	}
+	return nil
+}
+
+// formatCSVValue renders a column value as a CSV field
+func formatCSVValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int:
+		return strconv.Itoa(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case *float64:
+		if val == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*val, 'f', -1, 64)
+	case []string:
+		return strings.Join(val, "|")
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// runFlatten implements the flatten mode: it parses MRF files with the same
+// path as ProcessFile but writes denormalized rows instead of using MySQL
+func runFlatten(args []string) error {
+	fs := flag.NewFlagSet("flatten", flag.ExitOnError)
+	var (
+		file    = fs.String("file", "", "File to flatten (.json or .json.gz)")
+		dir     = fs.String("dir", "", "Directory to flatten (all .json files)")
+		format  = fs.String("format", "csv", "Output format: csv or ndjson")
+		out     = fs.String("out", "", "Output file (default: stdout)")
+		columns = fs.String("columns", defaultFlattenColumns, "Comma-separated output columns")
+		codes   = fs.String("codes", "", "Comma-separated billing codes to include (default: all)")
+		fees    = fs.String("fees", "", "Reference fee file for the effective_rate column (see the fees mode)")
+	)
+	fs.Parse(args)
+
+	var feeAmounts map[feeKey]float64
+	if *fees != "" {
+		feeAmounts = make(map[feeKey]float64)
+		_, err := readReferenceFees(*fees, ReferenceFee{BillingClass: "professional"}, func(fee ReferenceFee) error {
+			feeAmounts[feeKey{fee.BillingCodeType, fee.BillingCode, fee.BillingClass}] = fee.Amount
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read reference fees: %v", err)
+		}
+	}
+
+	var files []string
+	if *file != "" {
+		files = []string{*file}
+	} else if *dir != "" {
+		matches, err := filepath.Glob(filepath.Join(*dir, "*.json*"))
+		if err != nil {
+			return fmt.Errorf("failed to find files: %v", err)
+		}
+		files = matches
+	} else {
+		return fmt.Errorf("please specify either -file or -dir flag")
+	}
+
+	output, err := createOutput(*out)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	flattener, err := NewFlattener(output, FlattenOptions{
+		Format:  *format,
+		Columns: splitList(*columns),
+		Codes:   splitList(*codes),
+		Fees:    feeAmounts,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, path := range files {
+		log.Printf("📁 Flattening file: %s", path)
+		if _, _, err := scanServices(path, flattener.Write); err != nil {
+			return fmt.Errorf("failed to flatten %s: %v", path, err)
+		}
+	}
+
+	if err := flattener.Flush(); err != nil {
+		return err
+	}
+	if err := output.Close(); err != nil {
+		return fmt.Errorf("failed to write output: %v", err)
+	}
+
+	log.Printf("🎉 Wrote %d rows (%d services filtered out)", flattener.rows, flattener.skipped)
+	return nil
+}
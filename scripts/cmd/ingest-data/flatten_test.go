@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// flattenService has two provider reference arrays: one price for references
+// 1 and 2, and two prices for reference 3
+var flattenService = InsuranceService{
+	BillingCodeType: "CPT",
+	BillingCode:     "99213",
+	NegotiatedRates: []NegotiatedRate{
+		{
+			ProviderReferences: []int{1, 2},
+			NegotiatedPrices: []NegotiatedPrice{
+				{NegotiatedType: "negotiated", NegotiatedRate: 95.5, BillingClass: "professional", ServiceCode: []string{"11", "22"}},
+			},
+		},
+		{
+			ProviderReferences: []int{3},
+			NegotiatedPrices: []NegotiatedPrice{
+				{NegotiatedType: "negotiated", NegotiatedRate: 80, BillingClass: "professional", ServiceCode: []string{"11"}},
+				{NegotiatedType: "percentage", NegotiatedRate: 150, BillingClass: "institutional"},
+			},
+		},
+	},
+}
+
+func TestFlattener(t *testing.T) {
+	other := flattenService
+	other.BillingCode = "70551"
+
+	for _, tc := range []struct {
+		name    string
+		opts    FlattenOptions
+		want    string
+		rows    int
+		skipped int
+	}{
+		{
+			name: "one CSV row per price and provider reference, service codes joined by |",
+			opts: FlattenOptions{Format: "csv", Columns: []string{"billing_code", "provider_reference", "negotiated_rate", "service_codes"}},
+			want: "billing_code,provider_reference,negotiated_rate,service_codes\n" +
+				"99213,1,95.5,11|22\n99213,2,95.5,11|22\n99213,3,80,11\n99213,3,150,\n" +
+				"70551,1,95.5,11|22\n70551,2,95.5,11|22\n70551,3,80,11\n70551,3,150,\n",
+			rows: 8,
+		},
+		{
+			name: "NDJSON keeps service codes as arrays",
+			opts: FlattenOptions{Format: "ndjson", Columns: []string{"provider_reference", "service_codes"}, Codes: []string{"70551"}},
+			want: `{"provider_reference":1,"service_codes":["11","22"]}` + "\n" +
+				`{"provider_reference":2,"service_codes":["11","22"]}` + "\n" +
+				`{"provider_reference":3,"service_codes":["11"]}` + "\n" +
+				`{"provider_reference":3,"service_codes":null}` + "\n",
+			rows:    4,
+			skipped: 1,
+		},
+		{
+			name:    "codes filter skips whole services",
+			opts:    FlattenOptions{Format: "csv", Columns: []string{"billing_code"}, Codes: []string{"00000"}},
+			want:    "billing_code\n",
+			skipped: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			f, err := NewFlattener(&out, tc.opts)
+			if err != nil {
+				t.Fatalf("NewFlattener: %v", err)
+			}
+			for _, service := range []InsuranceService{flattenService, other} {
+				if err := f.Write(service); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if err := f.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("output =\n%s\nwant\n%s", out.String(), tc.want)
+			}
+			if f.rows != tc.rows || f.skipped != tc.skipped {
+				t.Errorf("rows, skipped = %d, %d, want %d, %d", f.rows, f.skipped, tc.rows, tc.skipped)
+			}
+		})
+	}
+}
+
+func TestNewFlattenerRejectsInvalidOptions(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts FlattenOptions
+		want string
+	}{
+		{"unknown column", FlattenOptions{Format: "csv", Columns: []string{"billing_code", "npi"}}, `unknown column "npi"`},
+		{"unknown format", FlattenOptions{Format: "parquet", Columns: []string{"billing_code"}}, `unsupported format "parquet"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if _, err := NewFlattener(&out, tc.opts); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("NewFlattener = %v, want an error containing %s", err, tc.want)
+			}
+			if out.Len() != 0 {
+				t.Errorf("wrote %q before rejecting the options", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"healthcare-saver-ingest/internal/config"
+)
+
+// historyFilter selects the rates whose history is printed
+type historyFilter struct {
+	BillingCode string
+	NPI         string // provider NPI, resolved through the roster of each rate's last run
+	RateKey     []byte // prefix of the rate key
+	Payer       string // reporting entity name
+}
+
+// PrintRateHistory writes the effective periods of every rate of a billing
+// code matching filter, oldest first within each rate. Provider group ids are
+// renumbered between files, so providers are matched by NPI.
+func (s *DataIngestionService) PrintRateHistory(w io.Writer, filter historyFilter) error {
+	conditions := []string{"s.billing_code = ?", "r.valid_from IS NOT NULL"}
+	args := []interface{}{filter.BillingCode}
+	if filter.NPI != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM negotiated_rate_provider_groups pg
+			JOIN provider_group_npis pn ON pn.provider_group_id = pg.provider_group_id AND pn.run_id = r.last_seen_run_id
+			WHERE pg.negotiated_rate_id = r.id AND pn.npi = ?)`)
+		args = append(args, filter.NPI)
+	}
+	if len(filter.RateKey) > 0 {
+		conditions = append(conditions, "LEFT(r.rate_key, ?) = ?")
+		args = append(args, len(filter.RateKey), filter.RateKey)
+	}
+	if filter.Payer != "" {
+		conditions = append(conditions, "ir.reporting_entity_name = ?")
+		args = append(args, filter.Payer)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT HEX(LEFT(r.rate_key, 4)), COALESCE(ir.reporting_entity_name, ''), s.name,
+			r.negotiated_type, r.billing_class, r.service_codes, r.negotiated_rate,
+			DATE_FORMAT(r.expiration_date, '%Y-%m-%d'),
+			DATE_FORMAT(r.valid_from, '%Y-%m-%d'), COALESCE(DATE_FORMAT(r.valid_to, '%Y-%m-%d'), '')
+		FROM negotiated_rates r
+		JOIN insurance_services s ON s.id = r.service_id
+		LEFT JOIN ingestion_runs ir ON ir.id = r.run_id
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY r.rate_key, r.valid_from
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query rate history: %v", err)
+	}
+	defer rows.Close()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RATE\tENTITY\tNAME\tTYPE\tCLASS\tSERVICE CODES\tRATE\tEXPIRES\tVALID FROM\tVALID TO")
+
+	count := 0
+	for rows.Next() {
+		var key, entity, name, negotiatedType, billingClass, serviceCodes, expiration, validFrom, validTo string
+		var rate float64
+		if err := rows.Scan(&key, &entity, &name, &negotiatedType, &billingClass, &serviceCodes, &rate, &expiration, &validFrom, &validTo); err != nil {
+			return fmt.Errorf("failed to scan rate history: %v", err)
+		}
+		if validTo == "" {
+			validTo = "current"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%.2f\t%s\t%s\t%s\n",
+			strings.ToLower(key), entity, name, negotiatedType, billingClass, serviceCodes, rate, expiration, validFrom, validTo)
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rate history: %v", err)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	log.Printf("📜 %d rate periods for code %s", count, filter.BillingCode)
+	return nil
+}
+
+// runHistory implements the history mode: it prints the effective periods
+// of the rates for a billing code at a provider or with a rate key
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	var (
+		code  = fs.String("code", "", "Billing code")
+		npi   = fs.String("npi", "", "Provider NPI")
+		rate  = fs.String("rate", "", "Rate key or its prefix in hex, as in the RATE column")
+		payer = fs.String("payer", "", "Only rates of this reporting entity")
+	)
+	fs.Parse(args)
+
+	if *code == "" || (*npi == "" && *rate == "") {
+		return fmt.Errorf("please specify -code and -npi or -rate")
+	}
+	filter := historyFilter{BillingCode: *code, NPI: *npi, Payer: *payer}
+	if *rate != "" {
+		key, err := hex.DecodeString(*rate)
+		if err != nil {
+			return fmt.Errorf("invalid -rate %q: %v", *rate, err)
+		}
+		filter.RateKey = key
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+
+	return service.PrintRateHistory(os.Stdout, filter)
+}
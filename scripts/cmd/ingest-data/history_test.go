@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPrintRateHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock database: %v", err)
+	}
+	defer db.Close()
+	s := &DataIngestionService{db: db}
+
+	columns := []string{"key", "entity", "name", "type", "class", "codes", "rate", "expires", "from", "to"}
+	mock.ExpectQuery(`pn.run_id = r.last_seen_run_id\s+WHERE pg.negotiated_rate_id = r.id AND pn.npi = \?\) AND ir.reporting_entity_name = \?`).
+		WithArgs("70551", "1234567893", "Acme Health").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("9F3A01C2", "Acme Health", "MRI brain", "negotiated", "professional", `["11"]`, 410.5, "2025-12-31", "2024-01-01", "2024-02-01").
+			AddRow("9F3A01C2", "Acme Health", "MRI brain", "negotiated", "professional", `["11"]`, 425.0, "2025-12-31", "2024-02-01", ""))
+	mock.ExpectQuery(`LEFT\(r.rate_key, \?\) = \?`).
+		WithArgs("70551", 4, []byte{0x9f, 0x3a, 0x01, 0xc2}).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	var out bytes.Buffer
+	if err := s.PrintRateHistory(&out, historyFilter{BillingCode: "70551", NPI: "1234567893", Payer: "Acme Health"}); err != nil {
+		t.Fatalf("PrintRateHistory by NPI: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "9f3a01c2") || !strings.HasSuffix(lines[2], "current") {
+		t.Errorf("history by NPI =\n%s\nwant a header and two periods of rate 9f3a01c2, the last current", out.String())
+	}
+
+	if err := s.PrintRateHistory(&out, historyFilter{BillingCode: "70551", RateKey: []byte{0x9f, 0x3a, 0x01, 0xc2}}); err != nil {
+		t.Fatalf("PrintRateHistory by rate key: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,602 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"healthcare-saver-ingest/internal/aggregate"
+	"healthcare-saver-ingest/internal/config"
+	"healthcare-saver-ingest/internal/outlier"
+)
+
+// ProcessFile processes a single file (supports both .json and .json.gz)
+func (s *DataIngestionService) ProcessFile(filePath string, workers int) error {
+	log.Printf("📁 Processing file: %s", filePath)
+
+	if err := s.startRun(filePath); err != nil {
+		return err
+	}
+
+	// Channel for processing services
+	serviceChan := make(chan InsuranceService, workers*2)
+
+	// Wait group for workers
+	var wg sync.WaitGroup
+
+	// Start workers
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go s.worker(&wg, serviceChan)
+	}
+
+	// The header is only applied before the first service is handed to the
+	// workers, so workers never see it change
+	sent := false
+	lineCount, processedCount, err := scanFile(filePath, fileHandlers{
+		Header: func(header FileHeader) error {
+			if sent {
+				log.Printf("⚠️ Ignoring file header after the first service")
+				return nil
+			}
+			return s.applyHeader(header)
+		},
+		ProviderReference: s.insertProviderReference,
+		Service: func(service InsuranceService) error {
+			if !sent && s.history && s.run.LastUpdatedOn == "" {
+				return fmt.Errorf("history mode requires last_updated_on: add a file header line or use -last-updated-on")
+			}
+			sent = true
+			serviceChan <- service
+			return nil
+		},
+	})
+
+	// Close channel and wait for workers
+	close(serviceChan)
+	wg.Wait()
+
+	if err == nil && s.history {
+		err = s.closeDroppedRates()
+	}
+
+	// Outliers are quarantined before a staged run is activated
+	if err == nil {
+		err = s.detectOutliers(workers)
+	}
+
+	if err == nil && s.staging {
+		err = s.publishStagedRun(processedCount)
+	}
+
+	if err != nil {
+		s.finishRun("failed", processedCount)
+		if s.staging {
+			log.Printf("🧹 Discarding staged data of run %d", s.run.ID)
+			if err := s.deleteRunData(s.run.ID); err != nil {
+				log.Printf("⚠️ Failed to discard staged data: %v", err)
+			}
+		} else {
+			// Whatever was loaded before the failure is visible
+			s.refreshAggregates(workers)
+		}
+		return err
+	}
+
+	// Aggregates are refreshed before the run is marked completed, which is
+	// when API servers invalidate their cached responses
+	s.refreshAggregates(workers)
+
+	if err := s.finishRun("completed", processedCount); err != nil {
+		return err
+	}
+
+	log.Printf("🎉 Successfully processed %d lines, %d services from %s", lineCount, processedCount, filePath)
+	return nil
+}
+
+// ingestStatements holds the prepared statements a worker uses to insert a service
+type ingestStatements struct {
+	service        *sql.Stmt
+	rate           *sql.Stmt
+	providerGroup  *sql.Stmt
+	placeOfService *sql.Stmt
+	findService    *sql.Stmt
+	currentRate    *sql.Stmt
+	extendRate     *sql.Stmt
+	closeRate      *sql.Stmt
+	unlinkRate     *sql.Stmt
+}
+
+// prepareIngestStatements prepares all statements used by processService
+func (s *DataIngestionService) prepareIngestStatements() (*ingestStatements, error) {
+	var stmts ingestStatements
+	var err error
+
+	stmts.service, err = s.db.Prepare(`
+		INSERT INTO insurance_services 
+		(negotiation_arrangement, name, billing_code_type, billing_code_type_version, billing_code, description, run_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare service statement: %v", err)
+	}
+
+	stmts.rate, err = s.db.Prepare(`
+		INSERT INTO negotiated_rates 
+		(service_id, provider_references, negotiated_type, negotiated_rate, expiration_date, service_codes, billing_class,
+		 rate_key, valid_from, run_id, last_seen_run_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		stmts.Close()
+		return nil, fmt.Errorf("failed to prepare rate statement: %v", err)
+	}
+
+	stmts.providerGroup, err = s.db.Prepare(`
+		INSERT IGNORE INTO negotiated_rate_provider_groups (negotiated_rate_id, provider_group_id)
+		VALUES (?, ?)
+	`)
+	if err != nil {
+		stmts.Close()
+		return nil, fmt.Errorf("failed to prepare provider group statement: %v", err)
+	}
+
+	stmts.placeOfService, err = s.db.Prepare(`
+		INSERT IGNORE INTO negotiated_rate_places_of_service (negotiated_rate_id, place_of_service)
+		VALUES (?, ?)
+	`)
+	if err != nil {
+		stmts.Close()
+		return nil, fmt.Errorf("failed to prepare place of service statement: %v", err)
+	}
+
+	// Statements used by history mode. A service is only shared with earlier
+	// history runs of the same reporting entity: services of other payers are
+	// deleted with their runs when a staged version replaces them.
+	stmts.findService, err = s.db.Prepare(`
+		SELECT s.id FROM insurance_services s
+		JOIN ingestion_runs ir ON ir.id = s.run_id
+		WHERE s.billing_code_type = ? AND s.billing_code = ? AND s.negotiation_arrangement = ?
+			AND ir.reporting_entity_name <=> ? AND ir.staging = 0
+		ORDER BY s.id LIMIT 1
+	`)
+	if err != nil {
+		stmts.Close()
+		return nil, fmt.Errorf("failed to prepare find service statement: %v", err)
+	}
+
+	stmts.currentRate, err = s.db.Prepare(`
+		SELECT id, negotiated_rate, expiration_date, provider_references FROM negotiated_rates
+		WHERE rate_key = ? AND valid_to IS NULL
+		ORDER BY id DESC LIMIT 1
+		FOR UPDATE
+	`)
+	if err != nil {
+		stmts.Close()
+		return nil, fmt.Errorf("failed to prepare current rate statement: %v", err)
+	}
+
+	stmts.extendRate, err = s.db.Prepare("UPDATE negotiated_rates SET last_seen_run_id = ?, provider_references = ? WHERE id = ?")
+	if err != nil {
+		stmts.Close()
+		return nil, fmt.Errorf("failed to prepare extend rate statement: %v", err)
+	}
+
+	stmts.closeRate, err = s.db.Prepare("UPDATE negotiated_rates SET valid_to = ?, closed_run_id = ? WHERE id = ?")
+	if err != nil {
+		stmts.Close()
+		return nil, fmt.Errorf("failed to prepare close rate statement: %v", err)
+	}
+
+	stmts.unlinkRate, err = s.db.Prepare("DELETE FROM negotiated_rate_provider_groups WHERE negotiated_rate_id = ?")
+	if err != nil {
+		stmts.Close()
+		return nil, fmt.Errorf("failed to prepare unlink rate statement: %v", err)
+	}
+
+	return &stmts, nil
+}
+
+// Close closes all prepared statements
+func (st *ingestStatements) Close() {
+	for _, stmt := range []*sql.Stmt{
+		st.service, st.rate, st.providerGroup, st.placeOfService,
+		st.findService, st.currentRate, st.extendRate, st.closeRate, st.unlinkRate,
+	} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+// worker processes services from the channel
+func (s *DataIngestionService) worker(wg *sync.WaitGroup, serviceChan <-chan InsuranceService) {
+	defer wg.Done()
+
+	// Prepare statements
+	stmts, err := s.prepareIngestStatements()
+	if err != nil {
+		log.Printf("❌ %v", err)
+		return
+	}
+	defer stmts.Close()
+
+	// Process services
+	for service := range serviceChan {
+		if err := s.processService(stmts, service); err != nil {
+			log.Printf("❌ Failed to process service %s: %v", service.Name, err)
+		}
+	}
+}
+
+// processService inserts a single service and its rates
+func (s *DataIngestionService) processService(stmts *ingestStatements, service InsuranceService) error {
+	// Start transaction
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// History mode keeps one service row per code and reporting entity so
+	// rates of successive files can be compared
+	var serviceID int64
+	if s.history {
+		err = tx.Stmt(stmts.findService).QueryRow(
+			service.BillingCodeType,
+			service.BillingCode,
+			service.NegotiationArrangement,
+			nullString(s.run.ReportingEntityName),
+		).Scan(&serviceID)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to find service: %v", err)
+		}
+	}
+
+	// Insert service
+	if serviceID == 0 {
+		result, err := tx.Stmt(stmts.service).Exec(
+			service.NegotiationArrangement,
+			service.Name,
+			service.BillingCodeType,
+			service.BillingCodeTypeVersion,
+			service.BillingCode,
+			service.Description,
+			s.run.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert service: %v", err)
+		}
+
+		serviceID, err = result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get service ID: %v", err)
+		}
+	}
+
+	if err := s.insertRates(tx, stmts, serviceID, service); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// insertRates writes one negotiated_rates row per price per provider reference
+// array. In history mode a price equal to the current row for its rate key only
+// extends that row; a changed price closes it and starts a new period.
+func (s *DataIngestionService) insertRates(tx *sql.Tx, stmts *ingestStatements, serviceID int64, service InsuranceService) error {
+	rateStmt := tx.Stmt(stmts.rate)
+	providerGroupStmt := tx.Stmt(stmts.providerGroup)
+	placeOfServiceStmt := tx.Stmt(stmts.placeOfService)
+
+	run := s.run
+	validFrom := nullString(run.LastUpdatedOn)
+
+	// Insert negotiated rates
+	for _, rate := range service.NegotiatedRates {
+		providerRefsJSON, err := json.Marshal(rate.ProviderReferences)
+		if err != nil {
+			return fmt.Errorf("failed to marshal provider references: %v", err)
+		}
+
+		for _, price := range rate.NegotiatedPrices {
+			s.runPrices.Add(1)
+			key := rateKey(run.ReportingEntityName, service, s.rosters.key(rate.ProviderReferences), price)
+
+			if s.history {
+				extended, err := s.extendCurrentRate(tx, stmts, key, price, rate.ProviderReferences, string(providerRefsJSON))
+				if err != nil {
+					return err
+				}
+				if extended {
+					continue
+				}
+			}
+
+			serviceCodesJSON, err := json.Marshal(price.ServiceCode)
+			if err != nil {
+				return fmt.Errorf("failed to marshal service codes: %v", err)
+			}
+
+			result, err := rateStmt.Exec(
+				serviceID,
+				string(providerRefsJSON),
+				price.NegotiatedType,
+				price.NegotiatedRate,
+				price.ExpirationDate,
+				string(serviceCodesJSON),
+				price.BillingClass,
+				key,
+				validFrom,
+				run.ID,
+				run.ID,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert rate: %v", err)
+			}
+
+			rateID, err := result.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("failed to get rate ID: %v", err)
+			}
+
+			// Insert normalized provider group and place of service links
+			for _, ref := range rate.ProviderReferences {
+				if _, err := providerGroupStmt.Exec(rateID, ref); err != nil {
+					return fmt.Errorf("failed to insert provider group link: %v", err)
+				}
+			}
+
+			for _, code := range price.ServiceCode {
+				if _, err := placeOfServiceStmt.Exec(rateID, code); err != nil {
+					return fmt.Errorf("failed to insert place of service link: %v", err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// extendCurrentRate looks up the open period for a rate key. If the price is
+// unchanged the period is extended to the current run and true is returned;
+// if it changed the period is closed at the file's last_updated_on.
+// Rate keys resolve provider references to their providers, so an extended
+// rate is relinked to the references of the current file, which may number
+// the same providers differently.
+func (s *DataIngestionService) extendCurrentRate(tx *sql.Tx, stmts *ingestStatements, key []byte, price NegotiatedPrice, providerRefs []int, providerRefsJSON string) (bool, error) {
+	var (
+		currentID         int64
+		currentRate       float64
+		currentExpiration time.Time
+		currentRefsJSON   string
+	)
+	err := tx.Stmt(stmts.currentRate).QueryRow(key).Scan(&currentID, &currentRate, &currentExpiration, &currentRefsJSON)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to find current rate: %v", err)
+	}
+
+	sameRate := strconv.FormatFloat(currentRate, 'f', 2, 64) == strconv.FormatFloat(price.NegotiatedRate, 'f', 2, 64)
+	sameExpiration := currentExpiration.Format("2006-01-02") == price.ExpirationDate
+	if sameRate && sameExpiration {
+		if _, err := tx.Stmt(stmts.extendRate).Exec(s.run.ID, providerRefsJSON, currentID); err != nil {
+			return false, fmt.Errorf("failed to extend rate: %v", err)
+		}
+		if currentRefsJSON != providerRefsJSON {
+			if _, err := tx.Stmt(stmts.unlinkRate).Exec(currentID); err != nil {
+				return false, fmt.Errorf("failed to unlink rate: %v", err)
+			}
+			for _, ref := range providerRefs {
+				if _, err := tx.Stmt(stmts.providerGroup).Exec(currentID, ref); err != nil {
+					return false, fmt.Errorf("failed to insert provider group link: %v", err)
+				}
+			}
+		}
+		return true, nil
+	}
+
+	if _, err := tx.Stmt(stmts.closeRate).Exec(s.run.LastUpdatedOn, s.run.ID, currentID); err != nil {
+		return false, fmt.Errorf("failed to close rate: %v", err)
+	}
+	return false, nil
+}
+
+// closeDroppedRates ends the open periods of rates the reporting entity
+// published before but left out of the current file
+func (s *DataIngestionService) closeDroppedRates() error {
+	if s.run.ReportingEntityName == "" {
+		log.Printf("⚠️ Unknown reporting entity, rates missing from this file stay open (use -reporting-entity)")
+		return nil
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE negotiated_rates r
+		JOIN ingestion_runs ir ON ir.id = r.last_seen_run_id
+		SET r.valid_to = ?, r.closed_run_id = ?
+		WHERE r.valid_to IS NULL AND ir.reporting_entity_name = ? AND r.last_seen_run_id <> ?
+	`, s.run.LastUpdatedOn, s.run.ID, s.run.ReportingEntityName, s.run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to close dropped rates: %v", err)
+	}
+
+	if closed, err := result.RowsAffected(); err == nil && closed > 0 {
+		log.Printf("📉 Closed %d rates no longer published by %s", closed, s.run.ReportingEntityName)
+	}
+	return nil
+}
+
+// refreshAggregates recomputes the rate aggregates of the billing codes the
+// current run loaded or replaced. Failures are only logged: the run's data
+// is already visible, and the aggregate mode can repair the aggregates.
+func (s *DataIngestionService) refreshAggregates(workers int) {
+	if s.skipAggregates {
+		return
+	}
+	start := time.Now()
+	aggregates := aggregate.New(s.db)
+
+	groups, err := aggregates.RunGroups(context.Background(), s.run.ID)
+	if err != nil {
+		log.Printf("⚠️ Failed to refresh rate aggregates: %v", err)
+		return
+	}
+
+	seen := make(map[aggregate.Group]bool, len(groups))
+	for _, g := range groups {
+		seen[g] = true
+	}
+	for _, g := range s.replaced {
+		if !seen[g] {
+			seen[g] = true
+			groups = append(groups, g)
+		}
+	}
+
+	if err := aggregates.Refresh(context.Background(), groups, workers, nil); err != nil {
+		log.Printf("⚠️ Failed to refresh rate aggregates, run the aggregate mode to repair them: %v", err)
+		return
+	}
+	log.Printf("📊 Refreshed rate aggregates of %d billing codes in %v", len(groups), time.Since(start).Round(time.Millisecond))
+}
+
+// detectOutliers flags the outlier rates of the billing codes the current run
+// loaded, and quarantines its extreme rates when asked to. A failed analysis
+// only fails the run when rates should have been quarantined.
+func (s *DataIngestionService) detectOutliers(workers int) error {
+	if s.skipOutliers {
+		return nil
+	}
+	start := time.Now()
+	detector := outlier.New(s.db, s.outliers)
+	detector.RunID = s.run.ID
+	detector.Quarantine = s.quarantineOutliers
+
+	codes, err := detector.RunCodes(context.Background(), s.run.ID)
+	if err == nil {
+		var result outlier.Result
+		result, err = detector.Analyze(context.Background(), codes, workers, nil)
+		if err == nil {
+			log.Printf("🔍 Flagged %d outlier rates of %d billing codes, %d quarantined, in %v",
+				result.Flagged, len(codes), result.Quarantined, time.Since(start).Round(time.Millisecond))
+			return nil
+		}
+	}
+
+	if s.quarantineOutliers {
+		return fmt.Errorf("outlier analysis failed: %v", err)
+	}
+	log.Printf("⚠️ Failed to flag outlier rates, run the outliers mode to repair them: %v", err)
+	return nil
+}
+
+// runIngest implements the default mode: it brings the schema up to date and
+// loads the payer files given by -file or -dir
+func runIngest(args []string) error {
+	fs := flag.NewFlagSet("ingest-data", flag.ExitOnError)
+	var (
+		workers = fs.Int("workers", 10, "Number of worker goroutines")
+		file    = fs.String("file", "", "File to process (.json or .json.gz)")
+		dir     = fs.String("dir", "", "Directory to process (all .json files)")
+		history = fs.Bool("history", false, "Keep rate history with effective periods instead of appending rates")
+		staging = fs.Bool("staging", false, "Load each file as a staged run and activate it only after validation")
+
+		stagingMinRatio = fs.Float64("staging-min-ratio", 0.5, "Minimum rate count of a staged run relative to the active version (0 disables)")
+		keepPrevious    = fs.Bool("keep-previous", false, "Keep the data of the previous version after activating a staged run")
+
+		lastUpdatedOn   = fs.String("last-updated-on", "", "File last_updated_on (YYYY-MM-DD), overrides the file header")
+		reportingEntity = fs.String("reporting-entity", "", "Reporting entity name, overrides the file header")
+
+		skipAggregates = fs.Bool("skip-aggregates", false, "Do not refresh rate aggregates after each file (run the aggregate mode afterwards)")
+
+		skipOutliers       = fs.Bool("skip-outliers", false, "Do not flag outlier rates after each file (run the outliers mode afterwards)")
+		quarantineOutliers = fs.Bool("quarantine-outliers", false, "Quarantine the extreme rates of each file instead of publishing them (requires -staging)")
+	)
+	outliers := outlierFlags(fs)
+	fs.Parse(args)
+
+	if *file == "" && *dir == "" {
+		return fmt.Errorf("please specify either -file or -dir (or the migrate mode to only update the schema)")
+	}
+	if *staging && *history {
+		return fmt.Errorf("-staging cannot be combined with -history")
+	}
+	if *quarantineOutliers && *skipOutliers {
+		return fmt.Errorf("-quarantine-outliers cannot be combined with -skip-outliers")
+	}
+	// Without staging the rates of the file are published as they are loaded,
+	// before the analysis could hide the extreme ones
+	if *quarantineOutliers && !*staging {
+		return fmt.Errorf("-quarantine-outliers requires -staging")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+	service.history = *history
+	service.lastUpdatedOn = *lastUpdatedOn
+	service.reportingEntity = *reportingEntity
+	service.staging = *staging
+	service.stagingMinRatio = *stagingMinRatio
+	service.keepPrevious = *keepPrevious
+	service.skipAggregates = *skipAggregates
+	service.outliers = *outliers
+	service.skipOutliers = *skipOutliers
+	service.quarantineOutliers = *quarantineOutliers
+
+	if err := service.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create tables: %v", err)
+	}
+	if err := service.RunMigrations(); err != nil {
+		return fmt.Errorf("failed to run migrations: %v", err)
+	}
+
+	// Process file or directory
+	if *file != "" {
+		if err := service.ProcessFile(*file, *workers); err != nil {
+			return fmt.Errorf("failed to process file: %v", err)
+		}
+	} else {
+		files, err := filepath.Glob(filepath.Join(*dir, "*.json*"))
+		if err != nil {
+			return fmt.Errorf("failed to find files: %v", err)
+		}
+
+		for _, file := range files {
+			log.Printf("🔄 Processing file: %s", file)
+			if err := service.ProcessFile(file, *workers); err != nil {
+				log.Printf("❌ Failed to process file %s: %v", file, err)
+			}
+		}
+	}
+
+	// Show statistics
+	if err := service.GetStatistics(); err != nil {
+		log.Printf("⚠️ Failed to get statistics: %v", err)
+	}
+
+	log.Println("🎉 Data ingestion completed successfully!")
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestProcessServiceHistoryScopesServicesToPayer(t *testing.T) {
+	service := InsuranceService{
+		NegotiationArrangement: "ffs",
+		Name:                   "Office visit",
+		BillingCodeType:        "CPT",
+		BillingCodeTypeVersion: "2024",
+		BillingCode:            "99213",
+	}
+
+	for _, tc := range []struct {
+		name      string
+		entity    string
+		entityArg driver.Value
+		found     bool
+	}{
+		{"earlier run of the payer", "Payer A", "Payer A", true},
+		{"first run of the payer", "Payer A", "Payer A", false},
+		// Files without a reporting entity share services with each other only
+		{"unknown payer", "", nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("failed to create mock database: %v", err)
+			}
+			defer db.Close()
+
+			for _, stmt := range []string{
+				"INSERT INTO insurance_services", "INSERT INTO negotiated_rates",
+				"INSERT IGNORE INTO negotiated_rate_provider_groups", "INSERT IGNORE INTO negotiated_rate_places_of_service",
+				"SELECT s.id FROM insurance_services s", "SELECT id, negotiated_rate", "UPDATE negotiated_rates SET last_seen_run_id",
+				"UPDATE negotiated_rates SET valid_to", "DELETE FROM negotiated_rate_provider_groups",
+			} {
+				mock.ExpectPrepare(stmt)
+			}
+			s := &DataIngestionService{db: db, history: true, run: &ingestionRun{ID: 3}}
+			s.run.ReportingEntityName = tc.entity
+			stmts, err := s.prepareIngestStatements()
+			if err != nil {
+				t.Fatalf("prepareIngestStatements: %v", err)
+			}
+			defer stmts.Close()
+
+			mock.ExpectBegin()
+			// Services of other payers and of staged runs are never shared
+			find := mock.ExpectQuery(`ir.reporting_entity_name <=> \? AND ir.staging = 0`).
+				WithArgs("CPT", "99213", "ffs", tc.entityArg)
+			if tc.found {
+				find.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+			} else {
+				find.WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec("INSERT INTO insurance_services").
+					WithArgs("ffs", "Office visit", "CPT", "2024", "99213", "", int64(3)).
+					WillReturnResult(sqlmock.NewResult(8, 1))
+			}
+			mock.ExpectCommit()
+
+			if err := s.processService(stmts, service); err != nil {
+				t.Fatalf("processService: %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
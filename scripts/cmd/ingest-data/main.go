@@ -0,0 +1,65 @@
+// Command ingest-data loads payer price transparency files into the database
+// and runs the modes that load reference data, analyze rates and write
+// reports. Without a mode it ingests the files given by -file or -dir.
+package main
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// mode is a subcommand, selected by the first argument
+type mode struct {
+	run func(args []string) error
+	// failure is logged before the error the mode fails with
+	failure string
+}
+
+// modes are the subcommands by name
+var modes = map[string]mode{
+	// Flatten mode works on files only and needs no database
+	"flatten": {runFlatten, "Failed to flatten"},
+	// Migrate mode only brings the schema up to date
+	"migrate": {runMigrate, "Failed to run migrations"},
+	// History, report and episodes modes only read rates
+	"history":  {runHistory, "Failed to show rate history"},
+	"report":   {runReport, "Failed to write report"},
+	"episodes": {runEpisodes, "Failed to price episodes"},
+	// Reference data for search and comparisons
+	"providers": {runProviders, "Failed to load providers"},
+	"zips":      {runZIPs, "Failed to load ZIP centroids"},
+	"terms":     {runTerms, "Failed to load terms"},
+	"fees":      {runFees, "Failed to load reference fees"},
+	"medicare":  {runMedicare, "Failed to load Medicare fee schedules"},
+	// Analyses of the loaded rates
+	"aggregate": {runAggregate, "Failed to aggregate rates"},
+	"diff":      {runDiff, "Failed to diff"},
+	"outliers":  {runOutliers, "Failed to analyze outliers"},
+}
+
+// modeNames returns the names of the modes in order
+func modeNames() []string {
+	names := make([]string, 0, len(modes))
+	for name := range modes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func main() {
+	m, args := mode{runIngest, "Failed to ingest data"}, os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		var ok bool
+		if m, ok = modes[args[0]]; !ok {
+			log.Fatalf("❌ Unknown mode %q, expected one of: %s", args[0], strings.Join(modeNames(), ", "))
+		}
+		args = args[1:]
+	}
+
+	if err := m.run(args); err != nil {
+		log.Fatalf("❌ %s: %v", m.failure, err)
+	}
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"healthcare-saver-ingest/internal/config"
+)
+
+// rvuColumns are the accepted header names of the billing code, work RVU,
+// non-facility and facility practice expense RVU, malpractice RVU, modifier
+// and conversion factor columns of a PFS RVU file (PPRRVU, with its two-line
+// header joined into one). Only the first five are required.
+var rvuColumns = [][]string{
+	{"hcpcs", "hcpcs_code", "billing_code", "cpt", "code"},
+	{"work_rvu", "rvu_work"},
+	{"non_fac_pe_rvu", "non_facility_pe_rvu", "nonfacility_pe_rvu", "~non_facility_pe_rvu"},
+	{"facility_pe_rvu", "fac_pe_rvu", "~fully_implemented_facility_pe_rvu"},
+	{"mp_rvu", "malpractice_rvu"},
+	{"mod", "modifier"},
+	{"conv_factor", "conversion_factor"},
+}
+
+// LoadMedicareRVUs upserts the RVUs of the Medicare Physician Fee Schedule.
+// The conversion factor comes from the file's CONV FACTOR column, or
+// conversionFactor when the file has none. Modifier rows (26, TC) and codes
+// without RVUs are skipped; a practice expense of NA is stored as NULL.
+func (s *DataIngestionService) LoadMedicareRVUs(filePath string, conversionFactor float64) error {
+	log.Printf("📁 Loading Medicare PFS RVUs: %s", filePath)
+
+	stmt, err := s.db.Prepare(`
+		INSERT INTO medicare_pfs_rvus (billing_code, work_rvu, nonfacility_pe_rvu, facility_pe_rvu, mp_rvu, conversion_factor)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE work_rvu = VALUES(work_rvu), nonfacility_pe_rvu = VALUES(nonfacility_pe_rvu),
+			facility_pe_rvu = VALUES(facility_pe_rvu), mp_rvu = VALUES(mp_rvu), conversion_factor = VALUES(conversion_factor)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare RVU statement: %v", err)
+	}
+	defer stmt.Close()
+
+	// parseRVU returns nil for NA and empty values
+	parseRVU := func(value string) (interface{}, float64, error) {
+		if value == "" || strings.EqualFold(value, "NA") {
+			return nil, 0, nil
+		}
+		rvu, err := strconv.ParseFloat(value, 64)
+		return rvu, rvu, err
+	}
+
+	loaded := 0
+	err = readTable(filePath, rvuColumns, 5, func(lineNumber int, values []string) error {
+		code := strings.ToUpper(values[0])
+		if code == "" || values[5] != "" {
+			return nil
+		}
+
+		var rvus [4]interface{}
+		total := 0.0
+		for i, value := range []string{values[1], values[2], values[3], values[4]} {
+			rvu, amount, err := parseRVU(value)
+			if err != nil {
+				log.Printf("⚠️ Skipping invalid RVUs of %s on line %d", code, lineNumber)
+				return nil
+			}
+			rvus[i] = rvu
+			total += amount
+		}
+		if total <= 0 {
+			return nil
+		}
+		if rvus[0] == nil {
+			rvus[0] = 0.0
+		}
+		if rvus[3] == nil {
+			rvus[3] = 0.0
+		}
+
+		cf := conversionFactor
+		if values[6] != "" {
+			parsed, err := strconv.ParseFloat(values[6], 64)
+			if err != nil {
+				log.Printf("⚠️ Skipping invalid conversion factor of %s on line %d", code, lineNumber)
+				return nil
+			}
+			cf = parsed
+		}
+		if cf <= 0 {
+			return fmt.Errorf("no conversion factor for %s on line %d, pass -conversion-factor", code, lineNumber)
+		}
+
+		if _, err := stmt.Exec(code, rvus[0], rvus[1], rvus[2], rvus[3], cf); err != nil {
+			return fmt.Errorf("failed to upsert RVUs of %s: %v", code, err)
+		}
+		loaded++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🎉 Loaded RVUs of %d billing codes from %s", loaded, filePath)
+	return nil
+}
+
+// gpciColumns are the accepted header names of the contractor (carrier),
+// locality, work, practice expense and malpractice GPCI, state and locality
+// name columns of a PFS GPCI file. Only the first five are required.
+var gpciColumns = [][]string{
+	{"carrier", "mac", "~contractor"},
+	{"locality", "locality_number"},
+	{"work_gpci", "~pw_gpci"},
+	{"pe_gpci", "~pe_gpci"},
+	{"mp_gpci", "~mp_gpci"},
+	{"state"},
+	{"locality_name"},
+}
+
+// localityID identifies a PFS locality by its carrier and locality numbers,
+// which spreadsheets tend to strip of leading zeros
+func localityID(carrier, locality string) string {
+	return fmt.Sprintf("%05s-%02s", carrier, locality)
+}
+
+// LoadMedicareGPCIs upserts the geographic practice cost indices of the PFS
+// localities
+func (s *DataIngestionService) LoadMedicareGPCIs(filePath string) error {
+	log.Printf("📁 Loading Medicare GPCIs: %s", filePath)
+
+	stmt, err := s.db.Prepare(`
+		INSERT INTO medicare_gpcis (locality_id, state, name, work_gpci, pe_gpci, mp_gpci)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE state = VALUES(state), name = VALUES(name),
+			work_gpci = VALUES(work_gpci), pe_gpci = VALUES(pe_gpci), mp_gpci = VALUES(mp_gpci)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare GPCI statement: %v", err)
+	}
+	defer stmt.Close()
+
+	loaded := 0
+	err = readTable(filePath, gpciColumns, 5, func(lineNumber int, values []string) error {
+		var gpcis [3]float64
+		for i := range gpcis {
+			gpci, err := strconv.ParseFloat(values[2+i], 64)
+			if err != nil || gpci <= 0 {
+				log.Printf("⚠️ Skipping invalid GPCI on line %d", lineNumber)
+				return nil
+			}
+			gpcis[i] = gpci
+		}
+		if values[0] == "" || values[1] == "" {
+			log.Printf("⚠️ Skipping GPCI without a locality on line %d", lineNumber)
+			return nil
+		}
+
+		id := localityID(values[0], values[1])
+		if _, err := stmt.Exec(id, strings.ToUpper(values[5]), values[6], gpcis[0], gpcis[1], gpcis[2]); err != nil {
+			return fmt.Errorf("failed to upsert GPCIs of locality %s: %v", id, err)
+		}
+		loaded++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🎉 Loaded GPCIs of %d localities from %s", loaded, filePath)
+	return nil
+}
+
+// zipLocalityColumns are the accepted header names of the ZIP, carrier,
+// locality and state columns of a ZIP code to PFS locality file
+var zipLocalityColumns = [][]string{
+	{"zip", "zip_code", "zipcode"},
+	{"carrier"},
+	{"locality", "pricing_locality"},
+	{"state"},
+}
+
+// fixedZIPLocality matches a record of the fixed-width CMS ZIP code to
+// carrier locality file (ZIP5): state, ZIP, carrier and pricing locality
+var fixedZIPLocality = regexp.MustCompile(`^([A-Z]{2})([0-9]{5})([0-9]{5})([0-9]{2})`)
+
+// LoadMedicareZIPLocalities upserts the PFS locality of each ZIP code, from
+// the fixed-width CMS ZIP5 file or a CSV with a header row
+func (s *DataIngestionService) LoadMedicareZIPLocalities(filePath string) error {
+	log.Printf("📁 Loading Medicare ZIP localities: %s", filePath)
+
+	stmt, err := s.db.Prepare(`
+		INSERT INTO medicare_zip_localities (zip, state, locality_id)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE state = VALUES(state), locality_id = VALUES(locality_id)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare ZIP locality statement: %v", err)
+	}
+	defer stmt.Close()
+
+	loaded := 0
+	upsert := func(lineNumber int, zip, state, carrier, locality string) error {
+		if len(zip) != 5 || carrier == "" || locality == "" {
+			log.Printf("⚠️ Skipping invalid ZIP locality on line %d", lineNumber)
+			return nil
+		}
+		if _, err := stmt.Exec(zip, strings.ToUpper(state), localityID(carrier, locality)); err != nil {
+			return fmt.Errorf("failed to upsert locality of ZIP %s: %v", zip, err)
+		}
+		loaded++
+		return nil
+	}
+
+	fixed, err := isFixedZIPLocalityFile(filePath)
+	if err != nil {
+		return err
+	}
+	if fixed {
+		scanner, closeFile, err := openLineScanner(filePath)
+		if err != nil {
+			return err
+		}
+		defer closeFile()
+
+		lineCount := 0
+		for scanner.Scan() {
+			lineCount++
+			m := fixedZIPLocality.FindStringSubmatch(scanner.Text())
+			if m == nil {
+				continue
+			}
+			if err := upsert(lineCount, m[2], m[1], m[3], m[4]); err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("error reading file: %v", err)
+		}
+	} else {
+		err := readTable(filePath, zipLocalityColumns, 3, func(lineNumber int, values []string) error {
+			return upsert(lineNumber, values[0], values[3], values[1], values[2])
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("🎉 Loaded localities of %d ZIP codes from %s", loaded, filePath)
+	return nil
+}
+
+// isFixedZIPLocalityFile reports whether the first record of a file is a
+// fixed-width ZIP5 record
+func isFixedZIPLocalityFile(filePath string) (bool, error) {
+	scanner, closeFile, err := openLineScanner(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer closeFile()
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return fixedZIPLocality.MatchString(line), nil
+	}
+	return false, scanner.Err()
+}
+
+// oppsColumns are the accepted header names of the billing code, payment rate
+// and APC columns of an OPPS Addendum B file. Only the first two are required.
+var oppsColumns = [][]string{
+	{"hcpcs_code", "hcpcs", "billing_code", "code"},
+	{"payment_rate", "payment", "rate"},
+	{"apc"},
+}
+
+// LoadMedicareOPPS upserts the national OPPS payment rates; codes without a
+// payment rate (packaged or not paid under OPPS) are skipped
+func (s *DataIngestionService) LoadMedicareOPPS(filePath string) error {
+	log.Printf("📁 Loading Medicare OPPS payment rates: %s", filePath)
+
+	stmt, err := s.db.Prepare(`
+		INSERT INTO medicare_opps_rates (billing_code, apc, payment_rate)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE apc = VALUES(apc), payment_rate = VALUES(payment_rate)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare OPPS statement: %v", err)
+	}
+	defer stmt.Close()
+
+	loaded := 0
+	err = readTable(filePath, oppsColumns, 2, func(lineNumber int, values []string) error {
+		code := strings.ToUpper(values[0])
+		if code == "" || values[1] == "" {
+			return nil
+		}
+		rate, err := parseAmount(values[1])
+		if err != nil {
+			log.Printf("⚠️ Skipping invalid OPPS payment rate of %s on line %d", code, lineNumber)
+			return nil
+		}
+		if rate <= 0 {
+			return nil
+		}
+
+		if _, err := stmt.Exec(code, nullString(values[2]), math.Round(rate*100)/100); err != nil {
+			return fmt.Errorf("failed to upsert OPPS payment rate of %s: %v", code, err)
+		}
+		loaded++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🎉 Loaded OPPS payment rates of %d billing codes from %s", loaded, filePath)
+	return nil
+}
+
+// medicareSamples are the approximate schedules the medicare mode loads for
+// development with -sample
+var medicareSamples = map[string]string{
+	"rvus":           "data/medicare-pfs-rvus.csv",
+	"gpci":           "data/medicare-gpci.csv",
+	"zip-localities": "data/medicare-zip-localities.csv",
+	"opps":           "data/medicare-opps.csv",
+}
+
+// runMedicare implements the medicare mode: it loads the Medicare fee
+// schedules rates are benchmarked against
+func runMedicare(args []string) error {
+	fs := flag.NewFlagSet("medicare", flag.ExitOnError)
+	var (
+		rvus             = fs.String("rvus", "", "PFS RVU file (PPRRVU)")
+		gpci             = fs.String("gpci", "", "PFS GPCI file")
+		zipLocalities    = fs.String("zip-localities", "", "ZIP code to carrier locality file (fixed-width ZIP5 or CSV)")
+		opps             = fs.String("opps", "", "OPPS Addendum B file")
+		conversionFactor = fs.Float64("conversion-factor", 0, "PFS conversion factor, for RVU files without a CONV FACTOR column")
+		sample           = fs.Bool("sample", false, "Load the approximate sample schedules in data/, for development only")
+	)
+	fs.Parse(args)
+
+	// The sample schedules are rough, and benchmarks computed from them would
+	// be published as if they were real
+	files := map[string]*string{"rvus": rvus, "gpci": gpci, "zip-localities": zipLocalities, "opps": opps}
+	given := *rvus != "" || *gpci != "" || *zipLocalities != "" || *opps != ""
+	switch {
+	case *sample && given:
+		return fmt.Errorf("-sample cannot be combined with schedule files")
+	case *sample:
+		log.Println("⚠️ Loading the approximate sample Medicare schedules; do not use them in production")
+		for name, file := range files {
+			*file = medicareSamples[name]
+		}
+	case !given:
+		return fmt.Errorf("at least one of -rvus, -gpci, -zip-localities and -opps is required (or -sample for the approximate development schedules)")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+
+	if err := service.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create tables: %v", err)
+	}
+	if err := service.RunMigrations(); err != nil {
+		return fmt.Errorf("failed to run migrations: %v", err)
+	}
+
+	if *rvus != "" {
+		if err := service.LoadMedicareRVUs(*rvus, *conversionFactor); err != nil {
+			return err
+		}
+	}
+	if *gpci != "" {
+		if err := service.LoadMedicareGPCIs(*gpci); err != nil {
+			return err
+		}
+	}
+	if *zipLocalities != "" {
+		if err := service.LoadMedicareZIPLocalities(*zipLocalities); err != nil {
+			return err
+		}
+	}
+	if *opps != "" {
+		if err := service.LoadMedicareOPPS(*opps); err != nil {
+			return err
+		}
+	}
+
+	return service.RefreshMedicareBenchmarks()
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// writeTestFile writes content to a file of a temporary directory
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+// newMockService returns an ingestion service on a mock database
+func newMockService(t *testing.T) (*DataIngestionService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &DataIngestionService{db: db}, mock
+}
+
+func TestLoadMedicareRVUs(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		file             string
+		conversionFactor float64
+		want             [][]interface{} // upserted rows
+		err              string
+	}{
+		{
+			name: "PPRRVU columns below a title line",
+			file: "ADDENDUM B, RELATIVE VALUE UNITS\n" +
+				"HCPCS,MOD,DESCRIPTION,WORK RVU,NON-FAC PE RVU,FACILITY PE RVU,MP RVU,CONV FACTOR\n" +
+				"99213,,Office visit,1.30,1.43,0.55,0.10,33.2875\n" +
+				"99213,26,Professional component,1.30,0.50,0.50,0.10,33.2875\n" +
+				"27447,,Total knee arthroplasty,19.60,NA,11.00,3.90,33.2875\n" +
+				"g0008,,Flu shot administration,0.00,0.00,NA,0.00,33.2875\n" +
+				"0001u,,Lab test,,NA,NA,,33.2875\n" +
+				"99214,,Office visit,x,1.43,0.55,0.10,33.2875\n",
+			want: [][]interface{}{
+				{"99213", 1.30, 1.43, 0.55, 0.10, 33.2875},
+				{"27447", 19.60, nil, 11.00, 3.90, 33.2875},
+			},
+		},
+		{
+			name:             "conversion factor of the command line",
+			file:             "hcpcs\twork_rvu\tnon_fac_pe_rvu\tfacility_pe_rvu\tmp_rvu\n99213\t1.30\t1.43\t0.55\t0.10\n",
+			conversionFactor: 32.3465,
+			want:             [][]interface{}{{"99213", 1.30, 1.43, 0.55, 0.10, 32.3465}},
+		},
+		{
+			name: "no conversion factor",
+			file: "hcpcs,work_rvu,non_fac_pe_rvu,facility_pe_rvu,mp_rvu\n99213,1.30,1.43,0.55,0.10\n",
+			err:  "no conversion factor for 99213 on line 2",
+		},
+		{
+			name: "missing required column",
+			file: "hcpcs,work_rvu,facility_pe_rvu,mp_rvu\n99213,1.30,0.55,0.10\n",
+			err:  "missing hcpcs column in header",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, mock := newMockService(t)
+			prepared := mock.ExpectPrepare("INSERT INTO medicare_pfs_rvus")
+			for _, row := range tc.want {
+				prepared.ExpectExec().WithArgs(toValues(row)...).WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err := s.LoadMedicareRVUs(writeTestFile(t, "rvus.csv", tc.file), tc.conversionFactor)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("LoadMedicareRVUs = %v, want an error containing %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadMedicareRVUs: %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestLoadMedicareGPCIs(t *testing.T) {
+	s, mock := newMockService(t)
+	prepared := mock.ExpectPrepare("INSERT INTO medicare_gpcis")
+	for _, row := range [][]interface{}{
+		{"13202-01", "NY", "Manhattan", 1.064, 1.254, 1.622},
+		// Spreadsheets strip leading zeros of carrier and locality numbers
+		{"01112-05", "CA", "San Francisco", 1.093, 1.472, 0.495},
+	} {
+		prepared.ExpectExec().WithArgs(toValues(row)...).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	file := "ADDENDUM E. FINAL CY 2025 GEOGRAPHIC PRACTICE COST INDICES (GPCIs)\n" +
+		"Medicare Administrative Contractor (MAC),State,Locality Number,Locality Name,PW GPCI (with 1.0 Floor),PE GPCI,MP GPCI\n" +
+		"13202,ny,01,Manhattan,1.064,1.254,1.622\n" +
+		"1112,CA,5,San Francisco,1.093,1.472,0.495\n" +
+		"13202,NY,,Rest of State,1.000,0.950,0.800\n" +
+		"13202,NY,99,Invalid,0,0.950,0.800\n"
+	if err := s.LoadMedicareGPCIs(writeTestFile(t, "gpci.csv", file)); err != nil {
+		t.Fatalf("LoadMedicareGPCIs: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadMedicareZIPLocalities(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		file string
+	}{
+		{
+			name: "fixed-width ZIP5 records",
+			file: "NY100011320201  2 20251\n" +
+				"CA941030111205  2 20251\n" +
+				"NY1000\n",
+		},
+		{
+			name: "CSV",
+			file: "# ZIP localities\nstate,zip,carrier,locality\nny,10001,13202,01\nCA,94103,1112,5\nNY,1000,13202,01\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, mock := newMockService(t)
+			prepared := mock.ExpectPrepare("INSERT INTO medicare_zip_localities")
+			prepared.ExpectExec().WithArgs("10001", "NY", "13202-01").WillReturnResult(sqlmock.NewResult(0, 1))
+			prepared.ExpectExec().WithArgs("94103", "CA", "01112-05").WillReturnResult(sqlmock.NewResult(0, 1))
+
+			if err := s.LoadMedicareZIPLocalities(writeTestFile(t, "zip5.txt", tc.file)); err != nil {
+				t.Fatalf("LoadMedicareZIPLocalities: %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// toValues returns the columns of a row as query arguments
+func toValues(row []interface{}) []driver.Value {
+	args := make([]driver.Value, len(row))
+	for i, value := range row {
+		args[i] = value
+	}
+	return args
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"healthcare-saver-ingest/internal/aggregate"
+	"healthcare-saver-ingest/internal/config"
+	"healthcare-saver-ingest/internal/outlier"
+)
+
+// outlierProgressInterval is how many billing codes DetectAllOutliers
+// analyzes between progress messages
+const outlierProgressInterval = 1000
+
+// DetectAllOutliers flags the outliers among the published rates of every
+// billing code, or of one billing code, optionally quarantining extreme ones
+func (s *DataIngestionService) DetectAllOutliers(codeType, code string, quarantine bool, workers int) error {
+	start := time.Now()
+	detector := outlier.New(s.db, s.outliers)
+	detector.Quarantine = quarantine
+
+	codes, err := detector.AllCodes(context.Background(), codeType, code)
+	if err != nil {
+		return err
+	}
+	log.Printf("🔍 Analyzing the rates of %d billing codes...", len(codes))
+
+	result, err := detector.Analyze(context.Background(), codes, workers, func(done int) {
+		if done%outlierProgressInterval == 0 {
+			log.Printf("🔍 Analyzed %d of %d billing codes", done, len(codes))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("🎉 Flagged %d outlier rates of %d billing codes, %d quarantined, in %v",
+		result.Flagged, len(codes), result.Quarantined, time.Since(start).Round(time.Second))
+
+	return s.refreshCodeAggregates(result.QuarantinedCodes, workers)
+}
+
+// ReleaseOutlier publishes a flagged or quarantined rate again after review
+func (s *DataIngestionService) ReleaseOutlier(rateID int64) error {
+	code, err := outlier.New(s.db, s.outliers).Release(context.Background(), rateID)
+	if err != nil {
+		return err
+	}
+	log.Printf("🔓 Released rate %d of %s %s", rateID, code.BillingCodeType, code.BillingCode)
+	return s.refreshCodeAggregates([]outlier.Code{code}, 1)
+}
+
+// refreshCodeAggregates refreshes the aggregates of billing codes whose
+// published rates changed outside of a run and records the change in the
+// load ledger
+func (s *DataIngestionService) refreshCodeAggregates(codes []outlier.Code, workers int) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	aggregates := aggregate.New(s.db)
+	var groups []aggregate.Group
+	for _, c := range codes {
+		codeGroups, err := aggregates.AllGroups(context.Background(), c.BillingCodeType, c.BillingCode)
+		if err != nil {
+			return err
+		}
+		groups = append(groups, codeGroups...)
+	}
+	if len(groups) > 0 {
+		// The aggregate mode records its own load, so a repair also
+		// invalidates the cached responses of these codes
+		if err := aggregates.Refresh(context.Background(), groups, workers, nil); err != nil {
+			return fmt.Errorf("failed to refresh rate aggregates, run the aggregate mode to repair them: %v", err)
+		}
+		log.Printf("📊 Refreshed rate aggregates of %d billing codes", len(codes))
+	}
+	return s.recordLoad("outliers", false, codes)
+}
+
+// PrintOutliers writes the review table, optionally only the rates with a
+// status, most extreme first
+func (s *DataIngestionService) PrintOutliers(w io.Writer, status string, limit int) error {
+	where, args := "", []interface{}{}
+	if status != "" {
+		where = "WHERE o.status = ?"
+		args = append(args, status)
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.Query(`
+		SELECT o.negotiated_rate_id, COALESCE(ir.reporting_entity_name, ''), o.billing_code_type, o.billing_code,
+			o.billing_class, o.negotiated_type, o.negotiated_rate, o.status, o.reason
+		FROM rate_outliers o
+		LEFT JOIN ingestion_runs ir ON ir.id = o.run_id
+		`+where+`
+		ORDER BY ABS(COALESCE(o.robust_z, 1000)) DESC, o.negotiated_rate_id
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query rate outliers: %v", err)
+	}
+	defer rows.Close()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RATE ID\tENTITY\tCODE\tCLASS\tTYPE\tRATE\tSTATUS\tREASON")
+
+	count := 0
+	for rows.Next() {
+		var id int64
+		var entity, codeType, code, billingClass, negotiatedType, rowStatus, reason string
+		var rate float64
+		if err := rows.Scan(&id, &entity, &codeType, &code, &billingClass, &negotiatedType, &rate, &rowStatus, &reason); err != nil {
+			return fmt.Errorf("failed to scan rate outlier: %v", err)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s %s\t%s\t%s\t%.2f\t%s\t%s\n",
+			id, entity, codeType, code, billingClass, negotiatedType, rate, rowStatus, reason)
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rate outliers: %v", err)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	log.Printf("🔍 %d outlier rates listed", count)
+	return nil
+}
+
+// runOutliers implements the outliers mode: it flags the outliers among the
+// published rates, lists the review table or releases a reviewed rate
+func runOutliers(args []string) error {
+	fs := flag.NewFlagSet("outliers", flag.ExitOnError)
+	var (
+		codeType   = fs.String("code-type", "CPT", "Billing code type of -code")
+		code       = fs.String("code", "", "Only analyze the rates of this billing code")
+		workers    = fs.Int("workers", 4, "Number of billing codes analyzed concurrently")
+		quarantine = fs.Bool("quarantine", false, "Quarantine extreme published rates, hiding them until released")
+		list       = fs.Bool("list", false, "List the review table instead of analyzing rates")
+		status     = fs.String("status", "", "With -list, only list rates with this status (flagged, quarantined or released)")
+		limit      = fs.Int("limit", 100, "With -list, the number of rates listed")
+		release    = fs.Int64("release", 0, "Release a flagged or quarantined rate by id after review")
+	)
+	options := outlierFlags(fs)
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+	service.outliers = *options
+
+	if err := service.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create tables: %v", err)
+	}
+	if err := service.RunMigrations(); err != nil {
+		return fmt.Errorf("failed to run migrations: %v", err)
+	}
+
+	switch {
+	case *list:
+		return service.PrintOutliers(os.Stdout, *status, *limit)
+	case *release != 0:
+		return service.ReleaseOutlier(*release)
+	default:
+		return service.DetectAllOutliers(*codeType, *code, *quarantine, *workers)
+	}
+}
+
+// outlierFlags defines the outlier threshold flags shared by the ingestion
+// and outliers modes
+func outlierFlags(fs *flag.FlagSet) *outlier.Options {
+	options := outlier.DefaultOptions
+	fs.Float64Var(&options.Threshold, "outlier-z", options.Threshold, "Robust z-score of log rates above which a rate is flagged")
+	fs.Float64Var(&options.QuarantineThreshold, "quarantine-z", options.QuarantineThreshold, "Robust z-score of log rates above which a rate is extreme")
+	fs.Float64Var(&options.MaxPercentage, "max-percentage", options.MaxPercentage, "Largest plausible percentage rate")
+	fs.IntVar(&options.MinSample, "outlier-min-sample", options.MinSample, "Dollar rates of a billing code and class needed for z-scores")
+	return &options
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// output is a buffered output file, or stdout
+type output struct {
+	*bufio.Writer
+	file *os.File // nil for stdout
+}
+
+// createOutput creates the output file of a mode, or writes to stdout when
+// path is empty
+func createOutput(path string) (*output, error) {
+	if path == "" {
+		return &output{Writer: bufio.NewWriter(os.Stdout)}, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %v", err)
+	}
+	return &output{Writer: bufio.NewWriter(file), file: file}, nil
+}
+
+// Close flushes the output and closes its file, returning the first error.
+// Modes defer it for early returns and call it again once done, since a
+// failed flush or close means the output is incomplete.
+func (o *output) Close() error {
+	err := o.Flush()
+	if o.file != nil {
+		if closeErr := o.file.Close(); err == nil {
+			err = closeErr
+		}
+		o.file = nil
+	}
+	return err
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"healthcare-saver-ingest/internal/config"
+	"healthcare-saver-ingest/internal/store"
+)
+
+// LoadProviders upserts the provider directory from a file with one provider
+// per line (or JSON arrays of providers), in the Provider shape of the web app
+func (s *DataIngestionService) LoadProviders(filePath string) error {
+	log.Printf("📁 Loading providers: %s", filePath)
+
+	scanner, closeFile, err := openLineScanner(filePath)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	stmt, err := s.db.Prepare(`
+		INSERT INTO providers (name, npi, address, contact_info, cms_certification_number)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE name = VALUES(name), address = VALUES(address),
+			contact_info = VALUES(contact_info), cms_certification_number = VALUES(cms_certification_number)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare provider statement: %v", err)
+	}
+	defer stmt.Close()
+
+	lineCount, loaded := 0, 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		lineCount++
+
+		var providers []store.Provider
+		if err := json.Unmarshal([]byte(line), &providers); err != nil {
+			var provider store.Provider
+			if err := json.Unmarshal([]byte(line), &provider); err != nil {
+				log.Printf("⚠️ Failed to parse line %d: %v", lineCount, err)
+				continue
+			}
+			providers = []store.Provider{provider}
+		}
+
+		for _, provider := range providers {
+			if provider.NPI == "" || provider.Name == "" {
+				log.Printf("⚠️ Skipping provider without name or NPI on line %d", lineCount)
+				continue
+			}
+
+			addressJSON, err := json.Marshal(provider.Address)
+			if err != nil {
+				return fmt.Errorf("failed to marshal address: %v", err)
+			}
+			contactJSON, err := json.Marshal(provider.ContactInfo)
+			if err != nil {
+				return fmt.Errorf("failed to marshal contact info: %v", err)
+			}
+
+			_, err = stmt.Exec(provider.Name, provider.NPI, string(addressJSON), string(contactJSON),
+				nullString(provider.CMSCertificationNumber))
+			if err != nil {
+				return fmt.Errorf("failed to upsert provider %s: %v", provider.NPI, err)
+			}
+			loaded++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+
+	log.Printf("🎉 Loaded %d providers from %s", loaded, filePath)
+	log.Println("📊 Rate aggregates by state follow the provider directory, run the aggregate mode to refresh them")
+	// Providers place the rates of every billing code
+	return s.recordLoad("providers", true, nil)
+}
+
+// runProviders implements the providers mode: it loads the provider directory
+func runProviders(args []string) error {
+	fs := flag.NewFlagSet("providers", flag.ExitOnError)
+	file := fs.String("file", "", "Provider directory file (.json or .json.gz, one provider per line)")
+	fs.Parse(args)
+
+	if *file == "" {
+		return fmt.Errorf("please specify the -file flag")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+
+	if err := service.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create tables: %v", err)
+	}
+
+	return service.LoadProviders(*file)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// rateKey identifies a rate across successive files: the reporting entity,
+// the service, its providers (see rosters.key) and every price attribute
+// except the rate and expiration date, which are the values tracked over time
+func rateKey(reportingEntity string, service InsuranceService, providers string, price NegotiatedPrice) []byte {
+	key := strings.Join([]string{
+		reportingEntity,
+		service.BillingCodeType,
+		service.BillingCode,
+		service.NegotiationArrangement,
+		providers,
+		strings.ToLower(strings.TrimSpace(price.NegotiatedType)),
+		strings.ToLower(strings.TrimSpace(price.BillingClass)),
+		strings.Join(normalizeCodes(price.ServiceCode), ","),
+		strings.Join(normalizeCodes(price.BillingCodeModifier), ","),
+	}, "|")
+
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// rosters resolves the provider references of a file to their NPIs and
+// TINs. Payers renumber provider_group_id from one file to the next, so rate
+// keys identify the providers behind the references instead.
+type rosters struct {
+	mu      sync.RWMutex
+	entries map[int][]string // sorted "tin_type:tin_value:npi" by reference
+	digests map[int]string   // of the entries of each reference
+}
+
+// newRosters creates an empty set of rosters
+func newRosters() *rosters {
+	return &rosters{entries: make(map[int][]string), digests: make(map[int]string)}
+}
+
+// add records the providers of a reference, merging repeated references
+func (r *rosters) add(ref ProviderReference) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.entries[ref.ProviderGroupID]
+	for _, group := range ref.ProviderGroups {
+		tin := strings.ToLower(strings.TrimSpace(group.TIN.Type)) + ":" + strings.TrimSpace(group.TIN.Value)
+		for _, npi := range group.NPI {
+			entries = append(entries, tin+":"+strconv.FormatInt(npi, 10))
+		}
+	}
+	entries = sortedUnique(entries)
+	r.entries[ref.ProviderGroupID] = entries
+	r.digests[ref.ProviderGroupID] = digestEntries(entries)
+}
+
+// key returns the digest of the providers of the references of a rate.
+// References not defined before the rate, which files should not have, key
+// on their number.
+func (r *rosters) key(refs []int) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(refs) == 1 {
+		if digest, ok := r.digests[refs[0]]; ok {
+			return digest
+		}
+	}
+	var entries []string
+	for _, ref := range refs {
+		if providers, ok := r.entries[ref]; ok {
+			entries = append(entries, providers...)
+		} else {
+			entries = append(entries, "ref:"+strconv.Itoa(ref))
+		}
+	}
+	return digestEntries(sortedUnique(entries))
+}
+
+// sortedUnique sorts strings and drops duplicates in place
+func sortedUnique(values []string) []string {
+	sort.Strings(values)
+	unique := values[:0]
+	for _, value := range values {
+		if len(unique) == 0 || value != unique[len(unique)-1] {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// digestEntries returns the hex SHA-256 of sorted roster entries
+func digestEntries(entries []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(entries, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeCodes trims, sorts and de-duplicates a list of codes
+func normalizeCodes(codes []string) []string {
+	normalized := make([]string, 0, len(codes))
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		normalized = append(normalized, code)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
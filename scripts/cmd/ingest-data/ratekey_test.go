@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// providerReference returns a reference of one TIN with its NPIs
+func providerReference(id int, tin string, npis ...int64) ProviderReference {
+	group := ProviderGroup{NPI: npis}
+	group.TIN.Type = "ein"
+	group.TIN.Value = tin
+	return ProviderReference{ProviderGroupID: id, ProviderGroups: []ProviderGroup{group}}
+}
+
+func TestNormalizeCodes(t *testing.T) {
+	for _, tc := range []struct {
+		codes []string
+		want  []string
+	}{
+		{[]string{" 22", "11", "22", "", "02"}, []string{"02", "11", "22"}},
+		{nil, []string{}},
+	} {
+		if got := normalizeCodes(tc.codes); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("normalizeCodes(%q) = %q, want %q", tc.codes, got, tc.want)
+		}
+	}
+}
+
+func TestRostersKey(t *testing.T) {
+	january, february := newRosters(), newRosters()
+	january.add(providerReference(1, "111", 1234567893, 1111111112))
+	january.add(providerReference(2, "222", 1999999994))
+	// The next file renumbers the references and lists the NPIs in another order
+	february.add(providerReference(7, "111", 1111111112, 1234567893))
+	february.add(providerReference(8, "222", 1999999994))
+	// A repeated reference merges its providers
+	february.add(providerReference(9, "111", 1234567893))
+	february.add(providerReference(9, "111", 1111111112))
+
+	for _, tc := range []struct {
+		name  string
+		a, b  string
+		equal bool
+	}{
+		{"renumbered reference", january.key([]int{1}), february.key([]int{7}), true},
+		{"merged repeated reference", january.key([]int{1}), february.key([]int{9}), true},
+		{"references in another order", january.key([]int{1, 2}), february.key([]int{8, 7}), true},
+		{"other providers", january.key([]int{1}), february.key([]int{8}), false},
+		{"undefined references key on their number", january.key([]int{5}), february.key([]int{5}), true},
+		{"undefined and defined reference", january.key([]int{5}), january.key([]int{1}), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if (tc.a == tc.b) != tc.equal {
+				t.Errorf("keys %s and %s: equal = %v, want %v", tc.a, tc.b, tc.a == tc.b, tc.equal)
+			}
+		})
+	}
+}
+
+func TestRateKey(t *testing.T) {
+	service := InsuranceService{BillingCodeType: "CPT", BillingCode: "99213", NegotiationArrangement: "ffs"}
+	price := NegotiatedPrice{
+		NegotiatedType: "negotiated", NegotiatedRate: 95.5, ExpirationDate: "2025-12-31",
+		BillingClass: "professional", ServiceCode: []string{"11", "22"}, BillingCodeModifier: []string{"26"},
+	}
+	base := rateKey("Acme Health", service, "providers", price)
+
+	for _, tc := range []struct {
+		name    string
+		entity  string
+		service func(s *InsuranceService)
+		price   func(p *NegotiatedPrice)
+		same    bool
+	}{
+		// The rate and expiration date are the values tracked over time
+		{"new rate", "Acme Health", nil, func(p *NegotiatedPrice) { p.NegotiatedRate = 99 }, true},
+		{"new expiration date", "Acme Health", nil, func(p *NegotiatedPrice) { p.ExpirationDate = "2026-12-31" }, true},
+		{"service codes in another order", "Acme Health", nil, func(p *NegotiatedPrice) { p.ServiceCode = []string{"22", " 11", "11"} }, true},
+		{"billing class case", "Acme Health", nil, func(p *NegotiatedPrice) { p.BillingClass = "Professional " }, true},
+		{"other payer", "Other Health", nil, nil, false},
+		{"other code", "Acme Health", func(s *InsuranceService) { s.BillingCode = "99214" }, nil, false},
+		{"other arrangement", "Acme Health", func(s *InsuranceService) { s.NegotiationArrangement = "bundle" }, nil, false},
+		{"other billing class", "Acme Health", nil, func(p *NegotiatedPrice) { p.BillingClass = "institutional" }, false},
+		{"other service codes", "Acme Health", nil, func(p *NegotiatedPrice) { p.ServiceCode = []string{"11"} }, false},
+		{"other modifiers", "Acme Health", nil, func(p *NegotiatedPrice) { p.BillingCodeModifier = nil }, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, p := service, price
+			if tc.service != nil {
+				tc.service(&s)
+			}
+			if tc.price != nil {
+				tc.price(&p)
+			}
+			if got := rateKey(tc.entity, s, "providers", p); bytes.Equal(got, base) != tc.same {
+				t.Errorf("same key = %v, want %v", !tc.same, tc.same)
+			}
+		})
+	}
+
+	if bytes.Equal(base, rateKey("Acme Health", service, "other providers", price)) {
+		t.Error("rates of other providers share a key")
+	}
+}
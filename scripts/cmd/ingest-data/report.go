@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthcare-saver-ingest/internal/config"
+	"healthcare-saver-ingest/internal/episode"
+	"healthcare-saver-ingest/internal/store"
+)
+
+// reportServiceColumns are the accepted header names of the service list of
+// market reports; category and description are optional
+var reportServiceColumns = [][]string{
+	{"billing_code"},
+	{"billing_code_type", "code_type"},
+	{"category"},
+	{"description", "name"},
+}
+
+// codeSet returns the set of billing codes to report, empty for all codes
+func codeSet(codes []string) map[string]bool {
+	wanted := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		wanted[strings.ToUpper(code)] = true
+	}
+	return wanted
+}
+
+// episodeReportServices returns the primary components of episodes as the
+// service list of market reports, optionally only the given billing codes
+func episodeReportServices(episodes []episode.Episode, codes []string) ([]store.ReportService, error) {
+	wanted := codeSet(codes)
+	var services []store.ReportService
+	for _, ep := range episodes {
+		if len(wanted) > 0 && !wanted[ep.Primary.BillingCode] {
+			continue
+		}
+		services = append(services, store.ReportService{
+			Category:        ep.Category,
+			BillingCodeType: ep.Primary.BillingCodeType,
+			BillingCode:     ep.Primary.BillingCode,
+			Description:     ep.Description,
+		})
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no episodes to report")
+	}
+	return services, nil
+}
+
+// readReportServices reads a service list of market reports, optionally
+// only the given billing codes
+func readReportServices(filePath string, codes []string) ([]store.ReportService, error) {
+	wanted := codeSet(codes)
+	var services []store.ReportService
+	err := readTable(filePath, reportServiceColumns, 1, func(lineNumber int, values []string) error {
+		code := strings.ToUpper(values[0])
+		if code == "" || len(wanted) > 0 && !wanted[code] {
+			return nil
+		}
+		codeType := strings.ToUpper(values[1])
+		if codeType == "" {
+			codeType = inferCodeType(code)
+		}
+		services = append(services, store.ReportService{
+			Category:        values[2],
+			BillingCodeType: codeType,
+			BillingCode:     code,
+			Description:     values[3],
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read services %s: %v", filePath, err)
+	}
+	if len(services) == 0 {
+		return nil, fmt.Errorf("no services to report in %s", filePath)
+	}
+	return services, nil
+}
+
+// loadReportServices returns the services of market reports: those of a
+// service list file, or else the primary services of episode definitions
+func loadReportServices(definitions, servicesFile string, codes []string) ([]store.ReportService, error) {
+	if servicesFile != "" {
+		return readReportServices(servicesFile, codes)
+	}
+	episodes, err := episode.Load(definitions)
+	if err != nil {
+		return nil, err
+	}
+	return episodeReportServices(episodes, codes)
+}
+
+// reportColumn is a column of the CSV and Markdown market reports
+type reportColumn struct {
+	name  string
+	value func(row store.MarketRow) string
+}
+
+// reportColumns are the columns of the CSV and Markdown market reports
+var reportColumns = []reportColumn{
+	{"category", func(r store.MarketRow) string { return r.Category }},
+	{"billing_code_type", func(r store.MarketRow) string { return r.BillingCodeType }},
+	{"billing_code", func(r store.MarketRow) string { return r.BillingCode }},
+	{"description", func(r store.MarketRow) string { return r.Description }},
+	{"billing_class", func(r store.MarketRow) string { return r.BillingClass }},
+	{"rate_count", func(r store.MarketRow) string { return strconv.Itoa(r.RateCount) }},
+	{"payer_count", func(r store.MarketRow) string { return strconv.Itoa(r.PayerCount) }},
+	{"provider_count", func(r store.MarketRow) string { return strconv.Itoa(r.ProviderCount) }},
+	{"contract_count", func(r store.MarketRow) string { return strconv.Itoa(r.ContractCount) }},
+	{"min", func(r store.MarketRow) string { return reportAmount(r.RateCount, r.Min) }},
+	{"median", func(r store.MarketRow) string { return reportAmount(r.RateCount, r.Median) }},
+	{"max", func(r store.MarketRow) string { return reportAmount(r.RateCount, r.Max) }},
+	{"mean", func(r store.MarketRow) string { return reportAmount(r.RateCount, r.Mean) }},
+	{"max_min_ratio", func(r store.MarketRow) string { return reportNumber(r.MaxMinRatio, 2) }},
+	{"cv", func(r store.MarketRow) string { return reportNumber(r.CV, 3) }},
+	{"payer_hhi", func(r store.MarketRow) string { return reportNumber(r.PayerHHI, 0) }},
+	{"provider_hhi", func(r store.MarketRow) string { return reportNumber(r.ProviderHHI, 0) }},
+}
+
+// reportAmount formats a dollar statistic, "" for services without rates
+func reportAmount(count int, amount float64) string {
+	if count == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// reportNumber formats an optional statistic with the given decimals
+func reportNumber(v *float64, decimals int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', decimals, 64)
+}
+
+// WriteMarketReport writes the price dispersion and market concentration of
+// services in a market as csv, markdown or json
+func (s *DataIngestionService) WriteMarketReport(w io.Writer, services []store.ReportService, market store.Market, format string) error {
+	rows, err := store.New(s.db).MarketReport(context.Background(), services, market)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		header := make([]string, len(reportColumns))
+		for i, col := range reportColumns {
+			header[i] = col.name
+		}
+		if err := writer.Write(header); err != nil {
+			return fmt.Errorf("failed to write report: %v", err)
+		}
+		for _, row := range rows {
+			record := make([]string, len(reportColumns))
+			for i, col := range reportColumns {
+				record[i] = col.value(row)
+			}
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write report: %v", err)
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("failed to write report: %v", err)
+		}
+	case "markdown":
+		if err := writeMarkdownReport(w, rows, market); err != nil {
+			return fmt.Errorf("failed to write report: %v", err)
+		}
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err := encoder.Encode(map[string]interface{}{
+			"market":      market.String(),
+			"generatedAt": time.Now().UTC().Format(time.RFC3339),
+			"services":    rows,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write report: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown report format %q: use csv, markdown or json", format)
+	}
+
+	log.Printf("📊 Reported %d services (%d rows) for %s", len(services), len(rows), market)
+	return nil
+}
+
+// writeMarkdownReport writes one table per service category; the first four
+// columns of reportColumns are folded into headings and the service column
+func writeMarkdownReport(w io.Writer, rows []store.MarketRow, market store.Market) error {
+	cell := func(value string) string {
+		return strings.ReplaceAll(value, "|", "\\|")
+	}
+
+	if _, err := fmt.Fprintf(w, "# Market report: %s\n\nGenerated %s. Rates are current effective dollar rates; shares are of payer and provider NPI contracts.\n",
+		market, time.Now().UTC().Format("2006-01-02")); err != nil {
+		return err
+	}
+
+	columns := reportColumns[4:]
+	category := "\x00"
+	for _, row := range rows {
+		if row.Category != category {
+			category = row.Category
+			title := category
+			if title == "" {
+				title = "Services"
+			}
+			names := make([]string, len(columns))
+			for i, col := range columns {
+				names[i] = col.name
+			}
+			if _, err := fmt.Fprintf(w, "\n## %s\n\n| service | %s |\n|---|%s\n",
+				cell(title), strings.Join(names, " | "), strings.Repeat("---:|", len(columns))); err != nil {
+				return err
+			}
+		}
+
+		values := make([]string, len(columns))
+		for i, col := range columns {
+			values[i] = cell(col.value(row))
+		}
+		service := row.BillingCodeType + " " + row.BillingCode
+		if row.Description != "" {
+			service += " " + row.Description
+		}
+		if _, err := fmt.Fprintf(w, "| %s | %s |\n", cell(service), strings.Join(values, " | ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runReport implements the report mode: it writes the price dispersion and
+// market concentration of a list of services in a market, by default the
+// primary services of the episode definitions
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	var (
+		definitions = fs.String("episodes", "data/episodes.yaml", "Episode definitions (YAML) whose primary services are reported")
+		services    = fs.String("services", "", "Service list instead of -episodes (CSV with billing_code and optional billing_code_type, category and description)")
+		codes       = fs.String("codes", "", "Only report these billing codes of the service list (comma-separated)")
+		state       = fs.String("state", "", "Market of providers in this two-letter state")
+		zip         = fs.String("zip", "", "Market of providers within -radius of this ZIP code")
+		radius      = fs.Float64("radius", 25, "Radius in miles around -zip")
+		format      = fs.String("format", "markdown", "Output format: csv, markdown or json")
+		out         = fs.String("out", "", "Output file (default: stdout)")
+	)
+	fs.Parse(args)
+
+	market := store.Market{State: strings.ToUpper(*state)}
+	if *zip != "" {
+		if *radius <= 0 || *radius > store.MaxRadiusMiles {
+			return fmt.Errorf("-radius must be between 0 and %d miles", store.MaxRadiusMiles)
+		}
+		market.Near = &store.GeoFilter{ZIP: *zip, RadiusMiles: *radius}
+	}
+
+	list, err := loadReportServices(*definitions, *services, splitList(*codes))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+
+	output, err := createOutput(*out)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	if err := service.WriteMarketReport(output, list, market, *format); err != nil {
+		return err
+	}
+	if err := output.Close(); err != nil {
+		return fmt.Errorf("failed to write market report: %v", err)
+	}
+	return nil
+}
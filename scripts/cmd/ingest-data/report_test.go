@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"healthcare-saver-ingest/internal/episode"
+	"healthcare-saver-ingest/internal/store"
+)
+
+func TestEpisodeReportServices(t *testing.T) {
+	episodes, err := episode.Parse([]byte(`
+episodes:
+  - id: mri-brain
+    category: Imaging
+    description: MRI of the brain without contrast
+    primary: {code: "70551", billing_class: institutional}
+    ancillaries:
+      - {code: "70551", billing_class: professional}
+  - id: flu-shot
+    category: Medicine
+    description: Flu shot administration
+    primary: {code: g0008, code_type: hcpcs, billing_class: professional}
+`))
+	if err != nil {
+		t.Fatalf("invalid test episodes: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		codes []string
+		want  []store.ReportService
+		err   bool
+	}{
+		{
+			name: "primary service of every episode",
+			want: []store.ReportService{
+				{Category: "Imaging", BillingCodeType: "CPT", BillingCode: "70551", Description: "MRI of the brain without contrast"},
+				{Category: "Medicine", BillingCodeType: "HCPCS", BillingCode: "G0008", Description: "Flu shot administration"},
+			},
+		},
+		{
+			name:  "only the given codes",
+			codes: []string{"g0008"},
+			want:  []store.ReportService{{Category: "Medicine", BillingCodeType: "HCPCS", BillingCode: "G0008", Description: "Flu shot administration"}},
+		},
+		{name: "no episode of the codes", codes: []string{"99213"}, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := episodeReportServices(episodes, tc.codes)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("episodeReportServices = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("episodeReportServices: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("episodeReportServices = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDefaultReportServices(t *testing.T) {
+	services, err := loadReportServices("../../data/episodes.yaml", "", nil)
+	if err != nil {
+		t.Fatalf("loadReportServices: %v", err)
+	}
+	// The 70 CMS-specified shoppable services
+	if len(services) != 70 {
+		t.Errorf("%d default report services, want 70", len(services))
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"healthcare-saver-ingest/internal/aggregate"
+	"healthcare-saver-ingest/internal/outlier"
+)
+
+// startRun records a new ingestion run for a file
+func (s *DataIngestionService) startRun(filePath string) error {
+	result, err := s.db.Exec("INSERT INTO ingestion_runs (file_path, staging) VALUES (?, ?)", filePath, s.staging)
+	if err != nil {
+		return fmt.Errorf("failed to record ingestion run: %v", err)
+	}
+
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get run ID: %v", err)
+	}
+
+	s.run = &ingestionRun{ID: runID}
+	s.runPrices.Store(0)
+	s.rosters = newRosters()
+	s.replaced = nil
+
+	// Without a header line the command line overrides are all we know
+	return s.applyHeader(FileHeader{})
+}
+
+// applyHeader stores the file header on the current run. Command line
+// overrides take precedence over the values found in the file.
+func (s *DataIngestionService) applyHeader(header FileHeader) error {
+	if s.reportingEntity != "" {
+		header.ReportingEntityName = s.reportingEntity
+	}
+	if s.lastUpdatedOn != "" {
+		header.LastUpdatedOn = s.lastUpdatedOn
+	}
+	if header.LastUpdatedOn != "" {
+		date, err := time.Parse("2006-01-02", header.LastUpdatedOn)
+		if err != nil {
+			return fmt.Errorf("invalid last_updated_on %q: %v", header.LastUpdatedOn, err)
+		}
+		header.LastUpdatedOn = date.Format("2006-01-02")
+	}
+	s.run.FileHeader = header
+
+	if s.history && header.ReportingEntityName != "" && header.LastUpdatedOn != "" {
+		var latest sql.NullString
+		err := s.db.QueryRow(`
+			SELECT DATE_FORMAT(MAX(last_updated_on), '%Y-%m-%d') FROM ingestion_runs
+			WHERE reporting_entity_name = ? AND status = 'completed'
+		`, header.ReportingEntityName).Scan(&latest)
+		if err != nil {
+			return fmt.Errorf("failed to check previous runs: %v", err)
+		}
+		if latest.Valid && latest.String > header.LastUpdatedOn {
+			return fmt.Errorf("file last_updated_on %s is older than the latest loaded file (%s) for %s; load files in chronological order",
+				header.LastUpdatedOn, latest.String, header.ReportingEntityName)
+		}
+	}
+
+	_, err := s.db.Exec(`
+		UPDATE ingestion_runs
+		SET reporting_entity_name = ?, reporting_entity_type = ?, last_updated_on = ?,
+			plan_name = ?, plan_id_type = ?, plan_id = ?, plan_market_type = ?
+		WHERE id = ?
+	`, nullString(header.ReportingEntityName), nullString(header.ReportingEntityType), nullString(header.LastUpdatedOn),
+		nullString(header.PlanName), nullString(header.PlanIDType), nullString(header.PlanID), nullString(header.PlanMarketType),
+		s.run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update ingestion run: %v", err)
+	}
+
+	return nil
+}
+
+// finishRun marks the current run as completed or failed
+func (s *DataIngestionService) finishRun(status string, servicesCount int) error {
+	_, err := s.db.Exec(`
+		UPDATE ingestion_runs
+		SET status = ?, services_count = ?, rates_count = ?, finished_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, status, servicesCount, s.runPrices.Load(), s.run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update ingestion run: %v", err)
+	}
+	return nil
+}
+
+// publishStagedRun validates the data loaded by the current staged run and
+// makes it the active version of its reporting entity
+func (s *DataIngestionService) publishStagedRun(servicesCount int) error {
+	if s.run.ReportingEntityName == "" {
+		return fmt.Errorf("staging mode requires a reporting entity: add a file header line or use -reporting-entity")
+	}
+
+	if err := s.validateRun(servicesCount); err != nil {
+		return fmt.Errorf("staged run %d failed validation: %v", s.run.ID, err)
+	}
+
+	previousRunID, err := s.activateRun()
+	if err != nil {
+		return err
+	}
+	log.Printf("🔀 Run %d is now the active version of %s", s.run.ID, s.run.ReportingEntityName)
+
+	if previousRunID.Valid && !s.skipAggregates {
+		groups, err := aggregate.New(s.db).RunGroups(context.Background(), previousRunID.Int64)
+		if err != nil {
+			log.Printf("⚠️ Failed to find the billing codes of previous run %d: %v", previousRunID.Int64, err)
+		}
+		s.replaced = append(s.replaced, groups...)
+	}
+
+	if previousRunID.Valid && !s.keepPrevious {
+		log.Printf("🧹 Deleting data of previous run %d", previousRunID.Int64)
+		if err := s.deleteRunData(previousRunID.Int64); err != nil {
+			log.Printf("⚠️ Failed to delete previous run data: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// validateRun checks the counts and referential integrity of the current run
+// before it is activated. Workers log and skip services they fail to insert,
+// so comparing counts catches partial loads.
+func (s *DataIngestionService) validateRun(servicesCount int) error {
+	runID := s.run.ID
+	expectedRates := s.runPrices.Load()
+
+	checks := []struct {
+		name     string
+		query    string
+		expected int64
+	}{
+		{"services", "SELECT COUNT(*) FROM insurance_services WHERE run_id = ?", int64(servicesCount)},
+		{"negotiated rates", "SELECT COUNT(*) FROM negotiated_rates WHERE run_id = ?", expectedRates},
+		{"rates without provider group links", `
+			SELECT COUNT(*) FROM negotiated_rates r
+			WHERE r.run_id = ? AND JSON_LENGTH(r.provider_references) > 0
+				AND NOT EXISTS (SELECT 1 FROM negotiated_rate_provider_groups pg WHERE pg.negotiated_rate_id = r.id)
+		`, 0},
+		{"rates referencing services of another run", `
+			SELECT COUNT(*) FROM negotiated_rates r
+			JOIN insurance_services s ON s.id = r.service_id
+			WHERE r.run_id = ? AND (s.run_id IS NULL OR s.run_id <> r.run_id)
+		`, 0},
+	}
+
+	for _, check := range checks {
+		var actual int64
+		if err := s.db.QueryRow(check.query, runID).Scan(&actual); err != nil {
+			return fmt.Errorf("failed to count %s: %v", check.name, err)
+		}
+		if actual != check.expected {
+			return fmt.Errorf("%s: expected %d, found %d", check.name, check.expected, actual)
+		}
+	}
+
+	// Guard against truncated files replacing a complete version
+	if s.stagingMinRatio > 0 {
+		var previousRates sql.NullInt64
+		err := s.db.QueryRow(`
+			SELECT ir.rates_count FROM active_file_versions v
+			JOIN ingestion_runs ir ON ir.id = v.run_id
+			WHERE v.reporting_entity_name = ?
+		`, s.run.ReportingEntityName).Scan(&previousRates)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to get active version: %v", err)
+		}
+		if previousRates.Valid && float64(expectedRates) < s.stagingMinRatio*float64(previousRates.Int64) {
+			return fmt.Errorf("only %d rates compared to %d in the active version (minimum ratio %.2f)",
+				expectedRates, previousRates.Int64, s.stagingMinRatio)
+		}
+	}
+
+	log.Printf("✅ Run %d validated: %d services, %d rates", runID, servicesCount, expectedRates)
+	return nil
+}
+
+// activateRun atomically switches the active version of the current run's
+// reporting entity to the current run and returns the previous version
+func (s *DataIngestionService) activateRun() (sql.NullInt64, error) {
+	var previousRunID sql.NullInt64
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return previousRunID, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow("SELECT run_id FROM active_file_versions WHERE reporting_entity_name = ? FOR UPDATE",
+		s.run.ReportingEntityName).Scan(&previousRunID)
+	if err != nil && err != sql.ErrNoRows {
+		return previousRunID, fmt.Errorf("failed to get active version: %v", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO active_file_versions (reporting_entity_name, run_id) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE previous_run_id = run_id, run_id = VALUES(run_id)
+	`, s.run.ReportingEntityName, s.run.ID)
+	if err != nil {
+		return previousRunID, fmt.Errorf("failed to switch active version: %v", err)
+	}
+
+	if _, err := tx.Exec("UPDATE ingestion_runs SET status = 'completed' WHERE id = ?", s.run.ID); err != nil {
+		return previousRunID, fmt.Errorf("failed to update ingestion run: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return previousRunID, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return previousRunID, nil
+}
+
+// deleteRunBatchSize is the number of rows deleted per statement
+const deleteRunBatchSize = 1000
+
+// deleteRunData deletes the services and provider groups of a run in
+// batches; rates and links are removed by the cascading foreign keys
+func (s *DataIngestionService) deleteRunData(runID int64) error {
+	for _, table := range []string{"insurance_services", "provider_group_npis"} {
+		for {
+			result, err := s.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE run_id = ? LIMIT ?", table), runID, deleteRunBatchSize)
+			if err != nil {
+				return fmt.Errorf("failed to delete %s of run %d: %v", table, runID, err)
+			}
+			deleted, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to get deleted count: %v", err)
+			}
+			if deleted < deleteRunBatchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// insertProviderReference stores the NPIs and TINs of a provider group
+// for the current run
+func (s *DataIngestionService) insertProviderReference(ref ProviderReference) error {
+	s.rosters.add(ref)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT IGNORE INTO provider_group_npis (run_id, provider_group_id, npi, tin_type, tin_value)
+		VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare provider group statement: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, group := range ref.ProviderGroups {
+		for _, npi := range group.NPI {
+			if _, err := stmt.Exec(s.run.ID, ref.ProviderGroupID, strconv.FormatInt(npi, 10), group.TIN.Type, group.TIN.Value); err != nil {
+				return fmt.Errorf("failed to insert provider group %d: %v", ref.ProviderGroupID, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// nullString converts an empty string to NULL
+func nullString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// recordLoad adds a load made outside ingestion runs to the data_loads
+// ledger once its data is visible, so API servers invalidate the cached
+// responses of the billing codes it changed, or of every code with all.
+// Responses spanning all codes are invalidated by any load.
+func (s *DataIngestionService) recordLoad(mode string, all bool, codes []outlier.Code) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO data_loads (mode, all_codes) VALUES (?, ?)", mode, all)
+	if err != nil {
+		return fmt.Errorf("failed to record %s load: %v", mode, err)
+	}
+	loadID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get load ID: %v", err)
+	}
+
+	if !all && len(codes) > 0 {
+		stmt, err := tx.Prepare("INSERT IGNORE INTO data_load_codes (load_id, billing_code_type, billing_code) VALUES (?, ?, ?)")
+		if err != nil {
+			return fmt.Errorf("failed to prepare load code statement: %v", err)
+		}
+		defer stmt.Close()
+		for _, code := range codes {
+			if _, err := stmt.Exec(loadID, code.BillingCodeType, code.BillingCode); err != nil {
+				return fmt.Errorf("failed to record billing code %s %s of load %d: %v", code.BillingCodeType, code.BillingCode, loadID, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// fileHandlers receives the records found while scanning a file.
+// Header and ProviderReference may be nil, in which case those lines are skipped.
+type fileHandlers struct {
+	Header            func(FileHeader) error
+	ProviderReference func(ProviderReference) error
+	Service           func(InsuranceService) error
+}
+
+// scanServices reads a file (.json or .json.gz) line by line and calls handle
+// for every service it contains. It returns the number of lines and services seen.
+func scanServices(filePath string, handle func(InsuranceService) error) (int, int, error) {
+	return scanFile(filePath, fileHandlers{Service: handle})
+}
+
+// scanFile reads a file (.json or .json.gz) line by line. Each line may hold a
+// single service object, a JSON array of services, a provider reference or
+// the file header.
+func scanFile(filePath string, handlers fileHandlers) (int, int, error) {
+	scanner, closeFile, err := openLineScanner(filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer closeFile()
+
+	// Process file line by line
+	lineCount := 0
+	processedCount := 0
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		lineCount++
+
+		// Try to parse as JSON array or single object
+		var services []InsuranceService
+
+		// First, try to parse as array
+		if err := json.Unmarshal([]byte(line), &services); err != nil {
+			// If that fails, try as single object
+			var service InsuranceService
+			if err := json.Unmarshal([]byte(line), &service); err != nil {
+				log.Printf("⚠️ Failed to parse line %d: %v", lineCount, err)
+				continue
+			}
+
+			// An object without a billing code is a provider reference or the file header
+			if service.BillingCode == "" {
+				if err := handleNonServiceLine(line, handlers); err != nil {
+					return lineCount, processedCount, err
+				}
+				continue
+			}
+
+			services = []InsuranceService{service}
+		}
+
+		// Hand services to the caller
+		for _, service := range services {
+			if err := handlers.Service(service); err != nil {
+				return lineCount, processedCount, err
+			}
+			processedCount++
+		}
+
+		// Log progress every 1000 lines
+		if lineCount%1000 == 0 {
+			log.Printf("📊 Processed %d lines, %d services", lineCount, processedCount)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return lineCount, processedCount, fmt.Errorf("error reading file: %v", err)
+	}
+
+	return lineCount, processedCount, nil
+}
+
+// openLineScanner opens a file (.json or .json.gz) for line-by-line reading.
+// The returned function closes the file.
+func openLineScanner(filePath string) (*bufio.Scanner, func(), error) {
+	// Open file
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %v", err)
+	}
+
+	// Create reader based on file extension
+	var reader io.Reader = file
+	closeFile := func() { file.Close() }
+	if strings.HasSuffix(filePath, ".gz") {
+		gzReader, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("failed to create gzip reader: %v", err)
+		}
+		reader = gzReader
+		closeFile = func() {
+			gzReader.Close()
+			file.Close()
+		}
+	}
+
+	// Create scanner for line-by-line processing
+	scanner := bufio.NewScanner(reader)
+
+	// Increase buffer size for large lines
+	const maxCapacity = 1024 * 1024 // 1MB
+	buf := make([]byte, maxCapacity)
+	scanner.Buffer(buf, maxCapacity)
+
+	return scanner, closeFile, nil
+}
+
+// handleNonServiceLine parses a line without a billing code as a provider
+// reference or as the file header
+func handleNonServiceLine(line string, handlers fileHandlers) error {
+	var ref ProviderReference
+	if err := json.Unmarshal([]byte(line), &ref); err == nil && ref.ProviderGroupID != 0 {
+		if handlers.ProviderReference == nil {
+			return nil
+		}
+		return handlers.ProviderReference(ref)
+	}
+
+	var header FileHeader
+	if err := json.Unmarshal([]byte(line), &header); err != nil || (header.ReportingEntityName == "" && header.LastUpdatedOn == "" && header.PlanName == "") {
+		log.Printf("⚠️ Skipping line without billing code, provider reference or file header")
+		return nil
+	}
+	if handlers.Header == nil {
+		return nil
+	}
+	return handlers.Header(header)
+}
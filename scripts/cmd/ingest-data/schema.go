@@ -0,0 +1,716 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+
+	"healthcare-saver-ingest/internal/config"
+)
+
+// schemaTable is a table created by CreateTables
+type schemaTable struct {
+	name string // for error messages
+	ddl  string
+}
+
+// rateTables hold the services and negotiated rates of payer files
+var rateTables = []schemaTable{
+	{"services", `
+	CREATE TABLE IF NOT EXISTS insurance_services (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		negotiation_arrangement VARCHAR(50) NOT NULL,
+		name VARCHAR(500) NOT NULL,
+		billing_code_type VARCHAR(20) NOT NULL,
+		billing_code_type_version VARCHAR(20) NOT NULL,
+		billing_code VARCHAR(50) NOT NULL,
+		description TEXT,
+		run_id BIGINT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX idx_billing_code (billing_code),
+		INDEX idx_name (name),
+		INDEX idx_negotiation_arrangement (negotiation_arrangement),
+		INDEX idx_run_id (run_id),
+		FULLTEXT INDEX ft_name_description (name, description)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	{"negotiated rates", `
+	CREATE TABLE IF NOT EXISTS negotiated_rates (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		service_id INT NOT NULL,
+		provider_references JSON NOT NULL,
+		negotiated_type ENUM('percentage', 'negotiated') NOT NULL,
+		negotiated_rate DECIMAL(15,2) NOT NULL,
+		expiration_date DATE NOT NULL,
+		service_codes JSON NOT NULL,
+		billing_class ENUM('professional', 'institutional') NOT NULL,
+		rate_key BINARY(32) NULL,
+		valid_from DATE NULL,
+		valid_to DATE NULL,
+		run_id BIGINT NULL,
+		last_seen_run_id BIGINT NULL,
+		closed_run_id BIGINT NULL,
+		quarantined TINYINT(1) NOT NULL DEFAULT 0,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		FOREIGN KEY (service_id) REFERENCES insurance_services(id) ON DELETE CASCADE,
+		INDEX idx_service_id (service_id),
+		INDEX idx_negotiated_type (negotiated_type),
+		INDEX idx_billing_class (billing_class),
+		INDEX idx_expiration_date (expiration_date),
+		INDEX idx_rate_key_valid_to (rate_key, valid_to),
+		INDEX idx_run_id (run_id),
+		INDEX idx_last_seen_run_id (last_seen_run_id),
+		INDEX idx_closed_run_id (closed_run_id),
+		INDEX idx_service_current_rate (service_id, valid_to, negotiated_rate)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	// Normalized copies of the provider_references and service_codes JSON
+	// arrays, so rates can be found by provider group or place of service
+	// through an index instead of a JSON_CONTAINS scan
+	{"rate provider groups", `
+	CREATE TABLE IF NOT EXISTS negotiated_rate_provider_groups (
+		negotiated_rate_id INT NOT NULL,
+		provider_group_id INT NOT NULL,
+		PRIMARY KEY (negotiated_rate_id, provider_group_id),
+		FOREIGN KEY (negotiated_rate_id) REFERENCES negotiated_rates(id) ON DELETE CASCADE,
+		INDEX idx_provider_group_rate (provider_group_id, negotiated_rate_id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	{"rate places of service", `
+	CREATE TABLE IF NOT EXISTS negotiated_rate_places_of_service (
+		negotiated_rate_id INT NOT NULL,
+		place_of_service VARCHAR(10) NOT NULL,
+		PRIMARY KEY (negotiated_rate_id, place_of_service),
+		FOREIGN KEY (negotiated_rate_id) REFERENCES negotiated_rates(id) ON DELETE CASCADE,
+		INDEX idx_place_of_service_rate (place_of_service, negotiated_rate_id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+}
+
+// runTables track ingested files, their staged versions and provider rosters
+var runTables = []schemaTable{
+	// Ledger of ingested files
+	{"ingestion runs", `
+	CREATE TABLE IF NOT EXISTS ingestion_runs (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		file_path VARCHAR(1000) NOT NULL,
+		reporting_entity_name VARCHAR(255) NULL,
+		reporting_entity_type VARCHAR(100) NULL,
+		plan_name VARCHAR(255) NULL,
+		plan_id_type VARCHAR(20) NULL,
+		plan_id VARCHAR(50) NULL,
+		plan_market_type VARCHAR(20) NULL,
+		last_updated_on DATE NULL,
+		status ENUM('running', 'completed', 'failed') NOT NULL DEFAULT 'running',
+		staging TINYINT(1) NOT NULL DEFAULT 0,
+		services_count INT NOT NULL DEFAULT 0,
+		rates_count INT NOT NULL DEFAULT 0,
+		started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		finished_at TIMESTAMP NULL,
+		INDEX idx_reporting_entity (reporting_entity_name, last_updated_on),
+		INDEX idx_plan (plan_name, plan_id),
+		INDEX idx_status (status)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	// Active version of each reporting entity's staged data, see activateRun
+	{"active file versions", `
+	CREATE TABLE IF NOT EXISTS active_file_versions (
+		reporting_entity_name VARCHAR(255) PRIMARY KEY,
+		run_id BIGINT NOT NULL,
+		previous_run_id BIGINT NULL,
+		activated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	// NPIs and TINs of the provider groups referenced by a run's rates.
+	// provider_group_id is only unique within a file, hence the run_id. A
+	// rate's groups resolve against the roster of its last_seen_run_id, the
+	// last file that published it.
+	{"provider group NPIs", `
+	CREATE TABLE IF NOT EXISTS provider_group_npis (
+		run_id BIGINT NOT NULL,
+		provider_group_id INT NOT NULL,
+		npi VARCHAR(10) NOT NULL,
+		tin_type VARCHAR(10) NOT NULL,
+		tin_value VARCHAR(20) NOT NULL,
+		PRIMARY KEY (run_id, provider_group_id, npi, tin_value),
+		INDEX idx_npi (npi, run_id, provider_group_id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	// Ledger of the loads made outside ingestion runs, see recordLoad. A
+	// load changes the listed billing codes, or every code with all_codes.
+	{"data loads", `
+	CREATE TABLE IF NOT EXISTS data_loads (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		mode VARCHAR(50) NOT NULL,
+		all_codes TINYINT(1) NOT NULL DEFAULT 0,
+		loaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		INDEX idx_loaded_at (loaded_at)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	{"data load codes", `
+	CREATE TABLE IF NOT EXISTS data_load_codes (
+		load_id BIGINT NOT NULL,
+		billing_code_type VARCHAR(20) NOT NULL,
+		billing_code VARCHAR(50) NOT NULL,
+		PRIMARY KEY (load_id, billing_code_type, billing_code),
+		FOREIGN KEY (load_id) REFERENCES data_loads(id) ON DELETE CASCADE
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+}
+
+// directoryTables hold the providers, places and lay terms searches use
+var directoryTables = []schemaTable{
+	// Provider directory, mirroring the Provider model of the web app
+	{"providers", `
+	CREATE TABLE IF NOT EXISTS providers (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(500) NOT NULL,
+		npi VARCHAR(10) NOT NULL,
+		address JSON NOT NULL,
+		contact_info JSON NOT NULL,
+		cms_certification_number VARCHAR(20) NULL,
+		address_city VARCHAR(100) AS (address->>'$.city') STORED,
+		address_state VARCHAR(2) AS (address->>'$.state') STORED,
+		address_zip VARCHAR(10) AS (address->>'$.zip') STORED,
+		address_zip5 CHAR(5) AS (LEFT(address->>'$.zip', 5)) STORED,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		UNIQUE KEY uk_npi (npi),
+		INDEX idx_state_city (address_state, address_city),
+		INDEX idx_zip (address_zip),
+		INDEX idx_zip5 (address_zip5)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	// ZIP code centroids for radius searches. location is POINT(longitude,
+	// latitude) in SRID 0 so the spatial index can prefilter by bounding box.
+	{"ZIP centroids", `
+	CREATE TABLE IF NOT EXISTS zip_centroids (
+		zip CHAR(5) PRIMARY KEY,
+		latitude DOUBLE NOT NULL,
+		longitude DOUBLE NOT NULL,
+		location POINT NOT NULL SRID 0,
+		SPATIAL INDEX idx_location (location)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	// Lay terms and synonyms for billing codes, searched together with
+	// service names and descriptions
+	{"service terms", `
+	CREATE TABLE IF NOT EXISTS service_terms (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		billing_code_type VARCHAR(20) NOT NULL,
+		billing_code VARCHAR(50) NOT NULL,
+		term VARCHAR(255) NOT NULL,
+		UNIQUE KEY uk_code_term (billing_code_type, billing_code, term),
+		FULLTEXT INDEX ft_term (term)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+}
+
+// apiKeyTables hold the keys and usage of the price API
+var apiKeyTables = []schemaTable{
+	// API keys of partners calling the price API. Only a SHA-256 hash of
+	// each key is stored; key_prefix identifies it in listings and logs.
+	{"API keys", `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		key_prefix VARCHAR(16) NOT NULL,
+		key_hash BINARY(32) NOT NULL,
+		rate_per_second DOUBLE NOT NULL,
+		burst INT NOT NULL,
+		daily_quota INT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		revoked_at TIMESTAMP NULL,
+		last_used_at TIMESTAMP NULL,
+		UNIQUE KEY uk_key_hash (key_hash)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	// Requests per API key and UTC day, for daily quotas
+	{"API key usage", `
+	CREATE TABLE IF NOT EXISTS api_key_usage (
+		api_key_id BIGINT NOT NULL,
+		day DATE NOT NULL,
+		requests BIGINT NOT NULL DEFAULT 0,
+		PRIMARY KEY (api_key_id, day)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+}
+
+// analysisTables hold what is computed from the current rates
+var analysisTables = []schemaTable{
+	// Distribution of the current rates of each billing code by billing
+	// class, negotiated type, payer (reporting entity, '' when unknown) and
+	// state ('' for all states), refreshed after each run. digest holds the
+	// t-digest centroids so rows can be merged, see internal/aggregate.
+	{"rate aggregates", `
+	CREATE TABLE IF NOT EXISTS rate_aggregates (
+		billing_code_type VARCHAR(20) NOT NULL,
+		billing_code VARCHAR(50) NOT NULL,
+		payer VARCHAR(255) NOT NULL,
+		state VARCHAR(2) NOT NULL,
+		billing_class ENUM('professional', 'institutional') NOT NULL,
+		negotiated_type ENUM('percentage', 'negotiated') NOT NULL,
+		rate_count BIGINT NOT NULL,
+		min_rate DECIMAL(15,2) NOT NULL,
+		max_rate DECIMAL(15,2) NOT NULL,
+		sum_rate DECIMAL(20,2) NOT NULL,
+		mean_rate DECIMAL(15,2) NOT NULL,
+		p10 DECIMAL(15,2) NOT NULL,
+		p25 DECIMAL(15,2) NOT NULL,
+		median DECIMAL(15,2) NOT NULL,
+		p75 DECIMAL(15,2) NOT NULL,
+		p90 DECIMAL(15,2) NOT NULL,
+		digest BLOB NOT NULL,
+		medicare_amount DECIMAL(15,2) NULL,
+		median_pct_of_medicare DECIMAL(10,1) NULL,
+		refreshed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (billing_code_type, billing_code, payer, state, billing_class, negotiated_type)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	// Rates the outlier analysis flagged for review, with the robust
+	// statistics of their billing code and billing class (median and MAD in
+	// dollars, robust_z on log rates) and why. Quarantined rates are hidden by
+	// active_negotiated_rates until a reviewer releases them.
+	{"rate outliers", `
+	CREATE TABLE IF NOT EXISTS rate_outliers (
+		negotiated_rate_id INT PRIMARY KEY,
+		run_id BIGINT NULL,
+		billing_code_type VARCHAR(20) NOT NULL,
+		billing_code VARCHAR(50) NOT NULL,
+		billing_class ENUM('professional', 'institutional') NOT NULL,
+		negotiated_type ENUM('percentage', 'negotiated') NOT NULL,
+		negotiated_rate DECIMAL(15,2) NOT NULL,
+		median DECIMAL(15,2) NULL,
+		mad DECIMAL(15,2) NULL,
+		sample_size INT NOT NULL DEFAULT 0,
+		robust_z DECIMAL(10,2) NULL,
+		reason VARCHAR(255) NOT NULL,
+		status ENUM('flagged', 'quarantined', 'released') NOT NULL DEFAULT 'flagged',
+		detected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		reviewed_at TIMESTAMP NULL,
+		FOREIGN KEY (negotiated_rate_id) REFERENCES negotiated_rates(id) ON DELETE CASCADE,
+		INDEX idx_code (billing_code_type, billing_code),
+		INDEX idx_status (status)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+}
+
+// feeTables hold the fee schedules rates are compared with
+var feeTables = []schemaTable{
+	// Reference fees are the dollar amounts percentage rates apply to, e.g.
+	// the Medicare PFS or OPPS payment of a code
+	{"reference fees", `
+	CREATE TABLE IF NOT EXISTS reference_fees (
+		billing_code_type VARCHAR(20) NOT NULL,
+		billing_code VARCHAR(50) NOT NULL,
+		billing_class ENUM('professional', 'institutional') NOT NULL,
+		amount DECIMAL(15,2) NOT NULL,
+		schedule VARCHAR(100) NOT NULL DEFAULT '',
+		loaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		PRIMARY KEY (billing_code_type, billing_code, billing_class)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	// Medicare fee schedules: PFS RVUs and GPCIs by locality for professional
+	// rates, OPPS payment rates for institutional ones
+	{"Medicare PFS RVUs", `
+	CREATE TABLE IF NOT EXISTS medicare_pfs_rvus (
+		billing_code VARCHAR(50) PRIMARY KEY,
+		work_rvu DECIMAL(8,2) NOT NULL,
+		nonfacility_pe_rvu DECIMAL(8,2) NULL,
+		facility_pe_rvu DECIMAL(8,2) NULL,
+		mp_rvu DECIMAL(8,2) NOT NULL,
+		conversion_factor DECIMAL(10,4) NOT NULL,
+		loaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	{"Medicare GPCIs", `
+	CREATE TABLE IF NOT EXISTS medicare_gpcis (
+		locality_id VARCHAR(10) PRIMARY KEY,
+		state VARCHAR(2) NOT NULL DEFAULT '',
+		name VARCHAR(255) NOT NULL DEFAULT '',
+		work_gpci DECIMAL(6,3) NOT NULL,
+		pe_gpci DECIMAL(6,3) NOT NULL,
+		mp_gpci DECIMAL(6,3) NOT NULL,
+		loaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	{"Medicare ZIP localities", `
+	CREATE TABLE IF NOT EXISTS medicare_zip_localities (
+		zip CHAR(5) PRIMARY KEY,
+		state VARCHAR(2) NOT NULL DEFAULT '',
+		locality_id VARCHAR(10) NOT NULL,
+		loaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		INDEX idx_locality (locality_id)
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+	{"Medicare OPPS rates", `
+	CREATE TABLE IF NOT EXISTS medicare_opps_rates (
+		billing_code VARCHAR(50) PRIMARY KEY,
+		apc VARCHAR(10) NULL,
+		payment_rate DECIMAL(15,2) NOT NULL,
+		loaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+}
+
+// migrationTables record the migrations applied, see RunMigrations
+var migrationTables = []schemaTable{
+	{"migrations", `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		name VARCHAR(100) PRIMARY KEY,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+	`},
+}
+
+// schema lists the tables of a new database, each after the tables its
+// foreign keys reference
+var schema = [][]schemaTable{
+	rateTables, runTables, directoryTables, apiKeyTables, analysisTables, feeTables, migrationTables,
+}
+
+// CreateTables creates the necessary database tables
+func (s *DataIngestionService) CreateTables() error {
+	log.Println("🏗️ Creating database tables...")
+
+	for _, tables := range schema {
+		for _, table := range tables {
+			if _, err := s.db.Exec(table.ddl); err != nil {
+				return fmt.Errorf("failed to create %s table: %v", table.name, err)
+			}
+		}
+	}
+
+	log.Println("✅ Database tables created successfully")
+	return nil
+}
+
+// migration is a named, run-once change to an existing database
+type migration struct {
+	name string
+	run  func(s *DataIngestionService) error
+}
+
+// migrations lists all migrations in the order they must be applied
+var migrations = []migration{
+	{name: "0001_backfill_rate_links", run: (*DataIngestionService).backfillRateLinks},
+	{name: "0002_rate_history_columns", run: (*DataIngestionService).addRateHistoryColumns},
+	{name: "0003_staging_runs", run: (*DataIngestionService).addStagingRuns},
+	{name: "0004_run_plan_columns", run: (*DataIngestionService).addRunPlanColumns},
+	{name: "0005_fulltext_search", run: (*DataIngestionService).addFulltextSearch},
+	{name: "0006_provider_zip5", run: (*DataIngestionService).addProviderZIP5},
+	{name: "0007_rate_aggregates", run: (*DataIngestionService).backfillAggregates},
+	{name: "0008_medicare_benchmarks", run: (*DataIngestionService).addMedicareBenchmarks},
+	{name: "0009_rate_quarantine", run: (*DataIngestionService).addRateQuarantine},
+	{name: "0010_rate_closed_run", run: (*DataIngestionService).addRateClosedRun},
+	{name: "0011_current_rate_index", run: (*DataIngestionService).addCurrentRateIndex},
+}
+
+// RunMigrations applies all migrations not yet recorded in schema_migrations
+func (s *DataIngestionService) RunMigrations() error {
+	for _, m := range migrations {
+		var applied int
+		err := s.db.QueryRow("SELECT COUNT(*) FROM schema_migrations WHERE name = ?", m.name).Scan(&applied)
+		if err != nil {
+			return fmt.Errorf("failed to check migration %s: %v", m.name, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		log.Printf("🔧 Applying migration %s...", m.name)
+		if err := m.run(s); err != nil {
+			return fmt.Errorf("migration %s failed: %v", m.name, err)
+		}
+
+		if _, err := s.db.Exec("INSERT INTO schema_migrations (name) VALUES (?)", m.name); err != nil {
+			return fmt.Errorf("failed to record migration %s: %v", m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// columnExists reports whether a column exists in the current database
+func (s *DataIngestionService) columnExists(table, column string) (bool, error) {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+	`, table, column).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check column %s.%s: %v", table, column, err)
+	}
+	return count > 0, nil
+}
+
+// indexExists reports whether an index exists in the current database
+func (s *DataIngestionService) indexExists(table, index string) (bool, error) {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME = ?
+	`, table, index).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check index %s.%s: %v", table, index, err)
+	}
+	return count > 0, nil
+}
+
+// addColumns adds the given columns and indexes to a table unless they
+// already exist (CreateTables includes them for new databases)
+func (s *DataIngestionService) addColumns(table string, columns, indexes [][2]string) error {
+	for _, col := range columns {
+		exists, err := s.columnExists(table, col[0])
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, col[0], col[1])); err != nil {
+				return fmt.Errorf("failed to add column %s.%s: %v", table, col[0], err)
+			}
+		}
+	}
+
+	for _, idx := range indexes {
+		exists, err := s.indexExists(table, idx[0])
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err := s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD INDEX %s (%s)", table, idx[0], idx[1])); err != nil {
+				return fmt.Errorf("failed to add index %s.%s: %v", table, idx[0], err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// addRateHistoryColumns adds the effective period columns to negotiated_rates
+func (s *DataIngestionService) addRateHistoryColumns() error {
+	return s.addColumns("negotiated_rates",
+		[][2]string{
+			{"rate_key", "BINARY(32) NULL"},
+			{"valid_from", "DATE NULL"},
+			{"valid_to", "DATE NULL"},
+			{"run_id", "BIGINT NULL"},
+			{"last_seen_run_id", "BIGINT NULL"},
+		},
+		[][2]string{
+			{"idx_rate_key_valid_to", "rate_key, valid_to"},
+			{"idx_run_id", "run_id"},
+			{"idx_last_seen_run_id", "last_seen_run_id"},
+		},
+	)
+}
+
+// addStagingRuns adds the run columns used by staging mode and the views that
+// only expose the active version of staged data
+func (s *DataIngestionService) addStagingRuns() error {
+	if err := s.addColumns("insurance_services",
+		[][2]string{{"run_id", "BIGINT NULL"}},
+		[][2]string{{"idx_run_id", "run_id"}},
+	); err != nil {
+		return err
+	}
+
+	if err := s.addColumns("ingestion_runs",
+		[][2]string{{"staging", "TINYINT(1) NOT NULL DEFAULT 0"}},
+		nil,
+	); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"insurance_services", "negotiated_rates"} {
+		if err := s.createActiveView(table, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createActiveView creates the view of the visible rows of a table, with an
+// optional extra condition on its rows (t). A row is visible when its run is
+// the active version of its reporting entity, or when it was not loaded
+// through staging and the entity has no active version yet. Rows from before
+// the run ledger are always visible.
+func (s *DataIngestionService) createActiveView(table, condition string) error {
+	if condition != "" {
+		condition = " AND " + condition
+	}
+	view := fmt.Sprintf(`
+		CREATE OR REPLACE VIEW active_%[1]s AS
+		SELECT t.* FROM %[1]s t
+		LEFT JOIN ingestion_runs ir ON ir.id = t.run_id
+		LEFT JOIN active_file_versions v ON v.reporting_entity_name = ir.reporting_entity_name
+		WHERE (ir.id IS NULL OR v.run_id = ir.id OR (v.run_id IS NULL AND ir.staging = 0))%[2]s
+	`, table, condition)
+	if _, err := s.db.Exec(view); err != nil {
+		return fmt.Errorf("failed to create view active_%s: %v", table, err)
+	}
+	return nil
+}
+
+// addRunPlanColumns adds the plan fields of the file header to ingestion_runs
+func (s *DataIngestionService) addRunPlanColumns() error {
+	return s.addColumns("ingestion_runs",
+		[][2]string{
+			{"plan_name", "VARCHAR(255) NULL"},
+			{"plan_id_type", "VARCHAR(20) NULL"},
+			{"plan_id", "VARCHAR(50) NULL"},
+			{"plan_market_type", "VARCHAR(20) NULL"},
+		},
+		[][2]string{{"idx_plan", "plan_name, plan_id"}},
+	)
+}
+
+// addFulltextSearch adds the FULLTEXT index over service names and descriptions
+func (s *DataIngestionService) addFulltextSearch() error {
+	exists, err := s.indexExists("insurance_services", "ft_name_description")
+	if err != nil || exists {
+		return err
+	}
+
+	log.Println("🔧 Building full-text index, this may take a while on large tables...")
+	_, err = s.db.Exec("ALTER TABLE insurance_services ADD FULLTEXT INDEX ft_name_description (name, description)")
+	if err != nil {
+		return fmt.Errorf("failed to add full-text index: %v", err)
+	}
+	return nil
+}
+
+// addProviderZIP5 adds the five-digit ZIP used to place providers on the map
+func (s *DataIngestionService) addProviderZIP5() error {
+	return s.addColumns("providers",
+		[][2]string{
+			{"address_zip5", "CHAR(5) AS (LEFT(address->>'$.zip', 5)) STORED"},
+		},
+		[][2]string{
+			{"idx_zip5", "address_zip5"},
+		},
+	)
+}
+
+// addMedicareBenchmarks adds the Medicare columns to rate_aggregates; they
+// fill in when the Medicare fee schedules are loaded
+func (s *DataIngestionService) addMedicareBenchmarks() error {
+	return s.addColumns("rate_aggregates",
+		[][2]string{
+			{"medicare_amount", "DECIMAL(15,2) NULL"},
+			{"median_pct_of_medicare", "DECIMAL(10,1) NULL"},
+		},
+		nil,
+	)
+}
+
+// addRateQuarantine adds the quarantine flag of outlier rates and hides
+// quarantined rates from active_negotiated_rates. The view is recreated even
+// when the column exists, since it only picks up columns added before it.
+func (s *DataIngestionService) addRateQuarantine() error {
+	if err := s.addColumns("negotiated_rates",
+		[][2]string{{"quarantined", "TINYINT(1) NOT NULL DEFAULT 0"}},
+		nil,
+	); err != nil {
+		return err
+	}
+	return s.createActiveView("negotiated_rates", "t.quarantined = 0")
+}
+
+// addRateClosedRun records the run that closed a rate's period, so the
+// codes a history-mode run changed can be found from its rates alone
+func (s *DataIngestionService) addRateClosedRun() error {
+	if err := s.addColumns("negotiated_rates",
+		[][2]string{{"closed_run_id", "BIGINT NULL"}},
+		[][2]string{{"idx_closed_run_id", "closed_run_id"}},
+	); err != nil {
+		return err
+	}
+	return s.createActiveView("negotiated_rates", "t.quarantined = 0")
+}
+
+// addCurrentRateIndex indexes the current rates of a service by rate, the
+// order in which the API pages through them
+func (s *DataIngestionService) addCurrentRateIndex() error {
+	return s.addColumns("negotiated_rates", nil,
+		[][2]string{{"idx_service_current_rate", "service_id, valid_to, negotiated_rate"}},
+	)
+}
+
+// backfillBatchSize is the number of negotiated_rates ids handled per backfill statement
+const backfillBatchSize = 10000
+
+// backfillRateLinks populates the provider group and place of service join
+// tables from the JSON columns of rates ingested before they existed
+func (s *DataIngestionService) backfillRateLinks() error {
+	backfillProviderGroups := `
+		INSERT IGNORE INTO negotiated_rate_provider_groups (negotiated_rate_id, provider_group_id)
+		SELECT r.id, jt.provider_group_id
+		FROM negotiated_rates r,
+			JSON_TABLE(r.provider_references, '$[*]' COLUMNS (provider_group_id INT PATH '$')) jt
+		WHERE r.id BETWEEN ? AND ?
+	`
+
+	backfillPlacesOfService := `
+		INSERT IGNORE INTO negotiated_rate_places_of_service (negotiated_rate_id, place_of_service)
+		SELECT r.id, jt.place_of_service
+		FROM negotiated_rates r,
+			JSON_TABLE(r.service_codes, '$[*]' COLUMNS (place_of_service VARCHAR(10) PATH '$')) jt
+		WHERE r.id BETWEEN ? AND ?
+	`
+
+	var minID, maxID sql.NullInt64
+	if err := s.db.QueryRow("SELECT MIN(id), MAX(id) FROM negotiated_rates").Scan(&minID, &maxID); err != nil {
+		return fmt.Errorf("failed to get rate id range: %v", err)
+	}
+	if !minID.Valid {
+		return nil
+	}
+
+	for start := minID.Int64; start <= maxID.Int64; start += backfillBatchSize {
+		end := start + backfillBatchSize - 1
+
+		if _, err := s.db.Exec(backfillProviderGroups, start, end); err != nil {
+			return fmt.Errorf("failed to backfill provider groups: %v", err)
+		}
+
+		if _, err := s.db.Exec(backfillPlacesOfService, start, end); err != nil {
+			return fmt.Errorf("failed to backfill places of service: %v", err)
+		}
+
+		log.Printf("📊 Backfilled rate links up to id %d of %d", end, maxID.Int64)
+	}
+
+	return nil
+}
+
+// runMigrate implements the migrate mode: it only brings the schema up to date
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+
+	if err := service.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create tables: %v", err)
+	}
+	if err := service.RunMigrations(); err != nil {
+		return fmt.Errorf("failed to run migrations: %v", err)
+	}
+
+	log.Println("🎉 Migrations completed successfully!")
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"healthcare-saver-ingest/internal/aggregate"
+	"healthcare-saver-ingest/internal/config"
+	"healthcare-saver-ingest/internal/outlier"
+)
+
+// Insurance data structures
+type NegotiatedPrice struct {
+	NegotiatedType string   `json:"negotiated_type"`
+	NegotiatedRate float64  `json:"negotiated_rate"`
+	ExpirationDate string   `json:"expiration_date"`
+	ServiceCode    []string `json:"service_code"`
+	BillingClass   string   `json:"billing_class"`
+	// BillingCodeModifier is optional in the schema and only part of the rate key
+	BillingCodeModifier []string `json:"billing_code_modifier,omitempty"`
+}
+
+type NegotiatedRate struct {
+	ProviderReferences []int             `json:"provider_references"`
+	NegotiatedPrices   []NegotiatedPrice `json:"negotiated_prices"`
+}
+
+type InsuranceService struct {
+	NegotiationArrangement string           `json:"negotiation_arrangement"`
+	Name                   string           `json:"name"`
+	BillingCodeType        string           `json:"billing_code_type"`
+	BillingCodeTypeVersion string           `json:"billing_code_type_version"`
+	BillingCode            string           `json:"billing_code"`
+	Description            string           `json:"description"`
+	NegotiatedRates        []NegotiatedRate `json:"negotiated_rates"`
+}
+
+// FileHeader holds the file-level fields of an MRF file. In line-delimited
+// files they arrive as a line without a billing code, ideally the first one.
+type FileHeader struct {
+	ReportingEntityName string `json:"reporting_entity_name"`
+	ReportingEntityType string `json:"reporting_entity_type"`
+	PlanName            string `json:"plan_name"`
+	PlanIDType          string `json:"plan_id_type"`
+	PlanID              string `json:"plan_id"`
+	PlanMarketType      string `json:"plan_market_type"`
+	LastUpdatedOn       string `json:"last_updated_on"`
+	Version             string `json:"version"`
+}
+
+// ProviderReference resolves a provider_group_id used in provider_references
+// to the NPIs and TIN of its provider groups
+type ProviderReference struct {
+	ProviderGroupID int             `json:"provider_group_id"`
+	ProviderGroups  []ProviderGroup `json:"provider_groups"`
+}
+
+// ProviderGroup is a set of NPIs billing under one TIN
+type ProviderGroup struct {
+	NPI []int64 `json:"npi"`
+	TIN struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"tin"`
+}
+
+// ingestionRun is a row of the ingestion_runs ledger, one per processed file
+type ingestionRun struct {
+	ID int64
+	FileHeader
+}
+
+// DataIngestionService handles database operations
+type DataIngestionService struct {
+	db     *sql.DB
+	config *config.DBConfig
+
+	// history keeps one row per rate per effective period (valid_from/valid_to)
+	// instead of appending every file's rates
+	history bool
+
+	// header overrides for files that carry no header line
+	lastUpdatedOn   string
+	reportingEntity string
+
+	// staging loads each file as an inactive run that only becomes visible
+	// through the active_* views once it is validated and activated
+	staging         bool
+	stagingMinRatio float64
+	keepPrevious    bool
+
+	// skipAggregates leaves rate_aggregates stale after each file, for bulk
+	// loads followed by the aggregate mode
+	skipAggregates bool
+
+	// outliers are the thresholds of the outlier analysis after each file;
+	// quarantineOutliers hides the extreme rates of the file instead of
+	// publishing them
+	outliers           outlier.Options
+	skipOutliers       bool
+	quarantineOutliers bool
+
+	// run is the ledger entry of the file being processed
+	run       *ingestionRun
+	runPrices atomic.Int64
+	// rosters resolves the provider references of the file for rate keys
+	rosters *rosters
+
+	// replaced are the aggregate groups of the staged version the current
+	// run replaced
+	replaced []aggregate.Group
+}
+
+// NewDataIngestionService creates a new ingestion service
+func NewDataIngestionService(cfg *config.DBConfig) (*DataIngestionService, error) {
+	db, err := cfg.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DataIngestionService{
+		db:       db,
+		config:   cfg,
+		outliers: outlier.DefaultOptions,
+	}, nil
+}
+
+// GetStatistics returns database statistics
+func (s *DataIngestionService) GetStatistics() error {
+	var servicesCount, ratesCount int
+
+	err := s.db.QueryRow("SELECT COUNT(*) FROM insurance_services").Scan(&servicesCount)
+	if err != nil {
+		return fmt.Errorf("failed to get services count: %v", err)
+	}
+
+	err = s.db.QueryRow("SELECT COUNT(*) FROM negotiated_rates").Scan(&ratesCount)
+	if err != nil {
+		return fmt.Errorf("failed to get rates count: %v", err)
+	}
+
+	log.Printf("\n📊 Database Statistics:")
+	log.Printf("   Services: %d", servicesCount)
+	log.Printf("   Negotiated Rates: %d", ratesCount)
+
+	return nil
+}
+
+// Close closes the database connection
+func (s *DataIngestionService) Close() error {
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// readTable calls handle with the values of columns, in order, for every row
+// of a comma or tab separated file (optionally .gz). columns lists the
+// accepted header names of each column, normalized by normalizeHeader; a name
+// starting with ~ matches any header containing the rest. The header is the
+// first line with all of the first required columns, so title lines above it
+// (as in CMS downloads) are skipped. Missing optional columns are "".
+func readTable(filePath string, columns [][]string, required int, handle func(lineNumber int, values []string) error) error {
+	scanner, closeFile, err := openLineScanner(filePath)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	var (
+		separator = ','
+		positions []int // positions of columns, -1 when missing
+		headerErr = fmt.Errorf("empty file")
+		lineCount int
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		lineCount++
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if positions == nil {
+			separator = ','
+			if strings.Contains(line, "\t") {
+				separator = '\t'
+			}
+		}
+		reader := csv.NewReader(strings.NewReader(line))
+		reader.Comma = separator
+		reader.LazyQuotes = true
+		fields, err := reader.Read()
+		if err != nil {
+			log.Printf("⚠️ Skipping unreadable line %d: %v", lineCount, err)
+			continue
+		}
+
+		if positions == nil {
+			positions, headerErr = findColumns(fields, columns, required)
+			continue
+		}
+
+		values := make([]string, len(columns))
+		for i, pos := range positions {
+			if pos >= 0 && pos < len(fields) {
+				values[i] = strings.TrimSpace(fields[pos])
+			}
+		}
+		if err := handle(lineCount, values); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+	if positions == nil {
+		return headerErr
+	}
+	return nil
+}
+
+// findColumns returns the positions of columns in a header row, -1 for the
+// optional columns it lacks
+func findColumns(header []string, columns [][]string, required int) ([]int, error) {
+	positions := make([]int, len(columns))
+	for c := range positions {
+		positions[c] = -1
+	}
+	for i, name := range header {
+		name = normalizeHeader(name)
+		for c, names := range columns {
+			for _, accepted := range names {
+				matches := name == accepted
+				if strings.HasPrefix(accepted, "~") {
+					matches = strings.Contains(name, accepted[1:])
+				}
+				if matches && positions[c] < 0 {
+					positions[c] = i
+				}
+			}
+		}
+	}
+
+	for c := 0; c < required; c++ {
+		if positions[c] < 0 {
+			return nil, fmt.Errorf("missing %s column in header", columns[c][0])
+		}
+	}
+	return positions, nil
+}
+
+// normalizeHeader lower-cases a header name and joins its words with
+// underscores, so "NON-FAC PE RVU" becomes non_fac_pe_rvu
+func normalizeHeader(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return strings.Join(words, "_")
+}
+
+// parseAmount parses a dollar amount such as "$1,152.93"
+func parseAmount(value string) (float64, error) {
+	return strconv.ParseFloat(strings.NewReplacer("$", "", ",", "").Replace(strings.TrimSpace(value)), 64)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"healthcare-saver-ingest/internal/config"
+)
+
+// LoadTerms upserts lay terms for billing codes from a CSV file with the
+// columns billing_code_type, billing_code, term (with a header row)
+func (s *DataIngestionService) LoadTerms(filePath string) error {
+	log.Printf("📁 Loading search terms: %s", filePath)
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("empty terms file")
+	}
+
+	stmt, err := s.db.Prepare(`
+		INSERT IGNORE INTO service_terms (billing_code_type, billing_code, term)
+		VALUES (?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare term statement: %v", err)
+	}
+	defer stmt.Close()
+
+	loaded := 0
+	for i, record := range records[1:] {
+		if len(record) != 3 {
+			log.Printf("⚠️ Skipping row %d: expected 3 columns, got %d", i+2, len(record))
+			continue
+		}
+		codeType, code, term := strings.TrimSpace(record[0]), strings.TrimSpace(record[1]), strings.TrimSpace(record[2])
+		if codeType == "" || code == "" || term == "" {
+			log.Printf("⚠️ Skipping row %d: empty column", i+2)
+			continue
+		}
+
+		result, err := stmt.Exec(codeType, code, strings.ToLower(term))
+		if err != nil {
+			return fmt.Errorf("failed to insert term %q: %v", term, err)
+		}
+		if affected, err := result.RowsAffected(); err == nil {
+			loaded += int(affected)
+		}
+	}
+
+	log.Printf("🎉 Loaded %d new terms from %s", loaded, filePath)
+	// Terms only change search, not the responses of billing codes
+	return s.recordLoad("terms", false, nil)
+}
+
+// runTerms implements the terms mode: it loads lay terms for search
+func runTerms(args []string) error {
+	fs := flag.NewFlagSet("terms", flag.ExitOnError)
+	file := fs.String("file", "data/lay-terms.csv", "Terms CSV (billing_code_type,billing_code,term)")
+	fs.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+
+	if err := service.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create tables: %v", err)
+	}
+
+	return service.LoadTerms(*file)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"healthcare-saver-ingest/internal/config"
+)
+
+// zipColumns are the accepted header names of the ZIP, latitude and longitude
+// columns, covering plain CSV files and the Census ZCTA Gazetteer files
+var zipColumns = [3][]string{
+	{"zip", "zipcode", "zip_code", "zcta", "zcta5", "geoid"},
+	{"latitude", "lat", "intptlat"},
+	{"longitude", "lon", "lng", "intptlong"},
+}
+
+// LoadZIPCentroids upserts ZIP code centroids from a comma or tab separated
+// file with a header row, e.g. the Census ZCTA Gazetteer file
+// (2023_Gaz_zcta_national.txt) or a zip,latitude,longitude CSV
+func (s *DataIngestionService) LoadZIPCentroids(filePath string) error {
+	log.Printf("📁 Loading ZIP centroids: %s", filePath)
+
+	scanner, closeFile, err := openLineScanner(filePath)
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	stmt, err := s.db.Prepare(`
+		INSERT INTO zip_centroids (zip, latitude, longitude, location)
+		VALUES (?, ?, ?, POINT(?, ?))
+		ON DUPLICATE KEY UPDATE latitude = VALUES(latitude), longitude = VALUES(longitude),
+			location = VALUES(location)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare ZIP statement: %v", err)
+	}
+	defer stmt.Close()
+
+	var (
+		separator = ","
+		columns   []int // positions of the ZIP, latitude and longitude columns
+		lineCount int
+		loaded    int
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		lineCount++
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if columns == nil {
+			if strings.Contains(line, "\t") {
+				separator = "\t"
+			}
+			columns, err = findZIPColumns(strings.Split(line, separator))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		fields := strings.Split(line, separator)
+		var values [3]string
+		for i, col := range columns {
+			if col < len(fields) {
+				values[i] = strings.Trim(strings.TrimSpace(fields[col]), `"`)
+			}
+		}
+
+		zip := values[0]
+		lat, latErr := strconv.ParseFloat(values[1], 64)
+		lon, lonErr := strconv.ParseFloat(values[2], 64)
+		if len(zip) != 5 || latErr != nil || lonErr != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			log.Printf("⚠️ Skipping invalid ZIP centroid on line %d", lineCount)
+			continue
+		}
+
+		if _, err := stmt.Exec(zip, lat, lon, lon, lat); err != nil {
+			return fmt.Errorf("failed to upsert ZIP %s: %v", zip, err)
+		}
+		loaded++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading file: %v", err)
+	}
+	if columns == nil {
+		return fmt.Errorf("empty ZIP centroid file")
+	}
+
+	log.Printf("🎉 Loaded %d ZIP centroids from %s", loaded, filePath)
+	// Centroids place the rates of every billing code
+	return s.recordLoad("zips", true, nil)
+}
+
+// findZIPColumns returns the positions of the ZIP, latitude and longitude
+// columns in a header row
+func findZIPColumns(header []string) ([]int, error) {
+	columns := []int{-1, -1, -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.Trim(strings.TrimSpace(name), `"`))
+		for c, names := range zipColumns {
+			for _, accepted := range names {
+				if name == accepted && columns[c] < 0 {
+					columns[c] = i
+				}
+			}
+		}
+	}
+
+	for c, col := range columns {
+		if col < 0 {
+			return nil, fmt.Errorf("missing %s column in header", zipColumns[c][0])
+		}
+	}
+	return columns, nil
+}
+
+// zctaGazetteer is the Census ZCTA Gazetteer file fetch-zcta-gazetteer.sh
+// downloads, the centroids of every ZIP code
+const zctaGazetteer = "data/2023_Gaz_zcta_national.txt.gz"
+
+// sampleZIPCentroids holds approximate centroids of a few metro ZIP codes for
+// development
+const sampleZIPCentroids = "data/zip-centroids.csv"
+
+// runZIPs implements the zips mode: it loads ZIP code centroids for radius search
+func runZIPs(args []string) error {
+	fs := flag.NewFlagSet("zips", flag.ExitOnError)
+	var (
+		file   = fs.String("file", zctaGazetteer, "ZIP centroid file (CSV or Census ZCTA Gazetteer, optionally .gz)")
+		sample = fs.Bool("sample", false, "Load the "+sampleZIPCentroids+" sample of a few metro ZIP codes, for development only")
+	)
+	fs.Parse(args)
+
+	switch {
+	case *sample && *file != zctaGazetteer:
+		return fmt.Errorf("-sample and -file cannot be combined")
+	case *sample:
+		log.Printf("⚠️ Loading the %s sample of a few metro ZIP codes: radius search will not find providers elsewhere", sampleZIPCentroids)
+		*file = sampleZIPCentroids
+	case *file == zctaGazetteer:
+		if _, err := os.Stat(*file); os.IsNotExist(err) {
+			return fmt.Errorf("%s not found: download it with ./fetch-zcta-gazetteer.sh, or use -sample for the development ZIP codes", zctaGazetteer)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %v", err)
+	}
+
+	service, err := NewDataIngestionService(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create ingestion service: %v", err)
+	}
+	defer service.Close()
+
+	if err := service.CreateTables(); err != nil {
+		return fmt.Errorf("failed to create tables: %v", err)
+	}
+	if err := service.RunMigrations(); err != nil {
+		return fmt.Errorf("failed to run migrations: %v", err)
+	}
+
+	return service.LoadZIPCentroids(*file)
+}
@@ -0,0 +1,18 @@
+#!/bin/bash
+# Downloads the Census ZCTA Gazetteer file (public domain) into
+# data/2023_Gaz_zcta_national.txt.gz, the default file of `ingest-data zips`
+
+set -euo pipefail
+
+URL="https://www2.census.gov/geo/docs/maps-data/data/gazetteer/2023_Gazetteer/2023_Gaz_zcta_national.zip"
+OUT="$(dirname "$0")/data/2023_Gaz_zcta_national.txt.gz"
+
+echo "📥 Downloading the Census ZCTA Gazetteer..."
+TMP=$(mktemp)
+trap 'rm -f "$TMP"' EXIT
+curl -fsSL "$URL" -o "$TMP"
+
+unzip -p "$TMP" 2023_Gaz_zcta_national.txt | gzip -9 > "$OUT"
+
+echo "✅ Wrote $OUT ($(zcat "$OUT" | tail -n +2 | wc -l) ZIP codes)"
+echo "   Load it with: ./ingest-data zips"
@@ -0,0 +1,280 @@
+// Package aggregate maintains rate_aggregates, the precomputed distribution
+// of the current rates of every billing code by billing class, negotiated
+// type, payer and state. Percentiles over millions of rates are too slow to
+// compute per request, so the ingestion tool refreshes the groups each run
+// touched and the API merges the stored sketches instead. Dollar rows also
+// carry the national Medicare amount and their median as a percent of it.
+package aggregate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"healthcare-saver-ingest/internal/medicare"
+)
+
+// insertBatchSize is the number of aggregate rows written per statement
+const insertBatchSize = 200
+
+// Group is the unit of refresh: the current rates of one billing code
+// published by one payer. A run only ever changes the groups of its own
+// reporting entity.
+type Group struct {
+	BillingCodeType string
+	BillingCode     string
+	Payer           string // reporting entity name, "" when unknown
+}
+
+// Aggregates refreshes the rate_aggregates table
+type Aggregates struct {
+	db *sql.DB
+}
+
+// New creates an aggregate writer on an open database
+func New(db *sql.DB) *Aggregates {
+	return &Aggregates{db: db}
+}
+
+// RunGroups returns the groups of the rates a run inserted, extended or
+// closed. History mode extends the rates and services of earlier runs, so
+// the services a run loaded are not enough.
+func (a *Aggregates) RunGroups(ctx context.Context, runID int64) ([]Group, error) {
+	return a.groups(ctx, `
+		SELECT DISTINCT s.billing_code_type, s.billing_code, COALESCE(ir.reporting_entity_name, '')
+		FROM negotiated_rates r
+		JOIN insurance_services s ON s.id = r.service_id
+		LEFT JOIN ingestion_runs ir ON ir.id = r.run_id
+		WHERE r.run_id = ? OR r.last_seen_run_id = ? OR r.closed_run_id = ?
+	`, runID, runID, runID)
+}
+
+// AllGroups returns every group with current rates or stored aggregates,
+// optionally only those of one billing code
+func (a *Aggregates) AllGroups(ctx context.Context, codeType, code string) ([]Group, error) {
+	rateWhere, aggregateWhere, args := "", "", []interface{}{}
+	if code != "" {
+		rateWhere = " AND s.billing_code_type = ? AND s.billing_code = ?"
+		aggregateWhere = " WHERE billing_code_type = ? AND billing_code = ?"
+		args = []interface{}{codeType, code, codeType, code}
+	}
+	return a.groups(ctx, `
+		SELECT s.billing_code_type, s.billing_code, COALESCE(ir.reporting_entity_name, '')
+		FROM active_insurance_services s
+		JOIN active_negotiated_rates r ON r.service_id = s.id
+		LEFT JOIN ingestion_runs ir ON ir.id = r.run_id
+		WHERE r.valid_to IS NULL`+rateWhere+`
+		UNION
+		SELECT billing_code_type, billing_code, payer FROM rate_aggregates`+aggregateWhere+`
+		ORDER BY 1, 2, 3
+	`, args...)
+}
+
+// groups runs a query returning billing code types, billing codes and payers
+func (a *Aggregates) groups(ctx context.Context, query string, args ...interface{}) ([]Group, error) {
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aggregate groups: %v", err)
+	}
+	defer rows.Close()
+
+	var groups []Group
+	for rows.Next() {
+		var g Group
+		if err := rows.Scan(&g.BillingCodeType, &g.BillingCode, &g.Payer); err != nil {
+			return nil, fmt.Errorf("failed to scan aggregate group: %v", err)
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read aggregate groups: %v", err)
+	}
+	return groups, nil
+}
+
+// Refresh recomputes the aggregates of groups with the given number of
+// workers, calling progress (if not nil) after each group
+func (a *Aggregates) Refresh(ctx context.Context, groups []Group, workers int, progress func(done int)) error {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		done     int
+		firstErr error
+	)
+	next := make(chan Group)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for g := range next {
+				err := a.refreshGroup(ctx, g)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("failed to aggregate %s %s of %q: %v", g.BillingCodeType, g.BillingCode, g.Payer, err)
+					cancel()
+				}
+				done++
+				if err == nil && progress != nil {
+					progress(done)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, g := range groups {
+		select {
+		case next <- g:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// key identifies an aggregate row within a group; state "" covers all states
+type key struct {
+	billingClass, negotiatedType, state string
+}
+
+// refreshGroup replaces the aggregate rows of a group. Each current rate
+// counts once in its payer's all-states row and once in the row of every
+// state its providers practice in, by the provider directory.
+func (a *Aggregates) refreshGroup(ctx context.Context, g Group) error {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT DISTINCT r.id, r.billing_class, r.negotiated_type, r.negotiated_rate, p.address_state
+		FROM active_insurance_services s
+		JOIN active_negotiated_rates r ON r.service_id = s.id
+		LEFT JOIN ingestion_runs ir ON ir.id = r.run_id
+		LEFT JOIN negotiated_rate_provider_groups pg ON pg.negotiated_rate_id = r.id
+		LEFT JOIN provider_group_npis pn ON pn.provider_group_id = pg.provider_group_id AND pn.run_id = r.last_seen_run_id
+		LEFT JOIN providers p ON p.npi = pn.npi
+		WHERE s.billing_code_type = ? AND s.billing_code = ? AND COALESCE(ir.reporting_entity_name, '') = ?
+			AND r.valid_to IS NULL
+		ORDER BY r.id
+	`, g.BillingCodeType, g.BillingCode, g.Payer)
+	if err != nil {
+		return fmt.Errorf("failed to query rates: %v", err)
+	}
+	defer rows.Close()
+
+	sketches := make(map[key]*Sketch)
+	var keys []key
+	add := func(k key, rate float64) {
+		s, ok := sketches[k]
+		if !ok {
+			s = NewSketch()
+			sketches[k] = s
+			keys = append(keys, k)
+		}
+		s.Add(rate)
+	}
+
+	lastID := int64(-1)
+	for rows.Next() {
+		var (
+			id                           int64
+			billingClass, negotiatedType string
+			rate                         float64
+			state                        sql.NullString
+		)
+		if err := rows.Scan(&id, &billingClass, &negotiatedType, &rate, &state); err != nil {
+			return fmt.Errorf("failed to scan rate: %v", err)
+		}
+		if id != lastID {
+			add(key{billingClass, negotiatedType, ""}, rate)
+			lastID = id
+		}
+		if state.Valid && state.String != "" {
+			add(key{billingClass, negotiatedType, state.String}, rate)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read rates: %v", err)
+	}
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM rate_aggregates WHERE billing_code_type = ? AND billing_code = ? AND payer = ?
+	`, g.BillingCodeType, g.BillingCode, g.Payer)
+	if err != nil {
+		return fmt.Errorf("failed to delete aggregates: %v", err)
+	}
+
+	for start := 0; start < len(keys); start += insertBatchSize {
+		batch := keys[start:min(start+insertBatchSize, len(keys))]
+		args := make([]interface{}, 0, len(batch)*17)
+		for _, k := range batch {
+			s := sketches[k]
+			args = append(args, g.BillingCodeType, g.BillingCode, g.Payer, k.state, k.billingClass, k.negotiatedType,
+				s.Count, s.Min, s.Max, s.Sum, round2(s.Mean()),
+				round2(s.Quantile(0.10)), round2(s.Quantile(0.25)), round2(s.Quantile(0.50)),
+				round2(s.Quantile(0.75)), round2(s.Quantile(0.90)), s.Digest())
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO rate_aggregates (billing_code_type, billing_code, payer, state, billing_class, negotiated_type,
+				rate_count, min_rate, max_rate, sum_rate, mean_rate, p10, p25, median, p75, p90, digest)
+			VALUES `+strings.TrimSuffix(strings.Repeat("(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?), ", len(batch)), ", "),
+			args...)
+		if err != nil {
+			return fmt.Errorf("failed to insert aggregates: %v", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, benchmarkUpdate+" WHERE ra.billing_code_type = ? AND ra.billing_code = ? AND ra.payer = ?",
+		g.BillingCodeType, g.BillingCode, g.Payer)
+	if err != nil {
+		return fmt.Errorf("failed to set Medicare amounts: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return nil
+}
+
+// benchmarkUpdate sets the national Medicare amount of aggregate rows and
+// their median as a percent of it; percentage rows have neither. MySQL
+// assigns left to right, so the percent sees the new amount.
+var benchmarkUpdate = `
+	UPDATE rate_aggregates ra SET
+		ra.medicare_amount = CASE WHEN ra.negotiated_type = 'negotiated'
+			THEN ` + medicare.AmountSQL("ra.billing_code_type", "ra.billing_code", "ra.billing_class", "NULL") + ` END,
+		ra.median_pct_of_medicare = ROUND(ra.median / NULLIF(ra.medicare_amount, 0) * 100, 1)`
+
+// RefreshBenchmarks recomputes the Medicare columns of every aggregate row,
+// after the fee schedules change, and returns the number of rows changed
+func (a *Aggregates) RefreshBenchmarks(ctx context.Context) (int64, error) {
+	result, err := a.db.ExecContext(ctx, benchmarkUpdate)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set Medicare amounts: %v", err)
+	}
+	return result.RowsAffected()
+}
+
+// round2 rounds to cents
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,127 @@
+package aggregate
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/influxdata/tdigest"
+)
+
+// compression bounds the number of t-digest centroids, and so the size of a
+// stored digest (about 16 bytes per centroid)
+const compression = 100
+
+// digestVersion is the first byte of an encoded digest
+const digestVersion = 1
+
+// Sketch summarizes a distribution of rates: the exact count, minimum,
+// maximum and sum, and a t-digest for percentiles. Sketches of disjoint sets
+// of rates merge into the sketch of their union.
+type Sketch struct {
+	Count int64
+	Min   float64
+	Max   float64
+	Sum   float64
+
+	digest *tdigest.TDigest
+}
+
+// NewSketch returns an empty sketch
+func NewSketch() *Sketch {
+	return &Sketch{digest: tdigest.NewWithCompression(compression)}
+}
+
+// Add adds a rate to the sketch
+func (s *Sketch) Add(v float64) {
+	if s.Count == 0 || v < s.Min {
+		s.Min = v
+	}
+	if s.Count == 0 || v > s.Max {
+		s.Max = v
+	}
+	s.Count++
+	s.Sum += v
+	s.digest.Add(v, 1)
+}
+
+// Merge adds the rates of another sketch
+func (s *Sketch) Merge(other *Sketch) {
+	if other.Count == 0 {
+		return
+	}
+	if s.Count == 0 || other.Min < s.Min {
+		s.Min = other.Min
+	}
+	if s.Count == 0 || other.Max > s.Max {
+		s.Max = other.Max
+	}
+	s.Count += other.Count
+	s.Sum += other.Sum
+	s.digest.AddCentroidList(other.digest.Centroids())
+}
+
+// Mean returns the mean rate, or 0 for an empty sketch
+func (s *Sketch) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// Quantile returns the approximate q-quantile (0-1), within the exact minimum
+// and maximum, or 0 for an empty sketch. While every centroid still holds a
+// single rate, it interpolates linearly between the closest ranks like the
+// exact statistics of the API.
+func (s *Sketch) Quantile(q float64) float64 {
+	if s.Count == 0 {
+		return 0
+	}
+
+	centroids := s.digest.Centroids()
+	if len(centroids) == int(s.Count) {
+		rank := q * float64(len(centroids)-1)
+		lower := int(math.Floor(rank))
+		upper := int(math.Ceil(rank))
+		return centroids[lower].Mean + (centroids[upper].Mean-centroids[lower].Mean)*(rank-float64(lower))
+	}
+
+	v := s.digest.Quantile(q)
+	if math.IsNaN(v) {
+		return s.Min
+	}
+	return math.Min(math.Max(v, s.Min), s.Max)
+}
+
+// Digest encodes the centroids of the t-digest: a version byte followed by
+// the little-endian float64 mean and weight of each centroid
+func (s *Sketch) Digest() []byte {
+	centroids := s.digest.Centroids()
+	b := make([]byte, 1, 1+16*len(centroids))
+	b[0] = digestVersion
+	for _, c := range centroids {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(c.Mean))
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(c.Weight))
+	}
+	return b
+}
+
+// ParseSketch rebuilds a sketch from its stored columns
+func ParseSketch(count int64, min, max, sum float64, digest []byte) (*Sketch, error) {
+	if len(digest) == 0 || digest[0] != digestVersion || (len(digest)-1)%16 != 0 {
+		return nil, fmt.Errorf("invalid rate digest of %d bytes", len(digest))
+	}
+
+	s := NewSketch()
+	s.Count, s.Min, s.Max, s.Sum = count, min, max, sum
+
+	centroids := make(tdigest.CentroidList, 0, (len(digest)-1)/16)
+	for b := digest[1:]; len(b) > 0; b = b[16:] {
+		centroids = append(centroids, tdigest.Centroid{
+			Mean:   math.Float64frombits(binary.LittleEndian.Uint64(b)),
+			Weight: math.Float64frombits(binary.LittleEndian.Uint64(b[8:])),
+		})
+	}
+	s.digest.AddCentroidList(centroids)
+	return s, nil
+}
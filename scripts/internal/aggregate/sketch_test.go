@@ -0,0 +1,88 @@
+package aggregate
+
+import (
+	"math"
+	"testing"
+)
+
+// sketchOf returns the sketch of values
+func sketchOf(values ...float64) *Sketch {
+	s := NewSketch()
+	for _, v := range values {
+		s.Add(v)
+	}
+	return s
+}
+
+func TestSketchMerge(t *testing.T) {
+	s := sketchOf(300, 100)
+	s.Merge(sketchOf(400, 200, 500))
+	s.Merge(NewSketch())
+
+	if s.Count != 5 || s.Min != 100 || s.Max != 500 || s.Sum != 1500 || s.Mean() != 300 {
+		t.Errorf("merged sketch = count %d, min %v, max %v, sum %v, want 5, 100, 500, 1500",
+			s.Count, s.Min, s.Max, s.Sum)
+	}
+	// Single-rate centroids interpolate between ranks like exact statistics
+	for _, tc := range []struct{ q, want float64 }{{0, 100}, {0.25, 200}, {0.5, 300}, {0.9, 460}, {1, 500}} {
+		if got := s.Quantile(tc.q); got != tc.want {
+			t.Errorf("Quantile(%v) = %v, want %v", tc.q, got, tc.want)
+		}
+	}
+
+	// Merging into an empty sketch takes the other's bounds
+	empty := NewSketch()
+	empty.Merge(sketchOf(-5, 7))
+	if empty.Count != 2 || empty.Min != -5 || empty.Max != 7 {
+		t.Errorf("merge into an empty sketch = count %d, min %v, max %v, want 2, -5, 7", empty.Count, empty.Min, empty.Max)
+	}
+	if q := NewSketch().Quantile(0.5); q != 0 {
+		t.Errorf("median of an empty sketch = %v, want 0", q)
+	}
+}
+
+func TestSketchMergeLarge(t *testing.T) {
+	// The rates 1..10000 split over four sketches, as four runs of a code
+	merged := NewSketch()
+	for part := 0; part < 4; part++ {
+		s := NewSketch()
+		for v := part + 1; v <= 10000; v += 4 {
+			s.Add(float64(v))
+		}
+		merged.Merge(s)
+	}
+
+	if merged.Count != 10000 || merged.Min != 1 || merged.Max != 10000 || merged.Sum != 50005000 {
+		t.Fatalf("merged sketch = count %d, min %v, max %v, sum %v", merged.Count, merged.Min, merged.Max, merged.Sum)
+	}
+	for _, q := range []float64{0.1, 0.5, 0.9, 0.99} {
+		if got, want := merged.Quantile(q), q*10000; math.Abs(got-want) > 0.01*10000 {
+			t.Errorf("Quantile(%v) = %v, want %v within 1%%", q, got, want)
+		}
+	}
+}
+
+func TestSketchDigest(t *testing.T) {
+	s := sketchOf(120, 80, 100, 100)
+	parsed, err := ParseSketch(s.Count, s.Min, s.Max, s.Sum, s.Digest())
+	if err != nil {
+		t.Fatalf("ParseSketch: %v", err)
+	}
+	for _, q := range []float64{0, 0.5, 0.75, 1} {
+		if got, want := parsed.Quantile(q), s.Quantile(q); got != want {
+			t.Errorf("Quantile(%v) of the parsed sketch = %v, want %v", q, got, want)
+		}
+	}
+
+	// A parsed sketch merges like the one it was stored from
+	parsed.Merge(sketchOf(1000))
+	if parsed.Count != 5 || parsed.Max != 1000 || parsed.Quantile(1) != 1000 {
+		t.Errorf("parsed sketch after a merge = count %d, max %v, want 5, 1000", parsed.Count, parsed.Max)
+	}
+
+	for _, digest := range [][]byte{nil, {2}, {digestVersion, 1, 2, 3}} {
+		if _, err := ParseSketch(1, 1, 1, 1, digest); err == nil {
+			t.Errorf("ParseSketch(%v) accepted an invalid digest", digest)
+		}
+	}
+}
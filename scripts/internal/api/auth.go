@@ -0,0 +1,53 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"healthcare-saver-ingest/internal/auth"
+)
+
+// apiKey returns the API key of a request, sent as a bearer token or in
+// X-API-Key
+func apiKey(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// authenticate charges a request to its API key. It writes the error
+// response and returns false when the request must not be served.
+func (s *Server) authenticate(w http.ResponseWriter, r *http.Request) (*auth.Key, bool) {
+	token := apiKey(r)
+	if token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+		writeError(w, http.StatusUnauthorized, "API key required")
+		return nil, false
+	}
+
+	key, decision, err := s.limiter.Allow(r.Context(), token)
+	if err != nil {
+		writeInternalError(w, err)
+		return nil, false
+	}
+	if key == nil {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+		writeError(w, http.StatusUnauthorized, "invalid API key")
+		return nil, false
+	}
+
+	if key.DailyQuota > 0 {
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(key.DailyQuota))
+		w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(decision.Remaining, 10))
+	}
+	if !decision.Allowed {
+		// Retry-After has whole seconds; round up so retries are not early
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, decision.Reason)
+		return key, false
+	}
+	return key, true
+}
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"healthcare-saver-ingest/internal/cache"
+	"healthcare-saver-ingest/internal/store"
+)
+
+// Cache tags. Responses for one billing code depend on that code's tag, so
+// a run only invalidates the codes it loaded, and on codesTag, which loads
+// changing every code (e.g. the provider directory) invalidate; every other
+// cached response depends on dataTag, which any run or load invalidates.
+const (
+	dataTag  = "data"
+	codesTag = "codes"
+)
+
+// codeTag returns the tag of the responses of one billing code
+func codeTag(codeType, code string) string {
+	return "code:" + strings.ToUpper(codeType) + ":" + code
+}
+
+// dataTags returns the tags of responses spanning all billing codes
+func dataTags(r *http.Request) []string {
+	return []string{dataTag}
+}
+
+// codeTags returns the tags of the responses of /v1/codes/{type}/{code}/...
+func codeTags(r *http.Request) []string {
+	return []string{codeTag(r.PathValue("type"), r.PathValue("code")), codesTag}
+}
+
+// cachedResponse is a response body with its entity tag
+type cachedResponse struct {
+	etag string
+	body []byte
+}
+
+// encode serializes a response as its entity tag, a newline and the body
+func (c cachedResponse) encode() []byte {
+	return append([]byte(c.etag+"\n"), c.body...)
+}
+
+// decodeResponse parses a response serialized by encode
+func decodeResponse(value []byte) (cachedResponse, bool) {
+	i := bytes.IndexByte(value, '\n')
+	if i < 0 {
+		return cachedResponse{}, false
+	}
+	return cachedResponse{etag: string(value[:i]), body: value[i+1:]}, true
+}
+
+// etag returns the strong entity tag of a body
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// cached wraps a GET handler with response caching and conditional GET.
+// Successful responses get an ETag and are answered with 304 when the client
+// already has them; with a cache they are also stored under the current
+// versions of the request's tags, so invalidating a tag makes the next
+// request run the handler again.
+func (s *Server) cached(tags func(*http.Request) []string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var key string
+		if s.cache != nil {
+			var err error
+			key, err = cache.Key(ctx, s.cache, cacheBase(r), tags(r))
+			if err != nil {
+				log.Printf("⚠️ Cache unavailable: %v", err)
+			} else if value, found, err := s.cache.Get(ctx, key); err != nil {
+				log.Printf("⚠️ Cache unavailable: %v", err)
+			} else if response, ok := decodeResponse(value); found && ok {
+				w.Header().Set("X-Cache", "HIT")
+				writeCachedResponse(w, r, response)
+				return
+			}
+		}
+
+		rec := &bodyRecorder{header: make(http.Header), status: http.StatusOK}
+		handler(rec, r)
+		if rec.status != http.StatusOK {
+			rec.flush(w)
+			return
+		}
+
+		response := cachedResponse{etag: etag(rec.body.Bytes()), body: rec.body.Bytes()}
+		if key != "" {
+			if err := s.cache.Set(ctx, key, response.encode(), s.cacheTTL); err != nil {
+				log.Printf("⚠️ Failed to cache response: %v", err)
+			}
+			w.Header().Set("X-Cache", "MISS")
+		}
+		writeCachedResponse(w, r, response)
+	}
+}
+
+// cacheBase returns the part of a cache key identifying the request: its
+// path and its query parameters in a canonical order
+func cacheBase(r *http.Request) string {
+	// url.Values.Encode sorts by key
+	return r.URL.Path + "?" + r.URL.Query().Encode()
+}
+
+// writeCachedResponse writes a JSON response, or 304 when If-None-Match
+// has its entity tag. Clients must revalidate, since any run may change it.
+func writeCachedResponse(w http.ResponseWriter, r *http.Request, response cachedResponse) {
+	w.Header().Set("ETag", response.etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), response.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(response.body); err != nil {
+		log.Printf("⚠️ Failed to write response: %v", err)
+	}
+}
+
+// etagMatches reports whether an If-None-Match header matches an entity tag,
+// comparing weakly as RFC 9110 requires for GET
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyRecorder buffers a handler's response so it can be cached
+type bodyRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *bodyRecorder) Header() http.Header         { return r.header }
+func (r *bodyRecorder) WriteHeader(status int)      { r.status = status }
+func (r *bodyRecorder) Write(p []byte) (int, error) { return r.body.Write(p) }
+
+// flush writes the buffered response unchanged
+func (r *bodyRecorder) flush(w http.ResponseWriter) {
+	for name, values := range r.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(r.status)
+	if _, err := w.Write(r.body.Bytes()); err != nil {
+		log.Printf("⚠️ Failed to write response: %v", err)
+	}
+}
+
+// runOverlap is how far each ledger check looks back before the previous
+// one, since ledger timestamps only have second precision
+const runOverlap = 5 * time.Second
+
+// WatchRuns polls the run ledger every interval until ctx is done and
+// invalidates the cached responses of the billing codes of every run that
+// completes or is activated and of every load made outside runs, plus every
+// response not specific to a code
+func (s *Server) WatchRuns(ctx context.Context, interval time.Duration) {
+	if s.cache == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var since time.Time
+	seen := make(map[store.RunChange]bool)
+	for {
+		changes, now, err := s.store.RunChanges(ctx, since)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("⚠️ Failed to check the run ledger: %v", err)
+		} else {
+			var runIDs, loadIDs []int64
+			for _, change := range changes {
+				if seen[change] {
+					continue
+				}
+				seen[change] = true
+				if change.LoadID != 0 {
+					loadIDs = append(loadIDs, change.LoadID)
+				} else {
+					runIDs = append(runIDs, change.RunID)
+				}
+			}
+			// The first check only records what was already there
+			if !since.IsZero() && len(runIDs)+len(loadIDs) > 0 {
+				s.invalidateChanges(ctx, runIDs, loadIDs)
+			}
+			since = now.Add(-runOverlap)
+			for change := range seen {
+				if change.At.Before(since) {
+					delete(seen, change)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// invalidateChanges invalidates the cached responses affected by runs and
+// by loads made outside runs
+func (s *Server) invalidateChanges(ctx context.Context, runIDs, loadIDs []int64) {
+	codes, err := s.store.RunBillingCodes(ctx, runIDs)
+	if err != nil {
+		log.Printf("⚠️ Failed to get billing codes of runs %v: %v", runIDs, err)
+		return
+	}
+	loadCodes, all, err := s.store.LoadBillingCodes(ctx, loadIDs)
+	if err != nil {
+		log.Printf("⚠️ Failed to get billing codes of loads %v: %v", loadIDs, err)
+		return
+	}
+	codes = append(codes, loadCodes...)
+
+	tags := []string{dataTag}
+	if all {
+		tags = append(tags, codesTag)
+	}
+	for _, code := range codes {
+		tags = append(tags, codeTag(code.Type, code.Code))
+	}
+	if err := s.cache.Invalidate(ctx, tags); err != nil {
+		log.Printf("⚠️ Failed to invalidate cache for runs %v and loads %v: %v", runIDs, loadIDs, err)
+		return
+	}
+	if all {
+		log.Printf("🧹 Invalidated cached responses of every billing code for runs %v and loads %v", runIDs, loadIDs)
+		return
+	}
+	log.Printf("🧹 Invalidated cached responses of %d billing codes for runs %v and loads %v", len(codes), runIDs, loadIDs)
+}
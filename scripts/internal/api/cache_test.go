@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"healthcare-saver-ingest/internal/cache"
+)
+
+func TestInvalidateChanges(t *testing.T) {
+	ctx := context.Background()
+	tags := []string{dataTag, codesTag, codeTag("CPT", "70551"), codeTag("CPT", "99213"), codeTag("HCPCS", "G0008")}
+
+	for _, tc := range []struct {
+		name     string
+		loads    *sqlmock.Rows
+		versions []int64 // of tags
+	}{
+		{
+			name: "loads of some codes",
+			loads: sqlmock.NewRows([]string{"all_codes", "billing_code_type", "billing_code"}).
+				AddRow(false, "CPT", "99213").
+				AddRow(false, nil, nil),
+			versions: []int64{1, 0, 1, 1, 0},
+		},
+		{
+			name: "load of every code",
+			loads: sqlmock.NewRows([]string{"all_codes", "billing_code_type", "billing_code"}).
+				AddRow(true, nil, nil),
+			versions: []int64{1, 1, 1, 0, 0},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, mock := newTestServer(t)
+			s.cache = cache.NewLRU(10)
+			mock.ExpectQuery(query("FROM insurance_services WHERE run_id IN (?)")).WithArgs(int64(7), int64(7), int64(7), int64(7)).
+				WillReturnRows(sqlmock.NewRows([]string{"billing_code_type", "billing_code"}).AddRow("CPT", "70551"))
+			mock.ExpectQuery(query("FROM data_loads l")).WithArgs(int64(3), int64(4)).WillReturnRows(tc.loads)
+
+			s.invalidateChanges(ctx, []int64{7}, []int64{3, 4})
+			expectationsMet(t, mock)
+
+			versions, err := s.cache.Versions(ctx, tags)
+			if err != nil {
+				t.Fatalf("Versions: %v", err)
+			}
+			if !reflect.DeepEqual(versions, tc.versions) {
+				t.Errorf("versions of %v = %v, want %v", tags, versions, tc.versions)
+			}
+		})
+	}
+}
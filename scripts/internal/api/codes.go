@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"healthcare-saver-ingest/internal/store"
+)
+
+// handleCodeRates serves GET /v1/codes/{type}/{code}/rates with the optional
+// filters payer, plan, provider_group with its run, npi, place_of_service,
+// state and near/radius, paged by the after cursor
+func (s *Server) handleCodeRates(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := store.RateFilter{
+		Payer:          query.Get("payer"),
+		Plan:           query.Get("plan"),
+		NPI:            query.Get("npi"),
+		PlaceOfService: query.Get("place_of_service"),
+		State:          strings.ToUpper(query.Get("state")),
+	}
+
+	if filter.NPI != "" && !validNPI(filter.NPI) {
+		writeError(w, http.StatusBadRequest, "npi must be 10 digits")
+		return
+	}
+
+	if value := query.Get("provider_group"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id <= 0 {
+			writeError(w, http.StatusBadRequest, "provider_group must be a positive integer")
+			return
+		}
+		filter.ProviderGroupID = id
+
+		runID, err := strconv.ParseInt(query.Get("run"), 10, 64)
+		if err != nil || runID <= 0 {
+			writeError(w, http.StatusBadRequest, "provider_group requires the run of its file")
+			return
+		}
+		filter.RunID = runID
+	}
+
+	near, msg := parseNear(r)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	filter.Near = near
+
+	pageSize, ok := parsePageSize(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "page_size must be between 1 and 100")
+		return
+	}
+
+	rates, err := s.store.CodeRates(r.Context(), r.PathValue("type"), r.PathValue("code"), filter, query.Get("after"), pageSize)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "unknown billing code")
+		return
+	}
+	if errors.Is(err, store.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, "after must be the nextCursor of a previous page")
+		return
+	}
+	if errors.Is(err, store.ErrUnknownZIP) {
+		writeError(w, http.StatusBadRequest, "unknown ZIP code")
+		return
+	}
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rates)
+}
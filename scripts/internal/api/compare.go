@@ -0,0 +1,76 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"healthcare-saver-ingest/internal/store"
+)
+
+// defaultAsOf fills in today's date as the as_of parameter of requests
+// without one, before the cache key is built from the query: a response
+// cached yesterday must not answer for today
+func defaultAsOf(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("as_of") == "" {
+			query.Set("as_of", time.Now().Format("2006-01-02"))
+			r.URL.RawQuery = query.Encode()
+		}
+		handler(w, r)
+	}
+}
+
+// handleComparePayers serves GET /v1/codes/{type}/{code}/compare with npi,
+// and the optional base and as_of parameters. Provider group ids are only
+// unique within one payer's file, so only NPIs identify a provider across
+// payers.
+func (s *Server) handleComparePayers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := store.CompareParams{
+		BillingCodeType: r.PathValue("type"),
+		BillingCode:     r.PathValue("code"),
+		NPI:             query.Get("npi"),
+		AsOf:            time.Now().Format("2006-01-02"),
+	}
+
+	if params.NPI == "" {
+		writeError(w, http.StatusBadRequest, "npi is required")
+		return
+	}
+	if !validNPI(params.NPI) {
+		writeError(w, http.StatusBadRequest, "npi must be 10 digits")
+		return
+	}
+
+	if value := query.Get("base"); value != "" {
+		base, err := strconv.ParseFloat(value, 64)
+		if err != nil || base <= 0 {
+			writeError(w, http.StatusBadRequest, "base must be a positive amount")
+			return
+		}
+		params.Base = base
+	}
+
+	if value := query.Get("as_of"); value != "" {
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			writeError(w, http.StatusBadRequest, "as_of must be a date (YYYY-MM-DD)")
+			return
+		}
+		params.AsOf = value
+	}
+
+	result, err := s.store.ComparePayers(r.Context(), params)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "unknown billing code")
+		return
+	}
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"healthcare-saver-ingest/internal/episode"
+	"healthcare-saver-ingest/internal/store"
+)
+
+// episodesResponse is the body of GET /v1/episodes
+type episodesResponse struct {
+	Episodes []episode.Episode `json:"episodes"`
+}
+
+// handleEpisodes serves GET /v1/episodes
+func (s *Server) handleEpisodes(w http.ResponseWriter, r *http.Request) {
+	episodes := s.episodes
+	if episodes == nil {
+		episodes = []episode.Episode{}
+	}
+	writeJSON(w, http.StatusOK, episodesResponse{Episodes: episodes})
+}
+
+// handleEpisodePrices serves GET /v1/episodes/{id}/prices?payer=&state=&near=&radius=
+func (s *Server) handleEpisodePrices(w http.ResponseWriter, r *http.Request) {
+	ep, ok := episode.Find(s.episodes, r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown episode")
+		return
+	}
+
+	page, pageSize, ok := parsePaging(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "page must be >= 1 and page_size between 1 and 100")
+		return
+	}
+	near, msg := parseNear(r)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Prices are assembled and ranked in memory, so they are limited to one
+	// market or payer rather than every rate of the primary component
+	query := r.URL.Query()
+	market := store.Market{State: strings.ToUpper(query.Get("state")), Near: near}
+	payer := query.Get("payer")
+	if market.State == "" && market.Near == nil && payer == "" {
+		writeError(w, http.StatusBadRequest, "state, near or payer is required")
+		return
+	}
+	prices, err := s.store.EpisodePrices(r.Context(), ep, market, payer, page, pageSize)
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prices)
+}
+
+// episodeTags returns the tags of the codes of the episode of
+// /v1/episodes/{id}/prices, so that only runs loading one of them
+// invalidate its prices
+func (s *Server) episodeTags(r *http.Request) []string {
+	ep, ok := episode.Find(s.episodes, r.PathValue("id"))
+	if !ok {
+		return dataTags(r)
+	}
+	tags := []string{codesTag}
+	for _, component := range ep.Components() {
+		tags = append(tags, codeTag(component.BillingCodeType, component.BillingCode))
+	}
+	return tags
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"healthcare-saver-ingest/internal/store"
+)
+
+// maxEstimateItems is the most billing codes of one estimate
+const maxEstimateItems = 20
+
+// estimateRequest is the body of POST /v1/estimate
+type estimateRequest struct {
+	Items           []store.EstimateItem `json:"items"`
+	NPI             string               `json:"npi,omitempty"`
+	ProviderGroupID int                  `json:"providerGroupId,omitempty"`
+	RunID           int64                `json:"runId,omitempty"` // run of providerGroupId
+	Payer           string               `json:"payer,omitempty"`
+	Plan            string               `json:"plan,omitempty"`
+	Base            float64              `json:"base,omitempty"`
+	Benefits        store.Benefits       `json:"benefits,omitempty"`
+}
+
+// handleEstimate serves POST /v1/estimate
+func (s *Server) handleEstimate(w http.ResponseWriter, r *http.Request) {
+	var req estimateRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+
+	if msg := req.validate(); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	estimate, err := s.store.EstimateCost(r.Context(), store.EstimateParams{
+		Items:           req.Items,
+		NPI:             req.NPI,
+		ProviderGroupID: req.ProviderGroupID,
+		RunID:           req.RunID,
+		Payer:           req.Payer,
+		Plan:            req.Plan,
+		Base:            req.Base,
+		AsOf:            time.Now().Format("2006-01-02"),
+		Benefits:        req.Benefits,
+	})
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, estimate)
+}
+
+// validate returns an error message for an invalid request, or ""
+func (req *estimateRequest) validate() string {
+	if len(req.Items) == 0 || len(req.Items) > maxEstimateItems {
+		return "items must have between 1 and 20 billing codes"
+	}
+	for _, item := range req.Items {
+		if item.BillingCodeType == "" || item.BillingCode == "" {
+			return "every item needs billingCodeType and billingCode"
+		}
+		if item.BillingClass != "" && item.BillingClass != "professional" && item.BillingClass != "institutional" {
+			return "billingClass must be professional or institutional"
+		}
+		if item.Units < 0 {
+			return "units must not be negative"
+		}
+	}
+
+	if (req.NPI == "") == (req.ProviderGroupID == 0) {
+		return "specify exactly one of npi or providerGroupId"
+	}
+	if req.NPI != "" && !validNPI(req.NPI) {
+		return "npi must be 10 digits"
+	}
+	if req.ProviderGroupID < 0 {
+		return "providerGroupId must be a positive integer"
+	}
+	if (req.ProviderGroupID != 0) != (req.RunID > 0) {
+		return "providerGroupId requires the runId of its file, and runId a providerGroupId"
+	}
+	if req.Base < 0 {
+		return "base must not be negative"
+	}
+
+	b := req.Benefits
+	if b.DeductibleRemaining < 0 || b.Copay < 0 || (b.OutOfPocketRemaining != nil && *b.OutOfPocketRemaining < 0) {
+		return "benefit amounts must not be negative"
+	}
+	if b.CoinsurancePercent < 0 || b.CoinsurancePercent > 100 {
+		return "coinsurancePercent must be between 0 and 100"
+	}
+	return ""
+}
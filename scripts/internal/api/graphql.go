@@ -0,0 +1,30 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"healthcare-saver-ingest/internal/gql"
+)
+
+// handleGraphQL serves POST /v1/graphql. Results are returned with 200 even
+// when a resolver fails, as GraphQL clients expect; requests that do not
+// validate or are too complex are rejected with 400.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req gql.Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	response, rejected := s.graphql.Exec(r.Context(), req)
+	status := http.StatusOK
+	if rejected {
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, response)
+}
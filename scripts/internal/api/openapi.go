@@ -0,0 +1,381 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"healthcare-saver-ingest/internal/episode"
+	"healthcare-saver-ingest/internal/gql"
+	"healthcare-saver-ingest/internal/openapi"
+	"healthcare-saver-ingest/internal/store"
+)
+
+// endpoint describes a route for the OpenAPI document
+type endpoint struct {
+	method string
+	path   string
+	op     openapi.Operation
+	public bool // served without an API key
+
+	request  interface{} // JSON body, or nil
+	response interface{} // JSON body of the 200 response
+	errors   []int
+	// badRequest is an extra body of 400 responses besides errorResponse
+	badRequest interface{}
+}
+
+// graphqlResponse documents gql.Response, whose data is raw JSON
+type graphqlResponse struct {
+	Data       map[string]interface{} `json:"data,omitempty"`
+	Errors     []gqlerrors.QueryError `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Parameters shared by several endpoints
+var (
+	pageSizeParam = queryParam("page_size", "Results per page", &openapi.Schema{Type: "integer", Minimum: openapi.Float(1), Maximum: openapi.Float(maxPageSize), Default: defaultPageSize})
+	pageParams    = []openapi.Parameter{
+		queryParam("page", "Page number, from 1", &openapi.Schema{Type: "integer", Minimum: openapi.Float(1), Default: 1}),
+		pageSizeParam,
+	}
+	nearParams = []openapi.Parameter{
+		queryParam("near", "ZIP code to search around", &openapi.Schema{Type: "string", Pattern: "^[0-9]{5}$"}),
+		queryParam("radius", "Radius around near in miles", &openapi.Schema{Type: "number", Minimum: openapi.Float(0), Maximum: openapi.Float(store.MaxRadiusMiles), Default: defaultRadiusMiles}),
+	}
+	codeParams = []openapi.Parameter{
+		pathParam("type", "Billing code type, e.g. CPT"),
+		pathParam("code", "Billing code, e.g. 70551"),
+	}
+	npiSchema = &openapi.Schema{Type: "string", Pattern: "^[0-9]{10}$"}
+	npiParam  = openapi.Parameter{Name: "npi", In: "path", Description: "National Provider Identifier", Required: true, Schema: npiSchema}
+)
+
+// endpoints lists every route of the API. NewServer registers handlers by
+// operation id, so a route cannot be served without being described here.
+var endpoints = []endpoint{
+	{
+		method: "GET", path: "/healthz", public: true,
+		op:       openapi.Operation{OperationID: "health", Summary: "Report whether the database is reachable", Tags: []string{"meta"}},
+		response: map[string]string{}, errors: []int{http.StatusServiceUnavailable},
+	},
+	{
+		method: "GET", path: "/v1/openapi.json", public: true,
+		op:       openapi.Operation{OperationID: "openapi", Summary: "This OpenAPI document", Tags: []string{"meta"}},
+		response: map[string]interface{}{},
+	},
+	{
+		method: "GET", path: "/v1/search",
+		op: openapi.Operation{
+			OperationID: "search", Summary: "Search services by name, description, lay term or billing code", Tags: []string{"search"},
+			Parameters: concat([]openapi.Parameter{
+				{Name: "q", In: "query", Description: "Search text", Required: true, Schema: &openapi.Schema{Type: "string"}},
+				queryParam("sort", "Result order; distance requires near", &openapi.Schema{
+					Type: "string", Enum: []string{"relevance", "price", "-price", "name", "-name", "code", "distance"}, Default: store.DefaultSearchSort,
+				}),
+			}, pageParams, nearParams),
+		},
+		response: store.SearchPage{}, errors: []int{http.StatusBadRequest},
+	},
+	{
+		method: "GET", path: "/v1/suggest",
+		op: openapi.Operation{
+			OperationID: "suggest", Summary: "Typeahead suggestions of service names and billing codes", Tags: []string{"search"},
+			Parameters: []openapi.Parameter{
+				{Name: "prefix", In: "query", Description: "Text typed so far", Required: true, Schema: &openapi.Schema{Type: "string"}},
+				queryParam("limit", "Number of suggestions", &openapi.Schema{Type: "integer", Minimum: openapi.Float(1), Maximum: openapi.Float(store.MaxSuggestions), Default: defaultSuggestLimit}),
+			},
+		},
+		response: suggestResponse{}, errors: []int{http.StatusBadRequest},
+	},
+	{
+		method: "GET", path: "/v1/codes/{type}/{code}/rates",
+		op: openapi.Operation{
+			OperationID: "codeRates", Summary: "Current negotiated rates of a billing code with their distribution", Tags: []string{"rates"},
+			Parameters: concat(codeParams, []openapi.Parameter{
+				queryParam("payer", "Reporting entity name", &openapi.Schema{Type: "string"}),
+				queryParam("plan", "Plan name or id", &openapi.Schema{Type: "string"}),
+				queryParam("provider_group", "Provider group id of the payer's file, with run", &openapi.Schema{Type: "integer", Minimum: openapi.Float(1)}),
+				queryParam("run", "Ingestion run of provider_group", &openapi.Schema{Type: "integer", Minimum: openapi.Float(1)}),
+				queryParam("npi", "Rates of provider groups with this NPI", npiSchema),
+				queryParam("place_of_service", "CMS place of service code", &openapi.Schema{Type: "string"}),
+				queryParam("state", "Two-letter state of the providers", &openapi.Schema{Type: "string"}),
+			}, nearParams, []openapi.Parameter{
+				queryParam("after", "nextCursor of the previous page", &openapi.Schema{Type: "string"}),
+				pageSizeParam,
+			}),
+		},
+		response: store.CodeRates{}, errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		method: "GET", path: "/v1/codes/{type}/{code}/compare",
+		op: openapi.Operation{
+			OperationID: "comparePayers", Summary: "Rank payers and plans by their rate for a billing code at one provider",
+			Tags: []string{"rates"},
+			Parameters: concat(codeParams, []openapi.Parameter{
+				{Name: "npi", In: "query", Description: "National Provider Identifier", Required: true, Schema: npiSchema},
+				queryParam("base", "Dollar amount percentage rates apply to", &openapi.Schema{Type: "number", Minimum: openapi.Float(0)}),
+				queryParam("as_of", "Date expiration dates are checked against, today by default", &openapi.Schema{Type: "string", Format: "date"}),
+			}),
+		},
+		response: store.PayerComparisonResult{}, errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		method: "POST", path: "/v1/estimate",
+		op: openapi.Operation{
+			OperationID: "estimate", Summary: "Estimate the out-of-pocket cost of an episode of billing codes at one provider",
+			Description: "Specify exactly one of npi or providerGroupId with its runId.", Tags: []string{"rates"},
+		},
+		request: estimateRequest{}, response: store.Estimate{}, errors: []int{http.StatusBadRequest},
+	},
+	{
+		method: "POST", path: "/v1/graphql",
+		op: openapi.Operation{
+			OperationID: "graphql", Summary: "Run a GraphQL query",
+			Description: "Queries that do not validate or are too complex are rejected with 400 and GraphQL errors.", Tags: []string{"graphql"},
+		},
+		request: gql.Request{}, response: graphqlResponse{}, errors: []int{http.StatusBadRequest}, badRequest: graphqlResponse{},
+	},
+	{
+		method: "GET", path: "/v1/providers",
+		op: openapi.Operation{
+			OperationID: "listProviders", Summary: "List providers of the directory", Tags: []string{"providers"},
+			Parameters: concat([]openapi.Parameter{
+				queryParam("state", "Two-letter state", &openapi.Schema{Type: "string"}),
+				queryParam("city", "City", &openapi.Schema{Type: "string"}),
+				queryParam("code", "Only providers with a rate for this billing code", &openapi.Schema{Type: "string"}),
+			}, pageParams),
+		},
+		response: store.ProviderPage{}, errors: []int{http.StatusBadRequest},
+	},
+	{
+		method: "GET", path: "/v1/providers/{npi}",
+		op: openapi.Operation{
+			OperationID: "getProvider", Summary: "A provider of the directory", Tags: []string{"providers"},
+			Parameters: []openapi.Parameter{npiParam},
+		},
+		response: store.Provider{}, errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		method: "GET", path: "/v1/providers/{npi}/rates",
+		op: openapi.Operation{
+			OperationID: "providerRates", Summary: "Standard charges of a provider with every payer's rates", Tags: []string{"providers"},
+			Parameters: concat([]openapi.Parameter{
+				npiParam,
+				queryParam("code", "Only this billing code", &openapi.Schema{Type: "string"}),
+			}, pageParams),
+		},
+		response: store.ProviderRates{}, errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		method: "GET", path: "/v1/episodes",
+		op:       openapi.Operation{OperationID: "listEpisodes", Summary: "Episodes of care, the bundles of billing codes patients shop for", Tags: []string{"episodes"}},
+		response: episodesResponse{},
+	},
+	{
+		method: "GET", path: "/v1/episodes/{id}/prices",
+		op: openapi.Operation{
+			OperationID: "episodePrices", Summary: "Price an episode of care for every payer and provider organization",
+			Description: "Requires a market (state or near) or a payer. Components a provider has no rate for are priced at the payer's median rate in the market, which makes the price an estimate rather than complete.",
+			Tags:        []string{"episodes"},
+			Parameters: concat([]openapi.Parameter{
+				pathParam("id", "Episode id, e.g. knee-replacement"),
+				queryParam("payer", "Reporting entity name", &openapi.Schema{Type: "string"}),
+				queryParam("state", "Two-letter state of the providers", &openapi.Schema{Type: "string"}),
+			}, nearParams, pageParams),
+		},
+		response: store.EpisodePrices{}, errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+}
+
+// queryParam returns an optional query parameter
+func queryParam(name, description string, schema *openapi.Schema) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+// pathParam returns a string path parameter
+func pathParam(name, description string) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &openapi.Schema{Type: "string"}}
+}
+
+// concat returns the parameters of several lists
+func concat(lists ...[]openapi.Parameter) []openapi.Parameter {
+	var params []openapi.Parameter
+	for _, list := range lists {
+		params = append(params, list...)
+	}
+	return params
+}
+
+// Spec returns the OpenAPI document of the API
+var Spec = sync.OnceValue(func() *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:   "Healthcare Saver Price API",
+		Version: "1.0.0",
+		Description: "Negotiated rates from payer price transparency files. " +
+			"Servers started with -require-api-key need an API key on every endpoint but /healthz and /v1/openapi.json.",
+	})
+	doc.Enum("BillingClass", "Whether a rate is for the professional or the facility part of a service", "professional", "institutional")
+	doc.Enum("NegotiatedType", "Whether a rate is a dollar amount or a percentage of billed charges", "negotiated", "percentage")
+	doc.Enum("EpisodeRateSource", "Whose rates price an episode component: the provider's, the payer's market median or none",
+		store.SourceProvider, store.SourcePayerMedian, store.SourceNone)
+	doc.Components.SecuritySchemes = map[string]openapi.SecurityScheme{
+		"bearer": {Type: "http", Scheme: "bearer"},
+		"apiKey": {Type: "apiKey", In: "header", Name: "X-API-Key"},
+	}
+	doc.Name(gql.Request{}, "GraphQLRequest")
+	doc.Name(graphqlResponse{}, "GraphQLResponse")
+	doc.Name(gqlerrors.QueryError{}, "GraphQLError")
+	doc.Name(gqlerrors.Location{}, "GraphQLLocation")
+	doc.Name(episode.Component{}, "EpisodeComponent")
+	errorSchema := doc.SchemaOf(errorResponse{})
+
+	for _, e := range endpoints {
+		op := e.op
+		op.Responses = map[string]openapi.Response{
+			"200": {Description: "OK", Content: jsonContent(doc.SchemaOf(e.response))},
+		}
+		if e.request != nil {
+			op.RequestBody = &openapi.RequestBody{Required: true, Content: jsonContent(doc.SchemaOf(e.request))}
+		}
+
+		codes := e.errors
+		if !e.public {
+			op.Security = []map[string][]string{{"bearer": {}}, {"apiKey": {}}}
+			codes = append(codes[:len(codes):len(codes)], http.StatusUnauthorized, http.StatusTooManyRequests)
+		}
+		for _, code := range codes {
+			schema := errorSchema
+			if code == http.StatusBadRequest && e.badRequest != nil {
+				schema = &openapi.Schema{AnyOf: []*openapi.Schema{doc.SchemaOf(e.badRequest), errorSchema}}
+			}
+			op.Responses[strconv.Itoa(code)] = openapi.Response{Description: http.StatusText(code), Content: jsonContent(schema)}
+		}
+		if e.method == http.MethodGet && !e.public {
+			op.Responses["304"] = openapi.Response{Description: "Not modified since the ETag in If-None-Match"}
+		}
+		op.Responses["500"] = openapi.Response{Description: http.StatusText(http.StatusInternalServerError), Content: jsonContent(errorSchema)}
+
+		doc.Add(e.method, e.path, &op)
+	}
+	return doc
+})
+
+// jsonContent returns the content of a JSON body
+func jsonContent(schema *openapi.Schema) map[string]openapi.MediaType {
+	return map[string]openapi.MediaType{"application/json": {Schema: schema}}
+}
+
+// endpointByID returns the endpoint of an operation id
+func endpointByID(id string) endpoint {
+	for _, e := range endpoints {
+		if e.op.OperationID == id {
+			return e
+		}
+	}
+	panic("api: no endpoint " + id)
+}
+
+// isPublic reports whether a path is served without an API key
+func isPublic(path string) bool {
+	for _, e := range endpoints {
+		if e.public && e.path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// handle registers the handler of an operation on its route, validating it
+// against the OpenAPI document when enabled
+func (s *Server) handle(id string, handler http.HandlerFunc) {
+	e := endpointByID(id)
+	if s.validate {
+		handler = s.validated(e, handler)
+	}
+	s.mux.HandleFunc(e.method+" "+e.path, handler)
+}
+
+// handleOpenAPI serves GET /v1/openapi.json
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, Spec())
+}
+
+// validated checks the requests and responses of an endpoint against the
+// OpenAPI document and logs mismatches, which are bugs in the handler or
+// in its description. Requests the document rejects only count when the
+// handler accepts them, since handlers also check rules the document cannot
+// express.
+func (s *Server) validated(e endpoint, handler http.HandlerFunc) http.HandlerFunc {
+	doc := Spec()
+	op := doc.Paths[e.path][strings.ToLower(e.method)]
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestErr := validateRequest(doc, op, r)
+
+		rec := &bodyRecorder{header: make(http.Header), status: http.StatusOK}
+		handler(rec, r)
+
+		if requestErr != nil && rec.status < http.StatusBadRequest {
+			log.Printf("❌ OpenAPI: %s %s accepted an invalid request: %v", r.Method, r.URL.RequestURI(), requestErr)
+		}
+		if err := validateResponse(doc, op, rec); err != nil {
+			log.Printf("❌ OpenAPI: %s %s returned an invalid response: %v", r.Method, r.URL.RequestURI(), err)
+		}
+		rec.flush(w)
+	}
+}
+
+// validateRequest checks the parameters and body of a request
+func validateRequest(doc *openapi.Document, op *openapi.Operation, r *http.Request) error {
+	for _, p := range op.Parameters {
+		var raw string
+		var present bool
+		if p.In == "path" {
+			raw = r.PathValue(p.Name)
+			present = raw != ""
+		} else {
+			present = r.URL.Query().Has(p.Name)
+			raw = r.URL.Query().Get(p.Name)
+		}
+		if !present {
+			if p.Required {
+				return fmt.Errorf("%s is required", p.Name)
+			}
+			continue
+		}
+		if err := doc.ValidateParameter(p, raw); err != nil {
+			return err
+		}
+	}
+
+	if op.RequestBody == nil {
+		return nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20+1))
+	if err != nil {
+		return fmt.Errorf("failed to read body: %v", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return doc.ValidateJSON(op.RequestBody.Content["application/json"].Schema, body)
+}
+
+// validateResponse checks that a response has a documented status and body
+func validateResponse(doc *openapi.Document, op *openapi.Operation, rec *bodyRecorder) error {
+	response, ok := op.Responses[strconv.Itoa(rec.status)]
+	if !ok {
+		return fmt.Errorf("undocumented status %d", rec.status)
+	}
+	media, ok := response.Content["application/json"]
+	if !ok {
+		return nil
+	}
+	return doc.ValidateJSON(media.Schema, rec.body.Bytes())
+}
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"healthcare-saver-ingest/internal/episode"
+	"healthcare-saver-ingest/internal/openapi"
+	"healthcare-saver-ingest/internal/store"
+)
+
+// testEpisodes are the episodes of the test server
+const testEpisodes = `
+episodes:
+  - id: mri-brain
+    name: MRI of the brain
+    category: Imaging
+    primary: {code: "70551", billing_class: professional}
+    ancillaries:
+      - {code: "70551", billing_class: institutional, description: Facility}
+`
+
+// newTestServer returns a server on a mock database, whose expectations
+// each test sets in the order of its queries
+func newTestServer(t *testing.T) (*Server, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("failed to create mock database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	episodes, err := episode.Parse([]byte(testEpisodes))
+	if err != nil {
+		t.Fatalf("invalid test episodes: %v", err)
+	}
+	return NewServer(store.New(db), Options{Episodes: episodes}), mock
+}
+
+// query returns the pattern of a query containing fragment
+func query(fragment string) string {
+	return regexp.QuoteMeta(fragment)
+}
+
+// checkSpec serves a request and checks it and its response against the
+// OpenAPI document. Requests answered with 2xx must be valid.
+func checkSpec(t *testing.T, s *Server, method, target, body string, wantStatus int) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	if rec.Code != wantStatus {
+		t.Fatalf("%s %s: status %d, want %d: %s", method, target, rec.Code, wantStatus, rec.Body)
+	}
+
+	// Route the request again to find its operation and path values
+	doc := Spec()
+	var (
+		op         *openapi.Operation
+		requestErr error
+	)
+	mux := http.NewServeMux()
+	for _, e := range endpoints {
+		e := e
+		mux.HandleFunc(e.method+" "+e.path, func(w http.ResponseWriter, r *http.Request) {
+			op = doc.Paths[e.path][strings.ToLower(e.method)]
+			requestErr = validateRequest(doc, op, r)
+		})
+	}
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, target, strings.NewReader(body)))
+	if op == nil {
+		t.Fatalf("%s %s: no operation in the OpenAPI document", method, target)
+	}
+	if requestErr != nil && rec.Code < http.StatusBadRequest {
+		t.Errorf("%s %s: invalid request accepted: %v", method, target, requestErr)
+	}
+
+	response := &bodyRecorder{header: rec.Header(), status: rec.Code}
+	response.body.Write(rec.Body.Bytes())
+	if err := validateResponse(doc, op, response); err != nil {
+		t.Errorf("%s %s: invalid response: %v\n%s", method, target, err, rec.Body)
+	}
+	return rec
+}
+
+// expectationsMet fails the test when the handler skipped expected queries
+func expectationsMet(t *testing.T, mock sqlmock.Sqlmock) {
+	t.Helper()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectPing()
+	checkSpec(t, s, "GET", "/healthz", "", http.StatusOK)
+
+	mock.ExpectPing().WillReturnError(sqlmock.ErrCancelled)
+	checkSpec(t, s, "GET", "/healthz", "", http.StatusServiceUnavailable)
+	expectationsMet(t, mock)
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	s, _ := newTestServer(t)
+	checkSpec(t, s, "GET", "/v1/openapi.json", "", http.StatusOK)
+}
+
+func TestSearch(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectQuery(query("WITH matches AS")).WillReturnRows(sqlmock.NewRows([]string{
+		"billing_code_type", "billing_code", "provider_group_id", "last_seen_run_id", "name", "description",
+		"min_rate", "relevance", "hospital", "location", "distance", "total",
+	}).AddRow("CPT", "70551", 367840, 12, "MRI Brain", "MRI of the brain", 850.0, 4.82, nil, nil, nil, 1))
+	mock.ExpectQuery(query("AS payer")).WillReturnRows(sqlmock.NewRows([]string{
+		"billing_code_type", "billing_code", "provider_group_id", "last_seen_run_id", "payer", "rate",
+	}).AddRow("CPT", "70551", 367840, 12, "Acme Health", 850.0))
+
+	rec := checkSpec(t, s, "GET", "/v1/search?q=mri&page_size=10", "", http.StatusOK)
+	if !strings.Contains(rec.Body.String(), `"id":"CPT-70551-367840-12"`) {
+		t.Errorf("result id missing the run of its provider group: %s", rec.Body)
+	}
+	expectationsMet(t, mock)
+
+	checkSpec(t, s, "GET", "/v1/search", "", http.StatusBadRequest)
+	checkSpec(t, s, "GET", "/v1/search?q=mri&sort=distance", "", http.StatusBadRequest)
+	checkSpec(t, s, "GET", "/v1/search?q=mri&page_size=500", "", http.StatusBadRequest)
+}
+
+func TestSuggest(t *testing.T) {
+	s, _ := newTestServer(t)
+	checkSpec(t, s, "GET", "/v1/suggest?prefix=mr", "", http.StatusOK)
+	checkSpec(t, s, "GET", "/v1/suggest", "", http.StatusBadRequest)
+	checkSpec(t, s, "GET", "/v1/suggest?prefix=mr&limit=0", "", http.StatusBadRequest)
+}
+
+func TestCodeRates(t *testing.T) {
+	s, mock := newTestServer(t)
+	expectRates := func(args ...driver.Value) {
+		mock.ExpectQuery(query("SELECT billing_code_type, name FROM active_insurance_services")).
+			WillReturnRows(sqlmock.NewRows([]string{"billing_code_type", "name"}).AddRow("CPT", "MRI Brain"))
+		mock.ExpectQuery(query("SELECT r.billing_class, r.negotiated_type, r.negotiated_rate")).
+			WillReturnRows(sqlmock.NewRows([]string{"billing_class", "negotiated_type", "negotiated_rate"}).
+				AddRow("professional", "negotiated", 850.0).
+				AddRow("professional", "negotiated", 900.0).
+				AddRow("professional", "percentage", 120.0))
+		mock.ExpectQuery(query("SELECT l.locality_id FROM providers")).
+			WillReturnRows(sqlmock.NewRows([]string{"locality_id"}))
+		mock.ExpectQuery(query("CROSS JOIN")).
+			WillReturnRows(sqlmock.NewRows([]string{"billing_class", "amount"}).
+				AddRow("professional", 250.0).
+				AddRow("institutional", nil))
+		mock.ExpectQuery(query("SELECT r.id,")).WithArgs(args...).WillReturnRows(sqlmock.NewRows([]string{
+			"id", "payer", "plan_name", "plan_id", "negotiated_type", "negotiated_rate", "effective_rate",
+			"expiration_date", "billing_class", "provider_references", "service_codes", "distance",
+		}).
+			AddRow(1, "Acme Health", "Gold", "123", "negotiated", 850.0, 850.0, "9999-12-31", "professional", "[367840]", `["11"]`, nil).
+			AddRow(2, "", "", "", "percentage", 120.0, nil, "9999-12-31", "professional", "[1]", "[]", nil))
+	}
+
+	// The total is counted by the statistics, and the extra rate read past
+	// the page makes its last rate the cursor of the next page
+	expectRates("CPT", "70551", "1234567890", 2)
+	rec := checkSpec(t, s, "GET", "/v1/codes/CPT/70551/rates?npi=1234567890&page_size=1", "", http.StatusOK)
+	var page store.CodeRates
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("failed to decode rates: %v", err)
+	}
+	if page.Total != 3 || len(page.Rates) != 1 || page.NextCursor == "" {
+		t.Errorf("total, rates, next cursor = %d, %d, %q, want 3, 1 and a cursor", page.Total, len(page.Rates), page.NextCursor)
+	}
+	expectationsMet(t, mock)
+
+	expectRates("CPT", "70551", "1234567890", false, 850.0, 850.0, int64(1), 3)
+	rec = checkSpec(t, s, "GET", "/v1/codes/CPT/70551/rates?npi=1234567890&page_size=2&after="+page.NextCursor, "", http.StatusOK)
+	if !strings.Contains(rec.Body.String(), `"nextCursor":""`) {
+		t.Errorf("last page has a next cursor: %s", rec.Body)
+	}
+	expectationsMet(t, mock)
+
+	mock.ExpectQuery(query("SELECT billing_code_type, name FROM active_insurance_services")).
+		WillReturnRows(sqlmock.NewRows([]string{"billing_code_type", "name"}))
+	checkSpec(t, s, "GET", "/v1/codes/CPT/00000/rates?npi=1234567890", "", http.StatusNotFound)
+
+	checkSpec(t, s, "GET", "/v1/codes/CPT/70551/rates?npi=123", "", http.StatusBadRequest)
+	checkSpec(t, s, "GET", "/v1/codes/CPT/70551/rates?provider_group=367840", "", http.StatusBadRequest)
+	checkSpec(t, s, "GET", "/v1/codes/CPT/70551/rates?after=MTo", "", http.StatusBadRequest)
+}
+
+func TestComparePayers(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectQuery(query("SELECT billing_code_type, name FROM active_insurance_services")).
+		WillReturnRows(sqlmock.NewRows([]string{"billing_code_type", "name"}).AddRow("CPT", "MRI Brain"))
+	mock.ExpectQuery(query("FROM reference_fees")).
+		WillReturnRows(sqlmock.NewRows([]string{"billing_class", "amount"}).AddRow("professional", 700.0))
+	mock.ExpectQuery(query("SELECT l.locality_id FROM providers")).
+		WillReturnRows(sqlmock.NewRows([]string{"locality_id"}).AddRow("0111205"))
+	mock.ExpectQuery(query("CROSS JOIN")).
+		WillReturnRows(sqlmock.NewRows([]string{"billing_class", "amount"}).AddRow("professional", 250.0))
+	mock.ExpectQuery(query("DATE_FORMAT(r.expiration_date")).WillReturnRows(sqlmock.NewRows([]string{
+		"payer", "plan_name", "plan_id", "billing_class", "negotiated_type", "negotiated_rate", "expiration_date",
+	}).
+		AddRow("Acme Health", "Gold", "123", "professional", "negotiated", 850.0, "9999-12-31").
+		AddRow("Beta Health", "", "", "professional", "percentage", 110.0, "2020-01-01"))
+
+	checkSpec(t, s, "GET", "/v1/codes/CPT/70551/compare?npi=1234567890&as_of=2025-01-01", "", http.StatusOK)
+	expectationsMet(t, mock)
+
+	checkSpec(t, s, "GET", "/v1/codes/CPT/70551/compare", "", http.StatusBadRequest)
+	checkSpec(t, s, "GET", "/v1/codes/CPT/70551/compare?npi=1234567890&as_of=tomorrow", "", http.StatusBadRequest)
+}
+
+func TestEstimate(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectQuery(query("SELECT name FROM active_insurance_services")).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("MRI Brain"))
+	mock.ExpectQuery(query("FROM reference_fees")).
+		WillReturnRows(sqlmock.NewRows([]string{"billing_class", "amount"}))
+	mock.ExpectQuery(query("SELECT r.billing_class, r.negotiated_type, r.negotiated_rate")).
+		WillReturnRows(sqlmock.NewRows([]string{"billing_class", "negotiated_type", "negotiated_rate"}).
+			AddRow("institutional", "negotiated", 1200.0).
+			AddRow("professional", "negotiated", 300.0).
+			AddRow("professional", "percentage", 80.0))
+	mock.ExpectQuery(query("SELECT name FROM active_insurance_services")).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+	body := `{"items": [{"billingCodeType": "CPT", "billingCode": "70551"}, {"billingCodeType": "CPT", "billingCode": "00000", "units": 2}],
+		"providerGroupId": 367840, "runId": 12, "payer": "Acme Health",
+		"benefits": {"deductibleRemaining": 500, "coinsurancePercent": 20, "outOfPocketRemaining": 3000}}`
+	checkSpec(t, s, "POST", "/v1/estimate", body, http.StatusOK)
+	expectationsMet(t, mock)
+
+	for _, body := range []string{
+		`{"items": [], "npi": "1234567890"}`,
+		`{"items": [{"billingCodeType": "CPT", "billingCode": "70551"}]}`,
+		`{"items": [{"billingCodeType": "CPT", "billingCode": "70551"}], "providerGroupId": 367840}`,
+		`{"items": [{"billingCodeType": "CPT", "billingCode": "70551"}], "npi": "1234567890", "unknown": 1}`,
+		`{"items": [{"billingCodeType": "CPT", "billingCode": "70551"}], "npi": "1234567890", "benefits": {"coinsurancePercent": 120}}`,
+	} {
+		checkSpec(t, s, "POST", "/v1/estimate", body, http.StatusBadRequest)
+	}
+}
+
+func TestEpisodes(t *testing.T) {
+	s, mock := newTestServer(t)
+	checkSpec(t, s, "GET", "/v1/episodes", "", http.StatusOK)
+
+	rateColumns := []string{"id", "payer", "tin_value", "rate", "name"}
+	mock.ExpectQuery(query("JOIN provider_group_npis pn")).WillReturnRows(sqlmock.NewRows(rateColumns).
+		AddRow(1, "Acme Health", "12-3456789", 300.0, "Imaging Center").
+		AddRow(2, "Acme Health", "22-2222222", 250.0, "").
+		AddRow(3, "Beta Health", "98-7654321", 280.0, ""))
+	mock.ExpectQuery(query("JOIN provider_group_npis pn")).WillReturnRows(sqlmock.NewRows(rateColumns).
+		AddRow(4, "Acme Health", "12-3456789", 700.0, "Imaging Center").
+		AddRow(5, "Acme Health", "11-1111111", 900.0, "Hospital"))
+
+	// Complete prices come first, then estimates using the payer's median,
+	// then prices missing a component, whatever their totals
+	rec := checkSpec(t, s, "GET", "/v1/episodes/mri-brain/prices?payer=Acme+Health&page_size=10", "", http.StatusOK)
+	body := rec.Body.String()
+	complete, estimate, missing := strings.Index(body, "12-3456789"), strings.Index(body, "22-2222222"), strings.Index(body, "98-7654321")
+	if complete < 0 || complete > estimate || estimate > missing {
+		t.Errorf("prices not ranked complete, estimated, incomplete: %s", body)
+	}
+	if !strings.Contains(body, `"complete":false,"estimated":["CPT 70551 (institutional)"],"missing":[]`) {
+		t.Errorf("component priced at the payer median not reported as estimated: %s", body)
+	}
+	expectationsMet(t, mock)
+
+	checkSpec(t, s, "GET", "/v1/episodes/unknown/prices", "", http.StatusNotFound)
+	checkSpec(t, s, "GET", "/v1/episodes/mri-brain/prices", "", http.StatusBadRequest)
+	checkSpec(t, s, "GET", "/v1/episodes/mri-brain/prices?near=1000", "", http.StatusBadRequest)
+}
+
+func TestGraphQL(t *testing.T) {
+	s, _ := newTestServer(t)
+	checkSpec(t, s, "POST", "/v1/graphql", `{"query": "{ __typename }"}`, http.StatusOK)
+	checkSpec(t, s, "POST", "/v1/graphql", `{"query": "{ unknownField }"}`, http.StatusBadRequest)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"healthcare-saver-ingest/internal/store"
+)
+
+// validNPI reports whether npi is a 10-digit National Provider Identifier
+func validNPI(npi string) bool {
+	return isDigits(npi, 10)
+}
+
+// handleProviders serves GET /v1/providers?state=&city=&code=
+func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
+	page, pageSize, ok := parsePaging(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "page must be >= 1 and page_size between 1 and 100")
+		return
+	}
+
+	query := r.URL.Query()
+	providers, err := s.store.Providers(r.Context(), store.ProviderFilter{
+		State: query.Get("state"),
+		City:  query.Get("city"),
+		Code:  query.Get("code"),
+	}, page, pageSize)
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, providers)
+}
+
+// handleProvider serves GET /v1/providers/{npi}
+func (s *Server) handleProvider(w http.ResponseWriter, r *http.Request) {
+	npi := r.PathValue("npi")
+	if !validNPI(npi) {
+		writeError(w, http.StatusBadRequest, "npi must be 10 digits")
+		return
+	}
+
+	provider, err := s.store.Provider(r.Context(), npi)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "unknown provider")
+		return
+	}
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, provider)
+}
+
+// handleProviderRates serves GET /v1/providers/{npi}/rates?code=
+func (s *Server) handleProviderRates(w http.ResponseWriter, r *http.Request) {
+	npi := r.PathValue("npi")
+	if !validNPI(npi) {
+		writeError(w, http.StatusBadRequest, "npi must be 10 digits")
+		return
+	}
+
+	page, pageSize, ok := parsePaging(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "page must be >= 1 and page_size between 1 and 100")
+		return
+	}
+
+	rates, err := s.store.ProviderRates(r.Context(), npi, r.URL.Query().Get("code"), page, pageSize)
+	if errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "unknown provider")
+		return
+	}
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rates)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"healthcare-saver-ingest/internal/store"
+)
+
+// handleSearch serves GET /v1/search?q=&sort=&page=&page_size=&near=&radius=
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "missing query parameter q")
+		return
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = store.DefaultSearchSort
+	}
+	if !store.ValidSearchSort(sort) {
+		writeError(w, http.StatusBadRequest, "unsupported sort (use relevance, price, -price, name, -name, code or distance)")
+		return
+	}
+
+	near, msg := parseNear(r)
+	if msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if sort == "distance" && near == nil {
+		writeError(w, http.StatusBadRequest, "sort=distance requires near")
+		return
+	}
+
+	page, pageSize, ok := parsePaging(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "page must be >= 1 and page_size between 1 and 100")
+		return
+	}
+
+	results, err := s.store.Search(r.Context(), store.SearchParams{
+		Query:    query,
+		Sort:     sort,
+		Page:     page,
+		PageSize: pageSize,
+		Near:     near,
+	})
+	if errors.Is(err, store.ErrUnknownZIP) {
+		writeError(w, http.StatusBadRequest, "unknown ZIP code")
+		return
+	}
+	if err != nil {
+		writeInternalError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
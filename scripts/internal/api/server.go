@@ -0,0 +1,247 @@
+// Package api serves the ingested price transparency data over HTTP.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"healthcare-saver-ingest/internal/auth"
+	"healthcare-saver-ingest/internal/cache"
+	"healthcare-saver-ingest/internal/episode"
+	"healthcare-saver-ingest/internal/gql"
+	"healthcare-saver-ingest/internal/store"
+)
+
+// Server is the HTTP API over a store
+type Server struct {
+	store         *store.Store
+	graphql       *gql.Schema
+	mux           *http.ServeMux
+	allowedOrigin string
+	cache         cache.Cache
+	cacheTTL      time.Duration
+	limiter       *auth.Limiter
+	validate      bool
+	episodes      []episode.Episode
+}
+
+// Options configures a Server
+type Options struct {
+	// AllowedOrigin is sent as Access-Control-Allow-Origin when not empty
+	AllowedOrigin string
+	// Cache stores GET responses when not nil
+	Cache cache.Cache
+	// CacheTTL bounds how long a cached response is served
+	CacheTTL time.Duration
+	// Limiter requires an API key on every route but /healthz when not nil
+	Limiter *auth.Limiter
+	// ValidateOpenAPI logs requests and responses that do not match the
+	// OpenAPI document
+	ValidateOpenAPI bool
+	// Episodes are the episodes of care priced by /v1/episodes
+	Episodes []episode.Episode
+}
+
+// NewServer creates the API server and registers its routes
+func NewServer(st *store.Store, opts Options) *Server {
+	s := &Server{
+		store:         st,
+		graphql:       gql.NewSchema(st),
+		mux:           http.NewServeMux(),
+		allowedOrigin: opts.AllowedOrigin,
+		cache:         opts.Cache,
+		cacheTTL:      opts.CacheTTL,
+		limiter:       opts.Limiter,
+		validate:      opts.ValidateOpenAPI,
+		episodes:      opts.Episodes,
+	}
+
+	s.handle("health", s.handleHealth)
+	s.handle("openapi", s.handleOpenAPI)
+	s.handle("search", s.cached(dataTags, s.handleSearch))
+	s.handle("suggest", s.handleSuggest)
+	s.handle("codeRates", s.cached(codeTags, s.handleCodeRates))
+	s.handle("comparePayers", defaultAsOf(s.cached(codeTags, s.handleComparePayers)))
+	s.handle("estimate", s.handleEstimate)
+	s.handle("graphql", s.handleGraphQL)
+	s.handle("listProviders", s.cached(dataTags, s.handleProviders))
+	s.handle("getProvider", s.cached(dataTags, s.handleProvider))
+	s.handle("providerRates", s.cached(dataTags, s.handleProviderRates))
+	s.handle("listEpisodes", s.handleEpisodes)
+	s.handle("episodePrices", s.cached(s.episodeTags, s.handleEpisodePrices))
+
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	var key *auth.Key
+	defer func() { s.logRequest(r, rec.status, start, key) }()
+
+	if s.allowedOrigin != "" {
+		rec.Header().Set("Access-Control-Allow-Origin", s.allowedOrigin)
+		rec.Header().Set("Access-Control-Expose-Headers", "ETag, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining")
+	}
+
+	// Answer CORS preflight requests, sent by browsers before JSON POSTs
+	if s.allowedOrigin != "" && r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		rec.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		rec.Header().Set("Access-Control-Allow-Headers", "Content-Type, If-None-Match, Authorization, X-API-Key")
+		rec.Header().Set("Access-Control-Max-Age", "86400")
+		rec.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if s.limiter != nil && !isPublic(r.URL.Path) {
+		var ok bool
+		if key, ok = s.authenticate(rec, r); !ok {
+			return
+		}
+	}
+	s.mux.ServeHTTP(rec, r)
+}
+
+// logRequest logs a served request, with the prefix of its API key
+func (s *Server) logRequest(r *http.Request, status int, start time.Time, key *auth.Key) {
+	if key != nil {
+		log.Printf("%s %s %d %s key=%s", r.Method, r.URL.RequestURI(), status, time.Since(start).Round(time.Microsecond), key.Prefix)
+		return
+	}
+	log.Printf("%s %s %d %s", r.Method, r.URL.RequestURI(), status, time.Since(start).Round(time.Microsecond))
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// handleHealth reports whether the database is reachable
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if err := s.store.Ping(); err != nil {
+		writeError(w, http.StatusServiceUnavailable, "database unavailable")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("⚠️ Failed to write response: %v", err)
+	}
+}
+
+// errorResponse is the body of every error response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
+
+// writeInternalError logs err and writes a generic 500 response
+func writeInternalError(w http.ResponseWriter, err error) {
+	log.Printf("❌ %v", err)
+	writeError(w, http.StatusInternalServerError, "internal server error")
+}
+
+// Pagination limits
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// parsePaging reads the page and page_size query parameters
+func parsePaging(r *http.Request) (page, pageSize int, ok bool) {
+	page = 1
+
+	if value := r.URL.Query().Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 0, 0, false
+		}
+		page = n
+	}
+
+	if pageSize, ok = parsePageSize(r); !ok {
+		return 0, 0, false
+	}
+	return page, pageSize, true
+}
+
+// parsePageSize reads the page_size query parameter
+func parsePageSize(r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("page_size")
+	if value == "" {
+		return defaultPageSize, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > maxPageSize {
+		return 0, false
+	}
+	return n, true
+}
+
+// defaultRadiusMiles is the radius of a near filter without a radius
+const defaultRadiusMiles = 25
+
+// parseNear parses the optional near (ZIP code) and radius (miles) query
+// parameters. It returns a nil filter without near, and an error message
+// when the parameters are invalid.
+func parseNear(r *http.Request) (*store.GeoFilter, string) {
+	zip := r.URL.Query().Get("near")
+	radius := r.URL.Query().Get("radius")
+	if zip == "" {
+		if radius != "" {
+			return nil, "radius requires near"
+		}
+		return nil, ""
+	}
+	if !validZIP(zip) {
+		return nil, "near must be a 5-digit ZIP code"
+	}
+
+	near := &store.GeoFilter{ZIP: zip, RadiusMiles: defaultRadiusMiles}
+	if radius != "" {
+		miles, err := strconv.ParseFloat(radius, 64)
+		if err != nil || miles <= 0 || miles > store.MaxRadiusMiles {
+			return nil, fmt.Sprintf("radius must be between 0 and %d miles", store.MaxRadiusMiles)
+		}
+		near.RadiusMiles = miles
+	}
+	return near, ""
+}
+
+// validZIP reports whether zip is a 5-digit ZIP code
+func validZIP(zip string) bool {
+	return isDigits(zip, 5)
+}
+
+// isDigits reports whether value consists of exactly n ASCII digits
+func isDigits(value string, n int) bool {
+	if len(value) != n {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"healthcare-saver-ingest/internal/store"
+)
+
+// defaultSuggestLimit is the number of suggestions when no limit is given
+const defaultSuggestLimit = 10
+
+// suggestResponse is the body of a suggest response
+type suggestResponse struct {
+	Prefix      string             `json:"prefix"`
+	Suggestions []store.Suggestion `json:"suggestions"`
+}
+
+// handleSuggest serves GET /v1/suggest?prefix=&limit=
+func (s *Server) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.TrimSpace(r.URL.Query().Get("prefix"))
+	if prefix == "" {
+		writeError(w, http.StatusBadRequest, "missing query parameter prefix")
+		return
+	}
+
+	limit := defaultSuggestLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > store.MaxSuggestions {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(store.MaxSuggestions))
+			return
+		}
+		limit = n
+	}
+
+	writeJSON(w, http.StatusOK, suggestResponse{
+		Prefix:      prefix,
+		Suggestions: s.store.Suggest(prefix, limit),
+	})
+}
@@ -0,0 +1,239 @@
+// Package auth issues the API keys of partners calling the price API and
+// enforces each key's request rate and daily quota. Keys are random tokens
+// shown once at creation; only their SHA-256 hash is stored.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// tokenPrefix starts every API key, so leaked keys are easy to recognize
+const tokenPrefix = "hcs_"
+
+// prefixLength is how many leading characters of a key are stored in clear
+// to identify it in listings and logs
+const prefixLength = len(tokenPrefix) + 8
+
+// ErrKeyNotFound is returned when revoking a key that does not exist or is
+// already revoked
+var ErrKeyNotFound = errors.New("API key not found")
+
+// Limits are the request limits of a key
+type Limits struct {
+	// RatePerSecond is the sustained request rate
+	RatePerSecond float64
+	// Burst is how many requests may be made at once
+	Burst int
+	// DailyQuota is the number of requests per UTC day; 0 means unlimited
+	DailyQuota int
+}
+
+// Validate checks that limits are usable
+func (l Limits) Validate() error {
+	if l.RatePerSecond <= 0 {
+		return fmt.Errorf("rate must be positive")
+	}
+	if l.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1")
+	}
+	if l.DailyQuota < 0 {
+		return fmt.Errorf("daily quota cannot be negative")
+	}
+	return nil
+}
+
+// Key is an issued API key
+type Key struct {
+	ID     int64
+	Name   string
+	Prefix string
+	Limits
+	CreatedAt     time.Time
+	RevokedAt     *time.Time
+	LastUsedAt    *time.Time
+	RequestsToday int64
+}
+
+// Keys stores API keys
+type Keys struct {
+	db *sql.DB
+}
+
+// NewKeys creates a key store on an open database
+func NewKeys(db *sql.DB) *Keys {
+	return &Keys{db: db}
+}
+
+// newToken returns a new random API key
+func newToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %v", err)
+	}
+	return tokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the stored hash of an API key
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// Create issues a key and returns it with its token, which is not stored
+// and cannot be shown again
+func (k *Keys) Create(ctx context.Context, name string, limits Limits) (string, *Key, error) {
+	if err := limits.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	result, err := k.db.ExecContext(ctx, `
+		INSERT INTO api_keys (name, key_prefix, key_hash, rate_per_second, burst, daily_quota)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, name, token[:prefixLength], hashToken(token), limits.RatePerSecond, limits.Burst, limits.DailyQuota)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create API key: %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get API key id: %v", err)
+	}
+
+	return token, &Key{ID: id, Name: name, Prefix: token[:prefixLength], Limits: limits, CreatedAt: time.Now()}, nil
+}
+
+// Revoke revokes a key, which stops working once API servers notice
+func (k *Keys) Revoke(ctx context.Context, id int64) error {
+	result, err := k.db.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %v", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %v", err)
+	}
+	if n == 0 {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// keyColumns are the columns scanned by scanKey, over api_keys k and the
+// current day's api_key_usage u
+const keyColumns = `k.id, k.name, k.key_prefix, k.rate_per_second, k.burst, k.daily_quota,
+	k.created_at, k.revoked_at, k.last_used_at, COALESCE(u.requests, 0)`
+
+// keyFrom joins each key to its usage of the current UTC day
+const keyFrom = `api_keys k
+	LEFT JOIN api_key_usage u ON u.api_key_id = k.id AND u.day = UTC_DATE()`
+
+// scanKey scans the keyColumns of a row
+func scanKey(row interface{ Scan(...interface{}) error }) (*Key, error) {
+	var key Key
+	var revokedAt, lastUsedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.RatePerSecond, &key.Burst, &key.DailyQuota,
+		&key.CreatedAt, &revokedAt, &lastUsedAt, &key.RequestsToday); err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	return &key, nil
+}
+
+// List returns all keys, including revoked ones, by id
+func (k *Keys) List(ctx context.Context) ([]Key, error) {
+	rows, err := k.db.QueryContext(ctx, `SELECT `+keyColumns+` FROM `+keyFrom+` ORDER BY k.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %v", err)
+	}
+	defer rows.Close()
+
+	var keys []Key
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %v", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %v", err)
+	}
+	return keys, nil
+}
+
+// Lookup returns the active key of a token, or nil when the token is not a
+// key or was revoked
+func (k *Keys) Lookup(ctx context.Context, token string) (*Key, error) {
+	key, err := scanKey(k.db.QueryRowContext(ctx, `
+		SELECT `+keyColumns+` FROM `+keyFrom+`
+		WHERE k.key_hash = ? AND k.revoked_at IS NULL
+	`, hashToken(token)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up API key: %v", err)
+	}
+	return key, nil
+}
+
+// RecordUsage adds request counts by key to their usage of a UTC day and
+// returns the keys' new totals for that day, which include the requests
+// recorded by other API servers
+func (k *Keys) RecordUsage(ctx context.Context, day time.Time, requests map[int64]int64) (map[int64]int64, error) {
+	totals := make(map[int64]int64, len(requests))
+	if len(requests) == 0 {
+		return totals, nil
+	}
+
+	tx, err := k.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin usage transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	date := day.Format("2006-01-02")
+	for id, n := range requests {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO api_key_usage (api_key_id, day, requests) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE requests = requests + VALUES(requests)
+		`, id, date, n); err != nil {
+			return nil, fmt.Errorf("failed to record usage of API key %d: %v", id, err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?
+		`, id); err != nil {
+			return nil, fmt.Errorf("failed to record use of API key %d: %v", id, err)
+		}
+		var total int64
+		if err := tx.QueryRowContext(ctx, `
+			SELECT requests FROM api_key_usage WHERE api_key_id = ? AND day = ?
+		`, id, date).Scan(&total); err != nil {
+			return nil, fmt.Errorf("failed to read usage of API key %d: %v", id, err)
+		}
+		totals[id] = total
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit usage: %v", err)
+	}
+	return totals, nil
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// lookupTTL is how long a looked up key is trusted before it is read again,
+// which bounds how long a revoked key or old limits keep working
+const lookupTTL = time.Minute
+
+// Decision is the outcome of charging a request to a key
+type Decision struct {
+	Allowed bool
+	// Reason explains a refusal
+	Reason string
+	// RetryAfter is how long to wait before retrying a refused request
+	RetryAfter time.Duration
+	// Remaining is what is left of the daily quota, or -1 without one
+	Remaining int64
+}
+
+// Limiter authenticates API keys and enforces their limits. Token buckets
+// are kept per API server; daily usage is shared through the database,
+// so with several servers a quota can be exceeded by what the others served
+// since their last flush.
+type Limiter struct {
+	keys *Keys
+	now  func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*client // by token hash
+	pending map[usage]int64    // requests not yet recorded
+}
+
+// client is the limiter state of a key
+type client struct {
+	key        *Key
+	lookedUpAt time.Time
+	tokens     float64
+	refilledAt time.Time
+	day        string
+	used       int64 // requests on day, recorded or pending
+}
+
+// usage identifies the requests of a key on a UTC day
+type usage struct {
+	keyID int64
+	day   string
+}
+
+// NewLimiter creates a limiter for the keys of a store
+func NewLimiter(keys *Keys) *Limiter {
+	return &Limiter{
+		keys:    keys,
+		now:     time.Now,
+		clients: make(map[string]*client),
+		pending: make(map[usage]int64),
+	}
+}
+
+// Allow authenticates token and charges one request to its key. It returns
+// a nil key when the token is not an active key.
+func (l *Limiter) Allow(ctx context.Context, token string) (*Key, Decision, error) {
+	hash := string(hashToken(token))
+	now := l.now()
+
+	l.mu.Lock()
+	c := l.clients[hash]
+	stale := c == nil || now.Sub(c.lookedUpAt) >= lookupTTL
+	l.mu.Unlock()
+
+	if stale {
+		key, err := l.keys.Lookup(ctx, token)
+		if err != nil {
+			return nil, Decision{}, err
+		}
+		c = l.refresh(hash, key, now)
+		if c == nil {
+			return nil, Decision{}, nil
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return c.key, l.charge(c, now), nil
+}
+
+// refresh stores a looked up key, or forgets a revoked one
+func (l *Limiter) refresh(hash string, key *Key, now time.Time) *client {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if key == nil {
+		delete(l.clients, hash)
+		return nil
+	}
+
+	day := now.UTC().Format("2006-01-02")
+	c := l.clients[hash]
+	if c == nil {
+		c = &client{tokens: float64(key.Burst), refilledAt: now}
+		l.clients[hash] = c
+	}
+	c.key = key
+	c.lookedUpAt = now
+	c.day = day
+	c.used = key.RequestsToday + l.pending[usage{key.ID, day}]
+	return c
+}
+
+// charge applies a key's limits to one request; the caller holds l.mu
+func (l *Limiter) charge(c *client, now time.Time) Decision {
+	utc := now.UTC()
+	day := utc.Format("2006-01-02")
+	if c.day != day {
+		c.day, c.used = day, l.pending[usage{c.key.ID, day}]
+	}
+
+	remaining := int64(-1)
+	if c.key.DailyQuota > 0 {
+		remaining = int64(c.key.DailyQuota) - c.used
+		if remaining <= 0 {
+			midnight := time.Date(utc.Year(), utc.Month(), utc.Day()+1, 0, 0, 0, 0, time.UTC)
+			return Decision{Reason: "daily quota exceeded", RetryAfter: midnight.Sub(utc), Remaining: 0}
+		}
+	}
+
+	// Refill the bucket for the time since the last request
+	rate := c.key.RatePerSecond
+	c.tokens = math.Min(float64(c.key.Burst), c.tokens+now.Sub(c.refilledAt).Seconds()*rate)
+	c.refilledAt = now
+	if c.tokens < 1 {
+		wait := time.Duration((1 - c.tokens) / rate * float64(time.Second))
+		return Decision{Reason: "rate limit exceeded", RetryAfter: wait, Remaining: remaining}
+	}
+	c.tokens--
+
+	c.used++
+	l.pending[usage{c.key.ID, day}]++
+	if remaining > 0 {
+		remaining--
+	}
+	return Decision{Allowed: true, Remaining: remaining}
+}
+
+// Flush records the pending usage in the database and picks up the usage
+// recorded by other API servers for the keys used here
+func (l *Limiter) Flush(ctx context.Context) error {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = make(map[usage]int64)
+	l.mu.Unlock()
+
+	byDay := make(map[string]map[int64]int64)
+	for u, n := range pending {
+		if byDay[u.day] == nil {
+			byDay[u.day] = make(map[int64]int64)
+		}
+		byDay[u.day][u.keyID] = n
+	}
+
+	for day, requests := range byDay {
+		date, _ := time.Parse("2006-01-02", day)
+		totals, err := l.keys.RecordUsage(ctx, date, requests)
+		if err != nil {
+			// Keep the requests for the next flush
+			l.mu.Lock()
+			for u, n := range pending {
+				l.pending[u] += n
+			}
+			l.mu.Unlock()
+			return err
+		}
+
+		l.mu.Lock()
+		for _, c := range l.clients {
+			if total, ok := totals[c.key.ID]; ok && c.day == day {
+				c.used = total + l.pending[usage{c.key.ID, day}]
+			}
+		}
+		l.mu.Unlock()
+		for id := range requests {
+			delete(pending, usage{id, day})
+		}
+	}
+	return nil
+}
+
+// Run flushes usage every interval until ctx is done, then a last time
+func (l *Limiter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := l.Flush(flushCtx); err != nil {
+				log.Printf("⚠️ Failed to record API key usage: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := l.Flush(ctx); err != nil {
+				log.Printf("⚠️ Failed to record API key usage: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// clock is a settable time source for a limiter
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time          { return c.now }
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newTestLimiter returns a limiter on a mock database and a clock at noon UTC
+func newTestLimiter(t *testing.T) (*Limiter, sqlmock.Sqlmock, *clock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+
+	c := &clock{now: time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)}
+	l := NewLimiter(NewKeys(db))
+	l.now = c.Now
+	return l, mock, c
+}
+
+// expectKey expects the lookup of an active key with limits, which already
+// made requestsToday requests
+func expectKey(mock sqlmock.Sqlmock, limits Limits, requestsToday int64) {
+	mock.ExpectQuery("FROM api_keys k").WillReturnRows(sqlmock.NewRows([]string{
+		"id", "name", "key_prefix", "rate_per_second", "burst", "daily_quota",
+		"created_at", "revoked_at", "last_used_at", "requests",
+	}).AddRow(1, "Acme Health", "hcs_abcdefgh", limits.RatePerSecond, limits.Burst, limits.DailyQuota,
+		time.Now(), nil, nil, requestsToday))
+}
+
+// allow charges one request and fails the test on errors
+func allow(t *testing.T, l *Limiter, token string) (*Key, Decision) {
+	t.Helper()
+	key, decision, err := l.Allow(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	return key, decision
+}
+
+func TestLimiterBurstAndRate(t *testing.T) {
+	l, mock, c := newTestLimiter(t)
+	expectKey(mock, Limits{RatePerSecond: 2, Burst: 2}, 0)
+
+	for i := 0; i < 2; i++ {
+		if _, d := allow(t, l, "hcs_token"); !d.Allowed || d.Remaining != -1 {
+			t.Fatalf("request %d of the burst = %+v, want allowed without a quota", i+1, d)
+		}
+	}
+	_, d := allow(t, l, "hcs_token")
+	if d.Allowed || d.Reason != "rate limit exceeded" || d.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("request over the burst = %+v, want refused for 500ms", d)
+	}
+
+	// Half a second refills one token at two per second
+	c.Advance(500 * time.Millisecond)
+	if _, d := allow(t, l, "hcs_token"); !d.Allowed {
+		t.Fatalf("request after the refill = %+v, want allowed", d)
+	}
+	if _, d := allow(t, l, "hcs_token"); d.Allowed {
+		t.Fatalf("second request after one token refilled = %+v, want refused", d)
+	}
+}
+
+func TestLimiterDailyQuota(t *testing.T) {
+	l, mock, c := newTestLimiter(t)
+	c.now = time.Date(2026, 3, 2, 23, 59, 30, 0, time.UTC)
+	expectKey(mock, Limits{RatePerSecond: 100, Burst: 100, DailyQuota: 3}, 1)
+
+	for _, remaining := range []int64{1, 0} {
+		if _, d := allow(t, l, "hcs_token"); !d.Allowed || d.Remaining != remaining {
+			t.Fatalf("request = %+v, want allowed with %d remaining", d, remaining)
+		}
+	}
+	_, d := allow(t, l, "hcs_token")
+	if d.Allowed || d.Reason != "daily quota exceeded" || d.RetryAfter != 30*time.Second {
+		t.Fatalf("request over the quota = %+v, want refused until midnight UTC", d)
+	}
+
+	// The quota starts over on the next UTC day, without waiting for the key to
+	// be looked up again
+	c.Advance(30 * time.Second)
+	if _, d := allow(t, l, "hcs_token"); !d.Allowed || d.Remaining != 2 {
+		t.Fatalf("request on the next day = %+v, want allowed with 2 remaining", d)
+	}
+}
+
+func TestLimiterUnknownAndRevokedKeys(t *testing.T) {
+	l, mock, c := newTestLimiter(t)
+
+	mock.ExpectQuery("FROM api_keys k").WillReturnRows(sqlmock.NewRows(nil))
+	if key, _ := allow(t, l, "hcs_unknown"); key != nil {
+		t.Fatalf("unknown token authenticated as %+v", key)
+	}
+
+	expectKey(mock, Limits{RatePerSecond: 1, Burst: 10}, 0)
+	if key, _ := allow(t, l, "hcs_token"); key == nil {
+		t.Fatal("active key not authenticated")
+	}
+
+	// Within the lookup TTL the cached key is trusted; after it, the
+	// revocation is noticed
+	c.Advance(lookupTTL - time.Second)
+	if key, _ := allow(t, l, "hcs_token"); key == nil {
+		t.Fatal("cached key not authenticated")
+	}
+	c.Advance(time.Second)
+	mock.ExpectQuery("FROM api_keys k").WillReturnRows(sqlmock.NewRows(nil))
+	if key, _ := allow(t, l, "hcs_token"); key != nil {
+		t.Fatal("revoked key still authenticated after the lookup TTL")
+	}
+
+	mock.ExpectQuery("FROM api_keys k").WillReturnError(errors.New("connection refused"))
+	if _, _, err := l.Allow(context.Background(), "hcs_token"); err == nil {
+		t.Fatal("failed lookup did not return an error")
+	}
+}
+
+// expectUsage expects the recording of requests of key 1, returning its new
+// total for the day
+func expectUsage(mock sqlmock.Sqlmock, requests, total int64) {
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO api_key_usage").WithArgs(1, "2026-03-02", requests).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE api_keys SET last_used_at").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT requests FROM api_key_usage").WithArgs(1, "2026-03-02").
+		WillReturnRows(sqlmock.NewRows([]string{"requests"}).AddRow(total))
+	mock.ExpectCommit()
+}
+
+func TestLimiterFlush(t *testing.T) {
+	l, mock, _ := newTestLimiter(t)
+	expectKey(mock, Limits{RatePerSecond: 100, Burst: 100, DailyQuota: 10}, 0)
+	for i := 0; i < 3; i++ {
+		allow(t, l, "hcs_token")
+	}
+
+	// A failed flush keeps the requests for the next one
+	mock.ExpectBegin().WillReturnError(errors.New("connection reset"))
+	if err := l.Flush(context.Background()); err == nil {
+		t.Fatal("failed flush did not return an error")
+	}
+
+	// Other servers served 5 requests of the key meanwhile
+	expectUsage(mock, 3, 8)
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if _, d := allow(t, l, "hcs_token"); !d.Allowed || d.Remaining != 1 {
+		t.Fatalf("request after the flush = %+v, want allowed with 1 remaining", d)
+	}
+
+	// Nothing is pending after a flush but the request since
+	expectUsage(mock, 1, 9)
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if err := l.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush without pending requests: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+// Package cache stores API responses. Entries are invalidated through tags:
+// a key embeds the current version of each tag it depends on, so bumping a
+// tag's version orphans every entry built from the older data without
+// having to find those entries. Orphans age out through their TTL (or LRU
+// eviction).
+package cache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache stores values by key. Implementations are safe for concurrent use.
+type Cache interface {
+	// Get returns the value of key, with ok false when it is missing or
+	// expired
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set stores value under key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Versions returns the current version of each tag
+	Versions(ctx context.Context, tags []string) ([]int64, error)
+	// Invalidate bumps the version of each tag
+	Invalidate(ctx context.Context, tags []string) error
+}
+
+// Key returns the key of a value that depends on tags, for their current
+// versions
+func Key(ctx context.Context, c Cache, base string, tags []string) (string, error) {
+	versions, err := c.Versions(ctx, tags)
+	if err != nil {
+		return "", err
+	}
+
+	var key strings.Builder
+	key.WriteString(base)
+	for i, tag := range tags {
+		key.WriteString("|")
+		key.WriteString(tag)
+		key.WriteString("@")
+		key.WriteString(strconv.FormatInt(versions[i], 10))
+	}
+	return key.String(), nil
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestLRU returns a cache of maxEntries entries on a settable clock
+func newTestLRU(maxEntries int) (*LRU, *time.Time) {
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	c := NewLRU(maxEntries)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+// cached returns the value of key and whether it was a hit
+func cached(t *testing.T, c *LRU, key string) (string, bool) {
+	t.Helper()
+	value, ok, err := c.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", key, err)
+	}
+	return string(value), ok
+}
+
+func TestLRUExpiry(t *testing.T) {
+	ctx := context.Background()
+	c, now := newTestLRU(10)
+
+	c.Set(ctx, "key", []byte("value"), time.Minute)
+	*now = now.Add(time.Minute - time.Second)
+	if value, ok := cached(t, c, "key"); !ok || value != "value" {
+		t.Fatalf("Get before the TTL = %q, %v, want value", value, ok)
+	}
+
+	// An entry expires at its TTL and is removed on the lookup that finds it
+	*now = now.Add(time.Second)
+	if _, ok := cached(t, c, "key"); ok {
+		t.Fatal("Get at the TTL hit")
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len after the expired lookup = %d, want 0", c.Len())
+	}
+
+	// Setting an existing key replaces its value and restarts its TTL
+	c.Set(ctx, "key", []byte("old"), time.Minute)
+	*now = now.Add(30 * time.Second)
+	c.Set(ctx, "key", []byte("new"), time.Minute)
+	*now = now.Add(45 * time.Second)
+	if value, ok := cached(t, c, "key"); !ok || value != "new" {
+		t.Fatalf("Get after the replacement = %q, %v, want new", value, ok)
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestLRU(2)
+
+	c.Set(ctx, "a", []byte("1"), time.Hour)
+	c.Set(ctx, "b", []byte("2"), time.Hour)
+	// Reading a makes b the least recently used
+	cached(t, c, "a")
+	c.Set(ctx, "c", []byte("3"), time.Hour)
+
+	if c.Len() != 2 {
+		t.Errorf("Len = %d, want 2", c.Len())
+	}
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := cached(t, c, key); ok != want {
+			t.Errorf("Get(%s) hit = %v, want %v", key, ok, want)
+		}
+	}
+
+	// Replacing an entry does not evict another one
+	c.Set(ctx, "c", []byte("4"), time.Hour)
+	if _, ok := cached(t, c, "a"); !ok || c.Len() != 2 {
+		t.Errorf("replacing c evicted a or changed Len to %d", c.Len())
+	}
+}
+
+func TestLRUVersions(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestLRU(10)
+
+	if err := c.Invalidate(ctx, []string{"code:CPT:70551", "code:CPT:70551", "data"}); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	versions, err := c.Versions(ctx, []string{"code:CPT:70551", "data", "search"})
+	if err != nil {
+		t.Fatalf("Versions: %v", err)
+	}
+	if versions[0] != 2 || versions[1] != 1 || versions[2] != 0 {
+		t.Errorf("versions = %v, want [2 1 0]", versions)
+	}
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a cache shared by every API instance using the same Redis
+// server. Tag versions never expire, so an invalidation by one instance
+// applies to all of them.
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedis creates a cache on a Redis client, namespacing its keys with
+// prefix
+func NewRedis(client redis.UniversalClient, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+// OpenRedis connects to the Redis server of a redis:// or rediss:// URL
+func OpenRedis(ctx context.Context, url, prefix string) (*Redis, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %v", err)
+	}
+	client := redis.NewClient(options)
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %v", err)
+	}
+	return NewRedis(client, prefix), nil
+}
+
+// Get implements Cache
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, c.prefix+"entry:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get cache entry: %v", err)
+	}
+	return value, true, nil
+}
+
+// Set implements Cache
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.client.Set(ctx, c.prefix+"entry:"+key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set cache entry: %v", err)
+	}
+	return nil
+}
+
+// Versions implements Cache
+func (c *Redis) Versions(ctx context.Context, tags []string) ([]int64, error) {
+	versions := make([]int64, len(tags))
+	if len(tags) == 0 {
+		return versions, nil
+	}
+
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = c.prefix + "tag:" + tag
+	}
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag versions: %v", err)
+	}
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			if versions[i], err = strconv.ParseInt(s, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid version of tag %s: %v", tags[i], err)
+			}
+		}
+	}
+	return versions, nil
+}
+
+// Invalidate implements Cache
+func (c *Redis) Invalidate(ctx context.Context, tags []string) error {
+	pipe := c.client.Pipeline()
+	for _, tag := range tags {
+		pipe.Incr(ctx, c.prefix+"tag:"+tag)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to invalidate tags: %v", err)
+	}
+	return nil
+}
+
+// Close closes the Redis client
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedis returns a cache on an in-memory Redis server
+func newTestRedis(t *testing.T, prefix string) (*Redis, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedis(client, prefix), server
+}
+
+func TestRedisGetSet(t *testing.T) {
+	ctx := context.Background()
+	c, server := newTestRedis(t, "test:")
+
+	if _, ok, err := c.Get(ctx, "missing"); err != nil || ok {
+		t.Fatalf("Get(missing) = ok %v, err %v, want a miss", ok, err)
+	}
+
+	if err := c.Set(ctx, "key", []byte("value"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	value, ok, err := c.Get(ctx, "key")
+	if err != nil || !ok || string(value) != "value" {
+		t.Fatalf("Get(key) = %q, ok %v, err %v, want value", value, ok, err)
+	}
+	if !server.Exists("test:entry:key") {
+		t.Errorf("entry not stored under its prefixed key, keys %v", server.Keys())
+	}
+
+	server.FastForward(time.Minute)
+	if _, ok, err := c.Get(ctx, "key"); err != nil || ok {
+		t.Fatalf("Get(key) after its TTL = ok %v, err %v, want a miss", ok, err)
+	}
+}
+
+func TestRedisVersions(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestRedis(t, "test:")
+
+	versions, err := c.Versions(ctx, nil)
+	if err != nil || len(versions) != 0 {
+		t.Fatalf("Versions(nil) = %v, %v, want none", versions, err)
+	}
+
+	versions, err = c.Versions(ctx, []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Versions: %v", err)
+	}
+	if versions[0] != 0 || versions[1] != 0 {
+		t.Fatalf("versions of new tags = %v, want [0 0]", versions)
+	}
+
+	if err := c.Invalidate(ctx, []string{"a"}); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if err := c.Invalidate(ctx, []string{"a", "b"}); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	versions, err = c.Versions(ctx, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("Versions: %v", err)
+	}
+	if versions[0] != 2 || versions[1] != 1 || versions[2] != 0 {
+		t.Fatalf("versions = %v, want [2 1 0]", versions)
+	}
+}
+
+func TestRedisInvalidVersion(t *testing.T) {
+	c, server := newTestRedis(t, "test:")
+	server.Set("test:tag:a", "not a number")
+
+	if _, err := c.Versions(context.Background(), []string{"a"}); err == nil {
+		t.Fatal("Versions of a corrupt tag succeeded")
+	}
+}
+
+// TestRedisSharedInvalidation checks that instances on one server share tag
+// versions, and that prefixes keep caches apart
+func TestRedisSharedInvalidation(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	open := func(prefix string) *Redis {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+		return NewRedis(client, prefix)
+	}
+	first, second, other := open("api:"), open("api:"), open("other:")
+
+	tags := []string{"code:CPT:70551"}
+	before, err := Key(ctx, first, "/v1/codes/CPT/70551/rates?", tags)
+	if err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	if err := first.Set(ctx, before, []byte("rates"), time.Minute); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, ok, _ := second.Get(ctx, before); !ok {
+		t.Fatal("entry set by one instance missing from another")
+	}
+	if _, ok, _ := other.Get(ctx, before); ok {
+		t.Fatal("entry visible under another prefix")
+	}
+
+	if err := second.Invalidate(ctx, tags); err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	after, err := Key(ctx, first, "/v1/codes/CPT/70551/rates?", tags)
+	if err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	if after == before {
+		t.Fatalf("key %q unchanged by an invalidation on another instance", after)
+	}
+	if _, ok, _ := first.Get(ctx, after); ok {
+		t.Fatal("stale entry found under the new key")
+	}
+
+	versions, err := other.Versions(ctx, tags)
+	if err != nil || versions[0] != 0 {
+		t.Fatalf("versions under another prefix = %v, %v, want [0]", versions, err)
+	}
+}
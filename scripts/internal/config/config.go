@@ -0,0 +1,89 @@
+// Package config holds the database configuration shared by the ingestion
+// tool and the API server.
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+)
+
+// DBConfig is the database configuration
+type DBConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Database string
+	SSL      string
+}
+
+// Load loads configuration from the .env file and environment variables
+func Load() (*DBConfig, error) {
+	// Load .env file
+	if err := godotenv.Load(); err != nil {
+		log.Printf("⚠️ Warning: Could not load .env file: %v", err)
+	}
+
+	config := &DBConfig{
+		Host:     GetEnv("DB_HOST", "localhost"),
+		Port:     GetEnv("DB_PORT", "3306"),
+		User:     GetEnv("DB_USER", "root"),
+		Password: GetEnv("DB_PASSWORD", ""),
+		Database: GetEnv("DB_NAME", "healthcare_saver"),
+		SSL:      GetEnv("DB_SSL", "false"),
+	}
+
+	if config.Password == "" {
+		return nil, fmt.Errorf("DB_PASSWORD environment variable is required")
+	}
+
+	return config, nil
+}
+
+// DSN builds the MySQL connection string
+func (c *DBConfig) DSN() string {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&multiStatements=true",
+		c.User, c.Password, c.Host, c.Port, c.Database)
+
+	if c.SSL == "true" {
+		dsn += "&tls=true"
+	}
+
+	return dsn
+}
+
+// Open connects to the database and configures the connection pool
+func (c *DBConfig) Open() (*sql.DB, error) {
+	// Connect to database
+	db, err := sql.Open("mysql", c.DSN())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// Test connection
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %v", err)
+	}
+
+	// Set connection pool settings
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(25)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	return db, nil
+}
+
+// GetEnv gets environment variable with fallback
+func GetEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
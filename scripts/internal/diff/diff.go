@@ -0,0 +1,423 @@
+// Package diff compares two versions of a payer's rates, such as successive
+// monthly files, by natural key: the rate key of the ingestion tool, which
+// covers the service, provider references and price attributes but not the
+// rate or expiration date. Only the old version is held in memory; the new
+// one is streamed through Diff.AddNew, which reports changes as it goes.
+package diff
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Kinds of changes
+const (
+	CodeAdded       = "code_added"
+	CodeRemoved     = "code_removed"
+	ProviderAdded   = "provider_added"
+	ProviderDropped = "provider_dropped"
+	RateAdded       = "rate_added"
+	RateRemoved     = "rate_removed"
+	RateIncreased   = "rate_increased"
+	RateDecreased   = "rate_decreased"
+)
+
+// Key is the natural key of a rate
+type Key [32]byte
+
+// Rate is a rate of one version
+type Rate struct {
+	Key                Key
+	BillingCodeType    string
+	BillingCode        string
+	BillingClass       string
+	NegotiatedType     string
+	ProviderReferences string
+	Rate               float64
+}
+
+// Change is an entry of the change log. Code changes only carry the code,
+// provider changes only the NPI.
+type Change struct {
+	Kind               string   `json:"kind"`
+	BillingCodeType    string   `json:"billingCodeType,omitempty"`
+	BillingCode        string   `json:"billingCode,omitempty"`
+	BillingClass       string   `json:"billingClass,omitempty"`
+	NegotiatedType     string   `json:"negotiatedType,omitempty"`
+	ProviderReferences string   `json:"providerReferences,omitempty"`
+	NPI                string   `json:"npi,omitempty"`
+	OldRate            *float64 `json:"oldRate,omitempty"`
+	NewRate            *float64 `json:"newRate,omitempty"`
+	PctChange          *float64 `json:"pctChange,omitempty"` // null when the old rate is 0
+}
+
+// Summary counts the changes between the versions
+type Summary struct {
+	OldRates         int     `json:"oldRates"`
+	NewRates         int     `json:"newRates"`
+	RatesAdded       int     `json:"ratesAdded"`
+	RatesRemoved     int     `json:"ratesRemoved"`
+	RatesIncreased   int     `json:"ratesIncreased"`
+	RatesDecreased   int     `json:"ratesDecreased"`
+	RatesUnchanged   int     `json:"ratesUnchanged"`
+	DuplicateKeys    int     `json:"duplicateKeys"` // rates of a version whose key was already seen, ignored
+	MedianPctChange  float64 `json:"medianPctChange"`
+	MaxIncreasePct   float64 `json:"maxIncreasePct"`
+	MaxDecreasePct   float64 `json:"maxDecreasePct"`
+	OldCodes         int     `json:"oldCodes"`
+	NewCodes         int     `json:"newCodes"`
+	CodesAdded       int     `json:"codesAdded"`
+	CodesRemoved     int     `json:"codesRemoved"`
+	OldProviders     int     `json:"oldProviders"`
+	NewProviders     int     `json:"newProviders"`
+	ProvidersAdded   int     `json:"providersAdded"`
+	ProvidersDropped int     `json:"providersDropped"`
+}
+
+// Thresholds trigger alerts; 0 disables a threshold
+type Thresholds struct {
+	RateChangePct       float64 // any rate changed by more than this percent
+	RemovedRatesPct     float64 // more than this percent of the old rates removed
+	RemovedCodesPct     float64 // more than this percent of the old codes removed
+	DroppedProvidersPct float64 // more than this percent of the old providers dropped
+}
+
+// Alert is a change that exceeded a threshold
+type Alert struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// code is a billing code of a version
+type code struct {
+	billingCodeType, billingCode string
+}
+
+// oldRate is a rate of the old version, with its code shared between rates
+type oldRate struct {
+	code               *code
+	billingClass       string
+	negotiatedType     string
+	providerReferences string
+	rate               float64
+	matched            bool
+}
+
+// Diff compares an old version, added first, with a new one
+type Diff struct {
+	thresholds Thresholds
+	emit       func(Change) error
+
+	old          map[Key]*oldRate
+	oldCodes     map[code]*code
+	newCodes     map[code]bool
+	oldProviders map[string]bool
+	newProviders map[string]bool
+	added        map[Key]bool
+
+	summary    Summary
+	pctChanges []float64
+	overLimit  int
+}
+
+// New creates a diff that reports each change to emit
+func New(thresholds Thresholds, emit func(Change) error) *Diff {
+	return &Diff{
+		thresholds:   thresholds,
+		emit:         emit,
+		old:          make(map[Key]*oldRate),
+		oldCodes:     make(map[code]*code),
+		newCodes:     make(map[code]bool),
+		oldProviders: make(map[string]bool),
+		newProviders: make(map[string]bool),
+		added:        make(map[Key]bool),
+	}
+}
+
+// AddOld adds a rate of the old version; of rates with the same key, the
+// first is kept
+func (d *Diff) AddOld(r Rate) {
+	if _, ok := d.old[r.Key]; ok {
+		d.summary.DuplicateKeys++
+		return
+	}
+	k := code{r.BillingCodeType, r.BillingCode}
+	c, ok := d.oldCodes[k]
+	if !ok {
+		c = &k
+		d.oldCodes[k] = c
+	}
+	d.old[r.Key] = &oldRate{
+		code:               c,
+		billingClass:       r.BillingClass,
+		negotiatedType:     r.NegotiatedType,
+		providerReferences: r.ProviderReferences,
+		rate:               r.Rate,
+	}
+	d.summary.OldRates++
+}
+
+// AddOldProvider adds an NPI of the old version
+func (d *Diff) AddOldProvider(npi string) {
+	d.oldProviders[npi] = true
+}
+
+// AddNewProvider adds an NPI of the new version
+func (d *Diff) AddNewProvider(npi string) {
+	d.newProviders[npi] = true
+}
+
+// AddNew compares a rate of the new version with the old one and reports it
+// if it was added or its rate changed by a cent or more
+func (d *Diff) AddNew(r Rate) error {
+	d.newCodes[code{r.BillingCodeType, r.BillingCode}] = true
+
+	old, ok := d.old[r.Key]
+	if !ok {
+		if d.added[r.Key] {
+			d.summary.DuplicateKeys++
+			return nil
+		}
+		d.added[r.Key] = true
+		d.summary.NewRates++
+		d.summary.RatesAdded++
+		return d.emit(rateChange(RateAdded, r.BillingCodeType, r.BillingCode, r.BillingClass, r.NegotiatedType, r.ProviderReferences, nil, &r.Rate))
+	}
+	if old.matched {
+		d.summary.DuplicateKeys++
+		return nil
+	}
+	old.matched = true
+	d.summary.NewRates++
+
+	if math.Abs(r.Rate-old.rate) < 0.005 {
+		d.summary.RatesUnchanged++
+		return nil
+	}
+	kind := RateIncreased
+	if r.Rate < old.rate {
+		kind = RateDecreased
+	}
+	change := rateChange(kind, r.BillingCodeType, r.BillingCode, r.BillingClass, r.NegotiatedType, r.ProviderReferences, &old.rate, &r.Rate)
+	d.countChange(change)
+	return d.emit(change)
+}
+
+// countChange adds a rate increase or decrease to the summary
+func (d *Diff) countChange(change Change) {
+	if change.Kind == RateIncreased {
+		d.summary.RatesIncreased++
+	} else {
+		d.summary.RatesDecreased++
+	}
+	if change.PctChange == nil {
+		return
+	}
+	pct := *change.PctChange
+	d.pctChanges = append(d.pctChanges, pct)
+	d.summary.MaxIncreasePct = math.Max(d.summary.MaxIncreasePct, pct)
+	d.summary.MaxDecreasePct = math.Min(d.summary.MaxDecreasePct, pct)
+	if d.thresholds.RateChangePct > 0 && math.Abs(pct) > d.thresholds.RateChangePct {
+		d.overLimit++
+	}
+}
+
+// rateChange builds the change of a rate
+func rateChange(kind, codeType, billingCode, billingClass, negotiatedType, providerReferences string, oldRate, newRate *float64) Change {
+	change := Change{
+		Kind:               kind,
+		BillingCodeType:    codeType,
+		BillingCode:        billingCode,
+		BillingClass:       billingClass,
+		NegotiatedType:     negotiatedType,
+		ProviderReferences: providerReferences,
+		OldRate:            oldRate,
+		NewRate:            newRate,
+	}
+	if oldRate != nil && newRate != nil && *oldRate != 0 {
+		pct := math.Round((*newRate-*oldRate) / *oldRate * 1000) / 10
+		change.PctChange = &pct
+	}
+	return change
+}
+
+// Finish reports the removed rates, the added and removed codes and the
+// added and dropped providers, and returns the summary and the alerts
+func (d *Diff) Finish() (Summary, []Alert, error) {
+	var removed []*oldRate
+	for _, old := range d.old {
+		if !old.matched {
+			removed = append(removed, old)
+		}
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		a, b := removed[i], removed[j]
+		if *a.code != *b.code {
+			return a.code.billingCodeType+"|"+a.code.billingCode < b.code.billingCodeType+"|"+b.code.billingCode
+		}
+		if a.billingClass != b.billingClass {
+			return a.billingClass < b.billingClass
+		}
+		return a.providerReferences < b.providerReferences
+	})
+	for _, old := range removed {
+		d.summary.RatesRemoved++
+		change := rateChange(RateRemoved, old.code.billingCodeType, old.code.billingCode, old.billingClass, old.negotiatedType, old.providerReferences, &old.rate, nil)
+		if err := d.emit(change); err != nil {
+			return d.summary, nil, err
+		}
+	}
+
+	for _, c := range sortedCodes(d.newCodes, func(c code) bool { return d.oldCodes[c] == nil }) {
+		d.summary.CodesAdded++
+		if err := d.emit(Change{Kind: CodeAdded, BillingCodeType: c.billingCodeType, BillingCode: c.billingCode}); err != nil {
+			return d.summary, nil, err
+		}
+	}
+	oldCodes := make(map[code]bool, len(d.oldCodes))
+	for c := range d.oldCodes {
+		oldCodes[c] = true
+	}
+	for _, c := range sortedCodes(oldCodes, func(c code) bool { return !d.newCodes[c] }) {
+		d.summary.CodesRemoved++
+		if err := d.emit(Change{Kind: CodeRemoved, BillingCodeType: c.billingCodeType, BillingCode: c.billingCode}); err != nil {
+			return d.summary, nil, err
+		}
+	}
+
+	for _, npi := range sortedKeys(d.newProviders, d.oldProviders) {
+		d.summary.ProvidersAdded++
+		if err := d.emit(Change{Kind: ProviderAdded, NPI: npi}); err != nil {
+			return d.summary, nil, err
+		}
+	}
+	for _, npi := range sortedKeys(d.oldProviders, d.newProviders) {
+		d.summary.ProvidersDropped++
+		if err := d.emit(Change{Kind: ProviderDropped, NPI: npi}); err != nil {
+			return d.summary, nil, err
+		}
+	}
+
+	d.summary.OldCodes, d.summary.NewCodes = len(d.oldCodes), len(d.newCodes)
+	d.summary.OldProviders, d.summary.NewProviders = len(d.oldProviders), len(d.newProviders)
+	if len(d.pctChanges) > 0 {
+		sort.Float64s(d.pctChanges)
+		n := len(d.pctChanges)
+		median := d.pctChanges[n/2]
+		if n%2 == 0 {
+			median = (d.pctChanges[n/2-1] + d.pctChanges[n/2]) / 2
+		}
+		d.summary.MedianPctChange = math.Round(median*10) / 10
+	}
+	return d.summary, d.alerts(), nil
+}
+
+// alerts returns the changes over the thresholds
+func (d *Diff) alerts() []Alert {
+	s, t := d.summary, d.thresholds
+	var alerts []Alert
+	if d.overLimit > 0 {
+		message := fmt.Sprintf("%d rates changed by more than %g%%", d.overLimit, t.RateChangePct)
+		if s.MaxIncreasePct > t.RateChangePct {
+			message += fmt.Sprintf(", largest increase %+.1f%%", s.MaxIncreasePct)
+		}
+		if -s.MaxDecreasePct > t.RateChangePct {
+			message += fmt.Sprintf(", largest decrease %+.1f%%", s.MaxDecreasePct)
+		}
+		alerts = append(alerts, Alert{Kind: "rate_change", Message: message})
+	}
+	if pct := percent(s.RatesRemoved, s.OldRates); t.RemovedRatesPct > 0 && pct > t.RemovedRatesPct {
+		alerts = append(alerts, Alert{Kind: "rates_removed", Message: fmt.Sprintf(
+			"%d of %d rates (%.1f%%) were removed", s.RatesRemoved, s.OldRates, pct)})
+	}
+	if pct := percent(s.CodesRemoved, s.OldCodes); t.RemovedCodesPct > 0 && pct > t.RemovedCodesPct {
+		alerts = append(alerts, Alert{Kind: "codes_removed", Message: fmt.Sprintf(
+			"%d of %d billing codes (%.1f%%) were removed", s.CodesRemoved, s.OldCodes, pct)})
+	}
+	if pct := percent(s.ProvidersDropped, s.OldProviders); t.DroppedProvidersPct > 0 && pct > t.DroppedProvidersPct {
+		alerts = append(alerts, Alert{Kind: "providers_dropped", Message: fmt.Sprintf(
+			"%d of %d providers (%.1f%%) were dropped", s.ProvidersDropped, s.OldProviders, pct)})
+	}
+	return alerts
+}
+
+// percent returns part as a percent of total, 0 for an empty total
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
+
+// sortedCodes returns the codes for which keep is true, in order
+func sortedCodes(codes map[code]bool, keep func(code) bool) []code {
+	var result []code
+	for c := range codes {
+		if keep(c) {
+			result = append(result, c)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].billingCodeType != result[j].billingCodeType {
+			return result[i].billingCodeType < result[j].billingCodeType
+		}
+		return result[i].billingCode < result[j].billingCode
+	})
+	return result
+}
+
+// sortedKeys returns the keys of a that are not in b, in order
+func sortedKeys(a, b map[string]bool) []string {
+	var result []string
+	for k := range a {
+		if !b[k] {
+			result = append(result, k)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// webhookTimeout bounds the alert webhook request
+const webhookTimeout = 10 * time.Second
+
+// PostAlerts sends the alerts of a diff to a webhook as JSON. The text field
+// makes the payload a valid Slack or Teams incoming webhook message.
+func PostAlerts(ctx context.Context, url, title string, summary Summary, alerts []Alert) error {
+	text := title
+	for _, alert := range alerts {
+		text += "\n• " + alert.Message
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"text":    text,
+		"alerts":  alerts,
+		"summary": summary,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode alerts: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alerts: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package diff
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// rate returns a rate whose natural key is k
+func rate(k byte, billingCode, billingClass string, value float64) Rate {
+	return Rate{Key: Key{k}, BillingCodeType: "CPT", BillingCode: billingCode, BillingClass: billingClass,
+		NegotiatedType: "negotiated", ProviderReferences: "[1]", Rate: value}
+}
+
+// record returns a diff that appends its changes to changes
+func record(thresholds Thresholds, changes *[]Change) *Diff {
+	return New(thresholds, func(c Change) error {
+		*changes = append(*changes, c)
+		return nil
+	})
+}
+
+func TestDiff(t *testing.T) {
+	var changes []Change
+	d := record(Thresholds{RateChangePct: 20, RemovedRatesPct: 30, RemovedCodesPct: 60, DroppedProvidersPct: 40}, &changes)
+
+	d.AddOld(rate(1, "99213", "professional", 100))
+	d.AddOld(rate(2, "99213", "institutional", 200))
+	d.AddOld(rate(3, "70551", "professional", 1000))
+	d.AddOld(rate(1, "99213", "professional", 999)) // duplicate key, ignored
+	d.AddOldProvider("1111111111")
+	d.AddOldProvider("2222222222")
+
+	for _, r := range []Rate{
+		rate(1, "99213", "professional", 100.004), // under a cent
+		rate(2, "99213", "institutional", 250),
+		rate(4, "99214", "professional", 50),
+		rate(4, "99214", "professional", 60), // duplicate key, ignored
+	} {
+		if err := d.AddNew(r); err != nil {
+			t.Fatalf("AddNew: %v", err)
+		}
+	}
+	d.AddNewProvider("2222222222")
+	d.AddNewProvider("3333333333")
+
+	summary, alerts, err := d.Finish()
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+
+	var kinds []string
+	for _, c := range changes {
+		kinds = append(kinds, c.Kind+" "+c.BillingCode+c.NPI)
+	}
+	wantKinds := []string{
+		"rate_increased 99213", "rate_added 99214", "rate_removed 70551",
+		"code_added 99214", "code_removed 70551", "provider_added 3333333333", "provider_dropped 1111111111",
+	}
+	if !reflect.DeepEqual(kinds, wantKinds) {
+		t.Errorf("changes = %v, want %v", kinds, wantKinds)
+	}
+	if c := changes[0]; *c.OldRate != 200 || *c.NewRate != 250 || *c.PctChange != 25 {
+		t.Errorf("increase = %v to %v (%v%%), want 200 to 250 (25%%)", *c.OldRate, *c.NewRate, *c.PctChange)
+	}
+	if c := changes[1]; c.OldRate != nil || *c.NewRate != 50 || c.PctChange != nil {
+		t.Errorf("added rate = %+v, want only the new rate", c)
+	}
+
+	want := Summary{
+		OldRates: 3, NewRates: 3, RatesAdded: 1, RatesRemoved: 1, RatesIncreased: 1, RatesUnchanged: 1,
+		DuplicateKeys: 2, MedianPctChange: 25, MaxIncreasePct: 25,
+		OldCodes: 2, NewCodes: 2, CodesAdded: 1, CodesRemoved: 1,
+		OldProviders: 2, NewProviders: 2, ProvidersAdded: 1, ProvidersDropped: 1,
+	}
+	if summary != want {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+
+	// Half the codes removed is under the 60% threshold
+	wantAlerts := []Alert{
+		{Kind: "rate_change", Message: "1 rates changed by more than 20%, largest increase +25.0%"},
+		{Kind: "rates_removed", Message: "1 of 3 rates (33.3%) were removed"},
+		{Kind: "providers_dropped", Message: "1 of 2 providers (50.0%) were dropped"},
+	}
+	if !reflect.DeepEqual(alerts, wantAlerts) {
+		t.Errorf("alerts = %+v, want %+v", alerts, wantAlerts)
+	}
+}
+
+func TestDiffPctChanges(t *testing.T) {
+	var changes []Change
+	d := record(Thresholds{}, &changes)
+
+	d.AddOld(rate(1, "99213", "professional", 0))
+	d.AddOld(rate(2, "99213", "institutional", 100))
+	d.AddOld(rate(3, "99214", "professional", 100))
+	for _, r := range []Rate{
+		rate(1, "99213", "professional", 10),
+		rate(2, "99213", "institutional", 80),
+		rate(3, "99214", "professional", 130),
+	} {
+		if err := d.AddNew(r); err != nil {
+			t.Fatalf("AddNew: %v", err)
+		}
+	}
+
+	summary, alerts, err := d.Finish()
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if changes[0].PctChange != nil {
+		t.Errorf("change from a zero rate has percent change %v", *changes[0].PctChange)
+	}
+	// The median of -20% and +30%; the change from 0 has no percent
+	if summary.RatesIncreased != 2 || summary.RatesDecreased != 1 || summary.MedianPctChange != 5 ||
+		summary.MaxIncreasePct != 30 || summary.MaxDecreasePct != -20 {
+		t.Errorf("summary = %+v, want 2 increases, 1 decrease, median 5%%, from -20%% to +30%%", summary)
+	}
+	if len(alerts) != 0 {
+		t.Errorf("alerts without thresholds: %+v", alerts)
+	}
+}
+
+func TestDiffEmitError(t *testing.T) {
+	failed := errors.New("disk full")
+	d := New(Thresholds{}, func(Change) error { return failed })
+
+	d.AddOld(rate(1, "99213", "professional", 100))
+	if err := d.AddNew(rate(2, "99213", "professional", 100)); err != failed {
+		t.Errorf("AddNew = %v, want the emit error", err)
+	}
+	if _, _, err := d.Finish(); err != failed {
+		t.Errorf("Finish = %v, want the emit error", err)
+	}
+}
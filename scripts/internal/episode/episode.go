@@ -0,0 +1,157 @@
+// Package episode defines episodes of care, the bundles patients shop for.
+// A knee replacement is not one billing code but a facility stay, the
+// surgeon and the anesthesiologist, each billed separately; an episode names
+// the primary code that identifies the service at a provider and the
+// ancillary codes that come with it, each in one billing class. Definitions
+// are kept in YAML:
+//
+//	episodes:
+//	  - id: knee-replacement
+//	    name: Knee replacement
+//	    category: Inpatient
+//	    primary: {code: "470", code_type: MS-DRG, billing_class: institutional}
+//	    ancillaries:
+//	      - {code: "27447", billing_class: professional, description: Surgeon}
+//	      - {code: "01402", billing_class: professional, description: Anesthesia}
+package episode
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultCodeType is the billing code type of components without one
+const DefaultCodeType = "CPT"
+
+// Component is a billing code of an episode
+type Component struct {
+	BillingCodeType string `yaml:"code_type" json:"billingCodeType"`
+	BillingCode     string `yaml:"code" json:"billingCode"`
+	BillingClass    string `yaml:"billing_class" json:"billingClass" enum:"BillingClass"`
+	Description     string `yaml:"description" json:"description"`
+	Units           int    `yaml:"units" json:"units"` // defaults to 1
+	// Optional components count when priced but do not make an episode
+	// incomplete when not, like sedation an endoscopist may give
+	Optional bool `yaml:"optional" json:"optional"`
+}
+
+// String identifies the component in messages
+func (c Component) String() string {
+	return c.BillingCodeType + " " + c.BillingCode + " (" + c.BillingClass + ")"
+}
+
+// Episode is a shoppable episode of care
+type Episode struct {
+	ID          string      `yaml:"id" json:"id"`
+	Name        string      `yaml:"name" json:"name"`
+	Category    string      `yaml:"category" json:"category"`
+	Description string      `yaml:"description" json:"description"`
+	Primary     Component   `yaml:"primary" json:"primary"`
+	Ancillaries []Component `yaml:"ancillaries" json:"ancillaries"`
+}
+
+// Components returns the primary component followed by the ancillaries
+func (e Episode) Components() []Component {
+	return append([]Component{e.Primary}, e.Ancillaries...)
+}
+
+// file is the layout of a definitions file
+type file struct {
+	Episodes []Episode `yaml:"episodes"`
+}
+
+// Load reads and validates the episode definitions of a YAML file
+func Load(path string) ([]Episode, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read episodes: %v", err)
+	}
+	episodes, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid episodes %s: %v", path, err)
+	}
+	return episodes, nil
+}
+
+// Parse decodes and validates episode definitions, filling in the default
+// code type and units
+func Parse(data []byte) ([]Episode, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	var f file
+	if err := decoder.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(f.Episodes) == 0 {
+		return nil, fmt.Errorf("no episodes defined")
+	}
+
+	seen := make(map[string]bool, len(f.Episodes))
+	for i := range f.Episodes {
+		e := &f.Episodes[i]
+		if e.ID == "" {
+			return nil, fmt.Errorf("episode %d has no id", i+1)
+		}
+		if seen[e.ID] {
+			return nil, fmt.Errorf("duplicate episode id %q", e.ID)
+		}
+		seen[e.ID] = true
+		if e.Name == "" {
+			e.Name = e.ID
+		}
+
+		if err := normalize(&e.Primary); err != nil {
+			return nil, fmt.Errorf("episode %s: primary: %v", e.ID, err)
+		}
+		if e.Primary.Optional {
+			return nil, fmt.Errorf("episode %s: the primary component cannot be optional", e.ID)
+		}
+		if e.Ancillaries == nil {
+			e.Ancillaries = []Component{}
+		}
+		for j := range e.Ancillaries {
+			if err := normalize(&e.Ancillaries[j]); err != nil {
+				return nil, fmt.Errorf("episode %s: ancillary %d: %v", e.ID, j+1, err)
+			}
+		}
+	}
+	return f.Episodes, nil
+}
+
+// normalize validates a component and fills in its defaults
+func normalize(c *Component) error {
+	c.BillingCode = strings.ToUpper(strings.TrimSpace(c.BillingCode))
+	c.BillingCodeType = strings.ToUpper(strings.TrimSpace(c.BillingCodeType))
+	if c.BillingCode == "" {
+		return fmt.Errorf("no code")
+	}
+	if c.BillingCodeType == "" {
+		c.BillingCodeType = DefaultCodeType
+	}
+	if c.BillingClass != "professional" && c.BillingClass != "institutional" {
+		return fmt.Errorf("%s: billing_class must be professional or institutional", c.BillingCode)
+	}
+	if c.Units < 0 {
+		return fmt.Errorf("%s: units must not be negative", c.BillingCode)
+	}
+	if c.Units == 0 {
+		c.Units = 1
+	}
+	return nil
+}
+
+// Find returns the episode with an id
+func Find(episodes []Episode, id string) (Episode, bool) {
+	for _, e := range episodes {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return Episode{}, false
+}
@@ -0,0 +1,90 @@
+package episode
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	episodes, err := Parse([]byte(`
+episodes:
+  - id: knee-replacement
+    name: Knee replacement
+    primary: {code: "470", code_type: ms-drg, billing_class: institutional}
+    ancillaries:
+      - {code: " 27447 ", billing_class: professional, description: Surgeon}
+      - {code: "01402", billing_class: professional, units: 2, optional: true}
+  - id: mri-brain
+    primary: {code: "70551", billing_class: professional}
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []Episode{
+		{
+			ID: "knee-replacement", Name: "Knee replacement",
+			Primary: Component{BillingCodeType: "MS-DRG", BillingCode: "470", BillingClass: "institutional", Units: 1},
+			Ancillaries: []Component{
+				{BillingCodeType: "CPT", BillingCode: "27447", BillingClass: "professional", Description: "Surgeon", Units: 1},
+				{BillingCodeType: "CPT", BillingCode: "01402", BillingClass: "professional", Units: 2, Optional: true},
+			},
+		},
+		// The name defaults to the id
+		{
+			ID: "mri-brain", Name: "mri-brain",
+			Primary:     Component{BillingCodeType: "CPT", BillingCode: "70551", BillingClass: "professional", Units: 1},
+			Ancillaries: []Component{},
+		},
+	}
+	if !reflect.DeepEqual(episodes, want) {
+		t.Errorf("episodes = %+v, want %+v", episodes, want)
+	}
+	if c := episodes[0].Components(); len(c) != 3 || c[0].BillingCode != "470" {
+		t.Errorf("components = %v, want the primary then both ancillaries", c)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name, yaml, err string
+	}{
+		{"empty file", ``, "no episodes defined"},
+		{"no episodes", `episodes: []`, "no episodes defined"},
+		{"unknown field", "episodes:\n  - id: a\n    primary: {code: \"1\", billing_class: professional, modifier: \"26\"}",
+			"field modifier not found"},
+		{"missing id", "episodes:\n  - primary: {code: \"1\", billing_class: professional}", "episode 1 has no id"},
+		{"duplicate id", "episodes:\n  - {id: a, primary: {code: \"1\", billing_class: professional}}\n" +
+			"  - {id: a, primary: {code: \"2\", billing_class: professional}}", `duplicate episode id "a"`},
+		{"missing code", "episodes:\n  - {id: a, primary: {code: \" \", billing_class: professional}}",
+			"episode a: primary: no code"},
+		{"unknown billing class", "episodes:\n  - {id: a, primary: {code: \"1\", billing_class: facility}}",
+			"episode a: primary: 1: billing_class must be professional or institutional"},
+		{"optional primary", "episodes:\n  - {id: a, primary: {code: \"1\", billing_class: professional, optional: true}}",
+			"episode a: the primary component cannot be optional"},
+		{"negative units", "episodes:\n  - id: a\n    primary: {code: \"1\", billing_class: professional}\n" +
+			"    ancillaries: [{code: \"2\", billing_class: professional, units: -1}]",
+			"episode a: ancillary 1: 2: units must not be negative"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.yaml))
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Parse = %v, want an error containing %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestLoadBundledDefinitions(t *testing.T) {
+	episodes, err := Load("../../data/episodes.yaml")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, ok := Find(episodes, "knee-replacement"); !ok {
+		t.Error("bundled definitions lack the knee replacement")
+	}
+	if _, ok := Find(episodes, "unknown"); ok {
+		t.Error("Find returned an unknown episode")
+	}
+}
@@ -0,0 +1,83 @@
+package gql
+
+import (
+	"encoding/json"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// defaultListSize is the assumed length of list fields without a first
+// argument, such as the provider groups of a rate
+const defaultListSize = 10
+
+// fieldCosts are the costs of fields backed by expensive queries; every
+// other field costs 1
+var fieldCosts = map[string]int{
+	"Query.services":             5,
+	"Query.reportingEntities":    5,
+	"ReportingEntity.compliance": 50,
+}
+
+// complexity estimates the cost of an operation before it runs: a field
+// costs its weight plus the cost of its selections, times the number of items
+// it may return when it is a list
+func complexity(doc *ast.QueryDocument, operationName string, variables map[string]interface{}) int {
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		return 0
+	}
+	return selectionCost(op.SelectionSet, variables)
+}
+
+func selectionCost(set ast.SelectionSet, variables map[string]interface{}) int {
+	total := 0
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *ast.Field:
+			total += fieldCost(s, variables)
+		case *ast.InlineFragment:
+			total += selectionCost(s.SelectionSet, variables)
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				total += selectionCost(s.Definition.SelectionSet, variables)
+			}
+		}
+	}
+	return total
+}
+
+func fieldCost(field *ast.Field, variables map[string]interface{}) int {
+	if field.Definition == nil || field.ObjectDefinition == nil {
+		return 0
+	}
+
+	cost, ok := fieldCosts[field.ObjectDefinition.Name+"."+field.Name]
+	if !ok {
+		cost = 1
+	}
+
+	children := selectionCost(field.SelectionSet, variables)
+	if field.Definition.Type.Elem != nil {
+		size := defaultListSize
+		if first, ok := intArgument(field.ArgumentMap(variables)["first"]); ok {
+			size = max(first, 0)
+		}
+		children *= size
+	}
+	return cost + children
+}
+
+// intArgument converts an argument value, which is int64 when written in the
+// query and a JSON number when passed as a variable
+func intArgument(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return int(n), err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,66 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestComplexity(t *testing.T) {
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: schemaSDL})
+	if err != nil {
+		t.Fatalf("LoadSchema: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		query         string
+		operationName string
+		variables     map[string]interface{}
+		want          int
+	}{
+		// 5 services of 1 + 1 + (1 + 3 rates of 1 + (1 + 10 groups of 2))
+		{"nested lists", `{ services(first: 5) { id name rates(first: 3) { id providerGroups { id npis } } } }`, "", nil, 350},
+		{"argument defaults", `{ services { id } }`, "", nil, 25},
+		{"weighted field in a list without first", `{ reportingEntities { compliance { issues } } }`, "", nil, 515},
+		{"negative first", `{ service(id: 1) { rates(first: -1) { id } } }`, "", nil, 2},
+		{"integer variable", `query Q($n: Int) { services(first: $n) { id } }`, "Q", map[string]interface{}{"n": float64(40)}, 45},
+		{"JSON number variable", `query Q($n: Int) { services(first: $n) { id } }`, "Q", map[string]interface{}{"n": json.Number("40")}, 45},
+		{"fragment spread", `{ service(id: 1) { ...R } } fragment R on InsuranceService { rates { id } }`, "", nil, 22},
+		{"inline fragment", `{ service(id: 1) { ... on InsuranceService { rates(first: 2) { id } } } }`, "", nil, 4},
+		{"named operation", `query A { services { id } } query B { provider(npi: "1234567890") { id } }`, "B", nil, 2},
+		{"unknown operation", `query A { services { id } }`, "B", nil, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			doc, errs := gqlparser.LoadQuery(schema, tc.query)
+			if len(errs) > 0 {
+				t.Fatalf("LoadQuery: %v", errs)
+			}
+			if got := complexity(doc, tc.operationName, tc.variables); got != tc.want {
+				t.Errorf("complexity = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExecRejectsComplexQueries(t *testing.T) {
+	s := NewSchema(nil)
+
+	// 5 + 100 services of (1 + 100 rates of 1)
+	response, rejected := s.Exec(context.Background(), Request{
+		Query: `{ services(first: 100) { rates(first: 100) { id } } }`,
+	})
+	if !rejected || len(response.Errors) != 1 ||
+		!strings.HasPrefix(response.Errors[0].Message, "query complexity 10105 exceeds the limit of 5000") {
+		t.Errorf("Exec = rejected %v, %+v, want rejected for its complexity", rejected, response.Errors)
+	}
+
+	response, rejected = s.Exec(context.Background(), Request{Query: `{ services { unknownField } }`})
+	if !rejected || len(response.Errors) == 0 {
+		t.Errorf("Exec of an invalid query = rejected %v, %+v, want rejected", rejected, response.Errors)
+	}
+}
@@ -0,0 +1,88 @@
+// Package gql serves the ingested data over GraphQL. Resolvers load each
+// level of a query in batches (see loader) and operations are priced before
+// they run, so a single request cannot fan out into unbounded work.
+package gql
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"healthcare-saver-ingest/internal/store"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// MaxComplexity is the highest estimated cost of an accepted operation
+const MaxComplexity = 5000
+
+// MaxDepth is the deepest selection nesting of an accepted operation
+const MaxDepth = 10
+
+// maxParallelism bounds the resolvers of one request running concurrently
+const maxParallelism = 10
+
+// Request is a GraphQL request as posted by clients
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL response
+type Response = graphql.Response
+
+// Schema executes GraphQL requests against a store
+type Schema struct {
+	store    *store.Store
+	exec     *graphql.Schema
+	analysis *ast.Schema // the same schema, for the complexity analysis
+}
+
+// NewSchema parses the embedded schema; it panics if the schema does not
+// match the resolvers
+func NewSchema(st *store.Store) *Schema {
+	analysis, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: schemaSDL})
+	if err != nil {
+		panic(fmt.Sprintf("invalid GraphQL schema: %v", err))
+	}
+	return &Schema{
+		store: st,
+		exec: graphql.MustParseSchema(schemaSDL, &rootResolver{store: st},
+			graphql.MaxDepth(MaxDepth),
+			graphql.MaxParallelism(maxParallelism),
+		),
+		analysis: analysis,
+	}
+}
+
+// Exec runs a request. Invalid requests and requests over MaxComplexity are
+// rejected with rejected set and nothing executed.
+func (s *Schema) Exec(ctx context.Context, req Request) (response *Response, rejected bool) {
+	doc, errs := gqlparser.LoadQuery(s.analysis, req.Query)
+	if len(errs) > 0 {
+		response := &Response{}
+		for _, err := range errs {
+			queryErr := &errors.QueryError{Message: err.Message}
+			for _, location := range err.Locations {
+				queryErr.Locations = append(queryErr.Locations, errors.Location{Line: location.Line, Column: location.Column})
+			}
+			response.Errors = append(response.Errors, queryErr)
+		}
+		return response, true
+	}
+	if cost := complexity(doc, req.OperationName, req.Variables); cost > MaxComplexity {
+		return &Response{Errors: []*errors.QueryError{{
+			Message: fmt.Sprintf("query complexity %d exceeds the limit of %d, request fewer items with first", cost, MaxComplexity),
+		}}}, true
+	}
+
+	ctx = context.WithValue(ctx, loadersKey{}, newLoaders(s.store))
+	return s.exec.Exec(ctx, req.Query, req.OperationName, req.Variables), false
+}
@@ -0,0 +1,248 @@
+package gql
+
+import (
+	"context"
+	"sync"
+
+	"healthcare-saver-ingest/internal/store"
+)
+
+// loader batches the loads of one kind of record within a request. Resolvers
+// returning a list announce the keys its items will load with expect; the
+// first load then fetches every announced key still missing in one query, so
+// the siblings resolved after it (or concurrently, waiting on the lock) find
+// their record already loaded. Each level of a query costs one query instead
+// of one per parent.
+type loader[K comparable, V any] struct {
+	mu      sync.Mutex
+	fetch   func(ctx context.Context, keys []K) (map[K]V, error)
+	pending map[K]bool
+	loaded  map[K]bool
+	values  map[K]V
+}
+
+func newLoader[K comparable, V any](fetch func(context.Context, []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		pending: make(map[K]bool),
+		loaded:  make(map[K]bool),
+		values:  make(map[K]V),
+	}
+}
+
+// expect announces keys that are about to be loaded
+func (l *loader[K, V]) expect(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if !l.loaded[key] {
+			l.pending[key] = true
+		}
+	}
+}
+
+// prime stores values that were loaded by other means
+func (l *loader[K, V]) prime(values map[K]V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, value := range values {
+		l.values[key] = value
+		l.loaded[key] = true
+		delete(l.pending, key)
+	}
+}
+
+// load returns the value of key, fetching it together with all pending keys.
+// ok is false when the store has no value for key.
+func (l *loader[K, V]) load(ctx context.Context, key K) (value V, ok bool, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.loaded[key] {
+		l.pending[key] = true
+		keys := make([]K, 0, len(l.pending))
+		for k := range l.pending {
+			keys = append(keys, k)
+		}
+
+		values, err := l.fetch(ctx, keys)
+		if err != nil {
+			return value, false, err
+		}
+		for _, k := range keys {
+			if v, found := values[k]; found {
+				l.values[k] = v
+			}
+			l.loaded[k] = true
+		}
+		clear(l.pending)
+	}
+
+	value, ok = l.values[key]
+	return value, ok, nil
+}
+
+// loaderSet is a loader per argument set of a field, such as the rates of
+// services with a given billing class. Announced keys apply to every set.
+type loaderSet[K comparable, A comparable, V any] struct {
+	mu      sync.Mutex
+	fetch   func(ctx context.Context, args A, keys []K) (map[K]V, error)
+	keys    []K
+	loaders map[A]*loader[K, V]
+}
+
+func newLoaderSet[K comparable, A comparable, V any](fetch func(context.Context, A, []K) (map[K]V, error)) *loaderSet[K, A, V] {
+	return &loaderSet[K, A, V]{fetch: fetch, loaders: make(map[A]*loader[K, V])}
+}
+
+// expect announces keys that are about to be loaded with any arguments
+func (s *loaderSet[K, A, V]) expect(keys ...K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, keys...)
+	for _, l := range s.loaders {
+		l.expect(keys...)
+	}
+}
+
+// load returns the value of key for the given arguments
+func (s *loaderSet[K, A, V]) load(ctx context.Context, args A, key K) (V, bool, error) {
+	s.mu.Lock()
+	l, ok := s.loaders[args]
+	if !ok {
+		l = newLoader(func(ctx context.Context, keys []K) (map[K]V, error) {
+			return s.fetch(ctx, args, keys)
+		})
+		l.expect(s.keys...)
+		s.loaders[args] = l
+	}
+	s.mu.Unlock()
+	return l.load(ctx, key)
+}
+
+// rateArgs are the arguments of InsuranceService.rates
+type rateArgs struct {
+	first  int
+	filter store.RateListFilter
+}
+
+// loaders are the loaders of one request
+type loaders struct {
+	services   *loader[int64, store.Service]
+	runs       *loader[int64, store.Run]
+	entities   *loader[string, store.ReportingEntity]
+	compliance *loader[string, store.Compliance]
+	rates      *loaderSet[int64, rateArgs, []store.Rate]
+	prices     *loaderSet[int64, int, []store.Price]
+	groups     *loader[int64, []store.ProviderGroupRef]
+	members    *loader[store.ProviderGroupRef, []store.ProviderGroupMember]
+	providers  *loader[string, store.Provider]
+}
+
+// newLoaders creates the loaders of a request. Loaders whose values lead to
+// another level announce its keys as soon as they fetch, e.g. the rates of
+// all services announce their provider groups, so the next level is batched
+// across every parent and not just across the items of one list.
+func newLoaders(st *store.Store) *loaders {
+	l := &loaders{}
+
+	l.services = newLoader(st.ServicesByID)
+	l.runs = newLoader(func(ctx context.Context, ids []int64) (map[int64]store.Run, error) {
+		runs, err := st.RunsByID(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, run := range runs {
+			if run.ReportingEntityName != "" {
+				l.entities.expect(run.ReportingEntityName)
+			}
+		}
+		return runs, nil
+	})
+	l.entities = newLoader(func(ctx context.Context, names []string) (map[string]store.ReportingEntity, error) {
+		list, err := st.ReportingEntities(ctx, names)
+		if err != nil {
+			return nil, err
+		}
+		entities := make(map[string]store.ReportingEntity, len(list))
+		for _, entity := range list {
+			entities[entity.Name] = entity
+			l.compliance.expect(entity.Name)
+		}
+		return entities, nil
+	})
+	l.compliance = newLoader(st.ComplianceByEntity)
+	l.rates = newLoaderSet(func(ctx context.Context, args rateArgs, ids []int64) (map[int64][]store.Rate, error) {
+		rates, err := st.RatesByService(ctx, ids, args.filter, args.first)
+		if err != nil {
+			return nil, err
+		}
+		for _, list := range rates {
+			for _, rate := range list {
+				l.groups.expect(rate.ID)
+			}
+		}
+		return rates, nil
+	})
+	l.prices = newLoaderSet(func(ctx context.Context, first int, ids []int64) (map[int64][]store.Price, error) {
+		prices, err := st.PricesByService(ctx, ids, first)
+		if err != nil {
+			return nil, err
+		}
+		for _, list := range prices {
+			for _, price := range list {
+				l.expectGroups(priceGroups(price))
+			}
+		}
+		return prices, nil
+	})
+	l.groups = newLoader(func(ctx context.Context, ids []int64) (map[int64][]store.ProviderGroupRef, error) {
+		groups, err := st.ProviderGroupsByRate(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, refs := range groups {
+			l.expectGroups(refs)
+		}
+		return groups, nil
+	})
+	l.members = newLoader(func(ctx context.Context, refs []store.ProviderGroupRef) (map[store.ProviderGroupRef][]store.ProviderGroupMember, error) {
+		members, err := st.ProviderGroupMembers(ctx, refs)
+		if err != nil {
+			return nil, err
+		}
+		for _, list := range members {
+			for _, member := range list {
+				l.providers.expect(member.NPI)
+			}
+		}
+		return members, nil
+	})
+	l.providers = newLoader(st.ProvidersByNPI)
+
+	return l
+}
+
+// expectGroups announces the members and runs of provider groups
+func (l *loaders) expectGroups(refs []store.ProviderGroupRef) {
+	for _, ref := range refs {
+		l.members.expect(ref)
+		l.runs.expect(ref.RunID)
+	}
+}
+
+// priceGroups returns the provider groups of a price
+func priceGroups(price store.Price) []store.ProviderGroupRef {
+	refs := make([]store.ProviderGroupRef, len(price.ProviderGroupIDs))
+	for i, id := range price.ProviderGroupIDs {
+		refs[i] = store.ProviderGroupRef{RunID: price.RunID, ProviderGroupID: id}
+	}
+	return refs
+}
+
+type loadersKey struct{}
+
+// loadersFrom returns the loaders of the request of ctx
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,499 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"healthcare-saver-ingest/internal/store"
+)
+
+// MaxFirst is the largest page size of a list field
+const MaxFirst = 100
+
+// staleAfterDays is the age after which a reporting entity's data misses the
+// monthly update requirement of the Transparency in Coverage rule
+const staleAfterDays = 31
+
+// checkFirst validates the page size argument of a list field
+func checkFirst(first int32) error {
+	if first < 0 || first > MaxFirst {
+		return fmt.Errorf("first must be between 0 and %d", MaxFirst)
+	}
+	return nil
+}
+
+// parseID parses a numeric ID argument
+func parseID(id graphql.ID) (int64, error) {
+	value, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", id)
+	}
+	return value, nil
+}
+
+func formatID(id int64) graphql.ID {
+	return graphql.ID(strconv.FormatInt(id, 10))
+}
+
+// rootResolver resolves Query
+type rootResolver struct {
+	store *store.Store
+}
+
+// serviceResolvers wraps services, announcing the keys their fields load
+func serviceResolvers(ctx context.Context, services []store.Service) []*serviceResolver {
+	l := loadersFrom(ctx)
+	resolvers := make([]*serviceResolver, len(services))
+	loaded := make(map[int64]store.Service, len(services))
+	for i, service := range services {
+		resolvers[i] = &serviceResolver{service}
+		loaded[service.ID] = service
+		l.rates.expect(service.ID)
+		l.prices.expect(service.ID)
+		if service.RunID != nil {
+			l.runs.expect(*service.RunID)
+		}
+	}
+	l.services.prime(loaded)
+	return resolvers
+}
+
+func (r *rootResolver) Services(ctx context.Context, args struct {
+	Query           *string
+	BillingCodeType *string
+	BillingCode     *string
+	First           int32
+	Offset          int32
+}) ([]*serviceResolver, error) {
+	if err := checkFirst(args.First); err != nil {
+		return nil, err
+	}
+	if args.Offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative")
+	}
+
+	var filter store.ServiceFilter
+	if args.Query != nil {
+		filter.Query = *args.Query
+	}
+	if args.BillingCodeType != nil {
+		filter.BillingCodeType = *args.BillingCodeType
+	}
+	if args.BillingCode != nil {
+		filter.BillingCode = *args.BillingCode
+	}
+
+	services, err := r.store.Services(ctx, filter, int(args.First), int(args.Offset))
+	if err != nil {
+		return nil, err
+	}
+	return serviceResolvers(ctx, services), nil
+}
+
+func (r *rootResolver) Service(ctx context.Context, args struct{ ID graphql.ID }) (*serviceResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	service, ok, err := loadersFrom(ctx).services.load(ctx, id)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return serviceResolvers(ctx, []store.Service{service})[0], nil
+}
+
+func (r *rootResolver) ReportingEntities(ctx context.Context) ([]*entityResolver, error) {
+	entities, err := r.store.ReportingEntities(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return entityResolvers(ctx, entities), nil
+}
+
+func (r *rootResolver) ReportingEntity(ctx context.Context, args struct{ Name string }) (*entityResolver, error) {
+	entity, ok, err := loadersFrom(ctx).entities.load(ctx, args.Name)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return entityResolvers(ctx, []store.ReportingEntity{entity})[0], nil
+}
+
+func (r *rootResolver) ProviderGroup(ctx context.Context, args struct {
+	RunID           graphql.ID
+	ProviderGroupID int32
+}) (*groupResolver, error) {
+	runID, err := parseID(args.RunID)
+	if err != nil {
+		return nil, err
+	}
+	ref := store.ProviderGroupRef{RunID: runID, ProviderGroupID: int(args.ProviderGroupID)}
+	members, _, err := loadersFrom(ctx).members.load(ctx, ref)
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+	return groupResolvers([]store.ProviderGroupRef{ref})[0], nil
+}
+
+func (r *rootResolver) Provider(ctx context.Context, args struct{ NPI string }) (*providerResolver, error) {
+	provider, ok, err := loadersFrom(ctx).providers.load(ctx, args.NPI)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &providerResolver{provider}, nil
+}
+
+// serviceResolver resolves InsuranceService
+type serviceResolver struct {
+	s store.Service
+}
+
+func (r *serviceResolver) ID() graphql.ID                 { return formatID(r.s.ID) }
+func (r *serviceResolver) Name() string                   { return r.s.Name }
+func (r *serviceResolver) Description() string            { return r.s.Description }
+func (r *serviceResolver) BillingCodeType() string        { return r.s.BillingCodeType }
+func (r *serviceResolver) BillingCodeTypeVersion() string { return r.s.BillingCodeTypeVersion }
+func (r *serviceResolver) BillingCode() string            { return r.s.BillingCode }
+func (r *serviceResolver) NegotiationArrangement() string { return r.s.NegotiationArrangement }
+
+func (r *serviceResolver) Run(ctx context.Context) (*runResolver, error) {
+	if r.s.RunID == nil {
+		return nil, nil
+	}
+	run, ok, err := loadersFrom(ctx).runs.load(ctx, *r.s.RunID)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &runResolver{run}, nil
+}
+
+func (r *serviceResolver) ReportingEntity(ctx context.Context) (*entityResolver, error) {
+	if r.s.RunID == nil {
+		return nil, nil
+	}
+	return runEntity(ctx, *r.s.RunID)
+}
+
+func (r *serviceResolver) Rates(ctx context.Context, args struct {
+	First          int32
+	BillingClass   *string
+	NegotiatedType *string
+}) ([]*rateResolver, error) {
+	if err := checkFirst(args.First); err != nil {
+		return nil, err
+	}
+	key := rateArgs{first: int(args.First)}
+	if args.BillingClass != nil {
+		key.filter.BillingClass = *args.BillingClass
+	}
+	if args.NegotiatedType != nil {
+		key.filter.NegotiatedType = *args.NegotiatedType
+	}
+
+	rates, _, err := loadersFrom(ctx).rates.load(ctx, key, r.s.ID)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*rateResolver, len(rates))
+	for i, rate := range rates {
+		resolvers[i] = &rateResolver{rate}
+	}
+	return resolvers, nil
+}
+
+func (r *serviceResolver) Prices(ctx context.Context, args struct{ First int32 }) ([]*priceResolver, error) {
+	if err := checkFirst(args.First); err != nil {
+		return nil, err
+	}
+	prices, _, err := loadersFrom(ctx).prices.load(ctx, int(args.First), r.s.ID)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*priceResolver, len(prices))
+	for i, price := range prices {
+		resolvers[i] = &priceResolver{price}
+	}
+	return resolvers, nil
+}
+
+// runEntity loads the reporting entity of a run
+func runEntity(ctx context.Context, runID int64) (*entityResolver, error) {
+	l := loadersFrom(ctx)
+	run, ok, err := l.runs.load(ctx, runID)
+	if err != nil || !ok || run.ReportingEntityName == "" {
+		return nil, err
+	}
+	entity, ok, err := l.entities.load(ctx, run.ReportingEntityName)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &entityResolver{entity}, nil
+}
+
+// rateResolver resolves NegotiatedRate
+type rateResolver struct {
+	r store.Rate
+}
+
+func (r *rateResolver) ID() graphql.ID           { return formatID(r.r.ID) }
+func (r *rateResolver) NegotiatedType() string   { return r.r.NegotiatedType }
+func (r *rateResolver) NegotiatedRate() float64  { return r.r.NegotiatedRate }
+func (r *rateResolver) EffectiveRate() *float64  { return r.r.EffectiveRate }
+func (r *rateResolver) Comparable() bool         { return r.r.EffectiveRate != nil }
+func (r *rateResolver) MedicareAmount() *float64 { return r.r.MedicareAmount }
+func (r *rateResolver) PctOfMedicare() *float64  { return r.r.PctOfMedicare }
+func (r *rateResolver) ExpirationDate() string   { return r.r.ExpirationDate }
+func (r *rateResolver) BillingClass() string     { return r.r.BillingClass }
+func (r *rateResolver) ServiceCodes() []string   { return r.r.ServiceCodes }
+
+func (r *rateResolver) Service(ctx context.Context) (*serviceResolver, error) {
+	service, ok, err := loadersFrom(ctx).services.load(ctx, r.r.ServiceID)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &serviceResolver{service}, nil
+}
+
+func (r *rateResolver) ProviderGroups(ctx context.Context) ([]*groupResolver, error) {
+	refs, _, err := loadersFrom(ctx).groups.load(ctx, r.r.ID)
+	if err != nil {
+		return nil, err
+	}
+	return groupResolvers(refs), nil
+}
+
+// priceResolver resolves NegotiatedPrice
+type priceResolver struct {
+	p store.Price
+}
+
+func (r *priceResolver) ID() graphql.ID           { return formatID(r.p.ID) }
+func (r *priceResolver) NegotiatedType() string   { return r.p.NegotiatedType }
+func (r *priceResolver) NegotiatedRate() float64  { return r.p.NegotiatedRate }
+func (r *priceResolver) EffectiveRate() *float64  { return r.p.EffectiveRate }
+func (r *priceResolver) Comparable() bool         { return r.p.EffectiveRate != nil }
+func (r *priceResolver) MedicareAmount() *float64 { return r.p.MedicareAmount }
+func (r *priceResolver) PctOfMedicare() *float64  { return r.p.PctOfMedicare }
+func (r *priceResolver) ExpirationDate() string   { return r.p.ExpirationDate }
+func (r *priceResolver) BillingClass() string     { return r.p.BillingClass }
+func (r *priceResolver) ServiceCodes() []string   { return r.p.ServiceCodes }
+
+func (r *priceResolver) ProviderGroups() []*groupResolver {
+	return groupResolvers(priceGroups(r.p))
+}
+
+// groupResolvers wraps provider groups. Their members and runs were
+// announced by the loader that found them.
+func groupResolvers(refs []store.ProviderGroupRef) []*groupResolver {
+	resolvers := make([]*groupResolver, len(refs))
+	for i, ref := range refs {
+		resolvers[i] = &groupResolver{ref}
+	}
+	return resolvers
+}
+
+// groupResolver resolves ProviderGroup
+type groupResolver struct {
+	ref store.ProviderGroupRef
+}
+
+func (r *groupResolver) ID() int32         { return int32(r.ref.ProviderGroupID) }
+func (r *groupResolver) RunID() graphql.ID { return formatID(r.ref.RunID) }
+
+func (r *groupResolver) ReportingEntity(ctx context.Context) (*entityResolver, error) {
+	return runEntity(ctx, r.ref.RunID)
+}
+
+func (r *groupResolver) NPIs(ctx context.Context) ([]string, error) {
+	members, _, err := loadersFrom(ctx).members.load(ctx, r.ref)
+	if err != nil {
+		return nil, err
+	}
+	npis := []string{}
+	for i, member := range members {
+		if i == 0 || member.NPI != members[i-1].NPI {
+			npis = append(npis, member.NPI)
+		}
+	}
+	return npis, nil
+}
+
+func (r *groupResolver) TINs(ctx context.Context) ([]*tinResolver, error) {
+	members, _, err := loadersFrom(ctx).members.load(ctx, r.ref)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[tinResolver]bool)
+	tins := []*tinResolver{}
+	for _, member := range members {
+		tin := tinResolver{member.TINType, member.TINValue}
+		if !seen[tin] {
+			seen[tin] = true
+			tins = append(tins, &tin)
+		}
+	}
+	return tins, nil
+}
+
+func (r *groupResolver) Providers(ctx context.Context) ([]*providerResolver, error) {
+	npis, err := r.NPIs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	l := loadersFrom(ctx)
+	providers := []*providerResolver{}
+	for _, npi := range npis {
+		provider, ok, err := l.providers.load(ctx, npi)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			providers = append(providers, &providerResolver{provider})
+		}
+	}
+	return providers, nil
+}
+
+// tinResolver resolves Tin
+type tinResolver struct {
+	kind, value string
+}
+
+func (r *tinResolver) Type() string  { return r.kind }
+func (r *tinResolver) Value() string { return r.value }
+
+// providerResolver resolves Provider, Address and ContactInfo
+type providerResolver struct {
+	p store.Provider
+}
+
+func (r *providerResolver) ID() int32                      { return int32(r.p.ID) }
+func (r *providerResolver) NPI() string                    { return r.p.NPI }
+func (r *providerResolver) Name() string                   { return r.p.Name }
+func (r *providerResolver) CMSCertificationNumber() string { return r.p.CMSCertificationNumber }
+func (r *providerResolver) Address() *addressResolver      { return &addressResolver{r.p.Address} }
+func (r *providerResolver) ContactInfo() *contactResolver  { return &contactResolver{r.p.ContactInfo} }
+
+type addressResolver struct {
+	a store.Address
+}
+
+func (r *addressResolver) Street() string { return r.a.Street }
+func (r *addressResolver) City() string   { return r.a.City }
+func (r *addressResolver) State() string  { return r.a.State }
+func (r *addressResolver) Zip() string    { return r.a.Zip }
+
+type contactResolver struct {
+	c store.ContactInfo
+}
+
+func (r *contactResolver) Phone() string   { return r.c.Phone }
+func (r *contactResolver) Email() string   { return r.c.Email }
+func (r *contactResolver) Website() string { return r.c.Website }
+
+// runResolver resolves IngestionRun
+type runResolver struct {
+	r store.Run
+}
+
+func (r *runResolver) ID() graphql.ID         { return formatID(r.r.ID) }
+func (r *runResolver) FilePath() string       { return r.r.FilePath }
+func (r *runResolver) PlanName() string       { return r.r.PlanName }
+func (r *runResolver) PlanIDType() string     { return r.r.PlanIDType }
+func (r *runResolver) PlanID() string         { return r.r.PlanID }
+func (r *runResolver) PlanMarketType() string { return r.r.PlanMarketType }
+func (r *runResolver) LastUpdatedOn() string  { return r.r.LastUpdatedOn }
+func (r *runResolver) Status() string         { return r.r.Status }
+func (r *runResolver) ServicesCount() int32   { return int32(r.r.ServicesCount) }
+func (r *runResolver) RatesCount() int32      { return int32(r.r.RatesCount) }
+
+// entityResolvers wraps reporting entities, announcing their compliance
+func entityResolvers(ctx context.Context, entities []store.ReportingEntity) []*entityResolver {
+	l := loadersFrom(ctx)
+	resolvers := make([]*entityResolver, len(entities))
+	for i, entity := range entities {
+		resolvers[i] = &entityResolver{entity}
+		l.compliance.expect(entity.Name)
+	}
+	return resolvers
+}
+
+// entityResolver resolves ReportingEntity
+type entityResolver struct {
+	e store.ReportingEntity
+}
+
+func (r *entityResolver) Name() string { return r.e.Name }
+func (r *entityResolver) Type() string { return r.e.Type }
+
+func (r *entityResolver) LastUpdatedOn() string {
+	if len(r.e.Runs) == 0 {
+		return ""
+	}
+	return r.e.Runs[0].LastUpdatedOn
+}
+
+func (r *entityResolver) Runs() []*runResolver {
+	runs := make([]*runResolver, len(r.e.Runs))
+	for i, run := range r.e.Runs {
+		runs[i] = &runResolver{run}
+	}
+	return runs
+}
+
+func (r *entityResolver) Compliance(ctx context.Context) (*complianceResolver, error) {
+	compliance, _, err := loadersFrom(ctx).compliance.load(ctx, r.e.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &complianceResolver{compliance}, nil
+}
+
+// complianceResolver resolves Compliance
+type complianceResolver struct {
+	c store.Compliance
+}
+
+func (r *complianceResolver) LastUpdatedOn() string      { return r.c.LastUpdatedOn }
+func (r *complianceResolver) HasPlanInfo() bool          { return r.c.HasPlanInfo }
+func (r *complianceResolver) ServiceCount() int32        { return int32(r.c.ServiceCount) }
+func (r *complianceResolver) RateCount() int32           { return int32(r.c.RateCount) }
+func (r *complianceResolver) ExpiredRateCount() int32    { return int32(r.c.ExpiredRateCount) }
+func (r *complianceResolver) PercentageRateCount() int32 { return int32(r.c.PercentageRateCount) }
+func (r *complianceResolver) UnresolvedProviderGroupCount() int32 {
+	return int32(r.c.UnresolvedProviderGroupCount)
+}
+
+func (r *complianceResolver) DaysSinceUpdate() *int32 {
+	if r.c.DaysSinceUpdate == nil {
+		return nil
+	}
+	days := int32(*r.c.DaysSinceUpdate)
+	return &days
+}
+
+func (r *complianceResolver) UpdatedWithinMonth() bool {
+	return r.c.DaysSinceUpdate != nil && *r.c.DaysSinceUpdate <= staleAfterDays
+}
+
+func (r *complianceResolver) Issues() []string {
+	issues := []string{}
+	switch {
+	case r.c.DaysSinceUpdate == nil:
+		issues = append(issues, "no last_updated_on date")
+	case *r.c.DaysSinceUpdate > staleAfterDays:
+		issues = append(issues, fmt.Sprintf("last updated %d days ago, files must be updated monthly", *r.c.DaysSinceUpdate))
+	}
+	if !r.c.HasPlanInfo {
+		issues = append(issues, "plan name or id missing from a file")
+	}
+	if r.c.ExpiredRateCount > 0 {
+		issues = append(issues, fmt.Sprintf("%d current rates have expired", r.c.ExpiredRateCount))
+	}
+	if r.c.UnresolvedProviderGroupCount > 0 {
+		issues = append(issues, fmt.Sprintf("%d provider groups have no NPIs", r.c.UnresolvedProviderGroupCount))
+	}
+	return issues
+}
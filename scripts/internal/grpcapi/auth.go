@@ -0,0 +1,89 @@
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"healthcare-saver-ingest/internal/auth"
+)
+
+// apiKey returns the API key of a call, sent like over HTTP as a bearer
+// token in authorization or in x-api-key metadata
+func apiKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, header := range md.Get("authorization") {
+		if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+			return strings.TrimSpace(header[7:])
+		}
+	}
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+// authenticate charges a call to its API key, returning the headers to send
+// and, when the call must not be served, its status error. Refused calls get
+// the limit headers of the HTTP API in metadata, retry-after included.
+func authenticate(ctx context.Context, limiter *auth.Limiter) (metadata.MD, error) {
+	token := apiKey(ctx)
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "API key required")
+	}
+
+	key, decision, err := limiter.Allow(ctx, token)
+	if err != nil {
+		return nil, internalError(err)
+	}
+	if key == nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+
+	header := metadata.MD{}
+	if key.DailyQuota > 0 {
+		header.Set("x-ratelimit-limit", strconv.Itoa(key.DailyQuota))
+		header.Set("x-ratelimit-remaining", strconv.FormatInt(decision.Remaining, 10))
+	}
+	if !decision.Allowed {
+		// Whole seconds, rounded up so retries are not early
+		header.Set("retry-after", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+		return header, status.Error(codes.ResourceExhausted, decision.Reason)
+	}
+	return header, nil
+}
+
+// authUnary requires an API key within its limits on unary calls
+func authUnary(limiter *auth.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		header, err := authenticate(ctx, limiter)
+		if len(header) > 0 {
+			grpc.SetHeader(ctx, header)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authStream requires an API key within its limits on streaming calls. A
+// stream is charged as one request, however many messages it sends.
+func authStream(limiter *auth.Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		header, err := authenticate(ss.Context(), limiter)
+		if len(header) > 0 {
+			ss.SetHeader(header)
+		}
+		if err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
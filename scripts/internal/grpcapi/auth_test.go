@@ -0,0 +1,67 @@
+package grpcapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"healthcare-saver-ingest/internal/auth"
+)
+
+// keyRows returns an api_keys row of a key with a burst of one request
+func keyRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "name", "key_prefix", "rate_per_second", "burst", "daily_quota",
+		"created_at", "revoked_at", "last_used_at", "requests",
+	}).AddRow(1, "Acme Health", "hcs_abcdefgh", 0.001, 1, 100, time.Now(), nil, nil, 0)
+}
+
+// withMetadata returns an incoming call context with metadata pairs
+func withMetadata(pairs ...string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(pairs...))
+}
+
+func TestAuthenticate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock database: %v", err)
+	}
+	defer db.Close()
+	limiter := auth.NewLimiter(auth.NewKeys(db))
+
+	if _, err := authenticate(context.Background(), limiter); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("call without a key = %v, want Unauthenticated", err)
+	}
+
+	mock.ExpectQuery("FROM api_keys").WillReturnRows(keyRows())
+	header, err := authenticate(withMetadata("authorization", "Bearer hcs_valid"), limiter)
+	if err != nil {
+		t.Fatalf("first call with a key = %v, want it served", err)
+	}
+	if got := header.Get("x-ratelimit-remaining"); len(got) != 1 || got[0] != "99" {
+		t.Errorf("x-ratelimit-remaining = %v, want 99", got)
+	}
+
+	// The key is cached, and its bucket of one request is empty
+	header, err = authenticate(withMetadata("x-api-key", "hcs_valid"), limiter)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second call = %v, want ResourceExhausted", err)
+	}
+	if len(header.Get("retry-after")) != 1 {
+		t.Errorf("refused call without retry-after: %v", header)
+	}
+
+	mock.ExpectQuery("FROM api_keys").WillReturnRows(sqlmock.NewRows(nil))
+	if _, err := authenticate(withMetadata("x-api-key", "hcs_revoked"), limiter); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("call with an unknown key = %v, want Unauthenticated", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
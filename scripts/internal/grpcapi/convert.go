@@ -0,0 +1,113 @@
+package grpcapi
+
+import (
+	"healthcare-saver-ingest/internal/grpcapi/ratespb"
+	"healthcare-saver-ingest/internal/store"
+)
+
+// negotiatedType maps the negotiated_type column to its enum
+func negotiatedType(value string) ratespb.NegotiatedType {
+	switch value {
+	case "negotiated":
+		return ratespb.NegotiatedType_NEGOTIATED_TYPE_NEGOTIATED
+	case "percentage":
+		return ratespb.NegotiatedType_NEGOTIATED_TYPE_PERCENTAGE
+	}
+	return ratespb.NegotiatedType_NEGOTIATED_TYPE_UNSPECIFIED
+}
+
+// billingClass maps the billing_class column to its enum
+func billingClass(value string) ratespb.BillingClass {
+	switch value {
+	case "professional":
+		return ratespb.BillingClass_BILLING_CLASS_PROFESSIONAL
+	case "institutional":
+		return ratespb.BillingClass_BILLING_CLASS_INSTITUTIONAL
+	}
+	return ratespb.BillingClass_BILLING_CLASS_UNSPECIFIED
+}
+
+// billingClassName maps a billing class enum to the billing_class column, or
+// "" for any billing class
+func billingClassName(value ratespb.BillingClass) string {
+	switch value {
+	case ratespb.BillingClass_BILLING_CLASS_PROFESSIONAL:
+		return "professional"
+	case ratespb.BillingClass_BILLING_CLASS_INSTITUTIONAL:
+		return "institutional"
+	}
+	return ""
+}
+
+// int32s converts provider group ids
+func int32s(values []int) []int32 {
+	result := make([]int32, len(values))
+	for i, value := range values {
+		result[i] = int32(value)
+	}
+	return result
+}
+
+// toServiceRates converts a service with its rates and prices
+func toServiceRates(result store.ServiceRates) *ratespb.ServiceRates {
+	service := result.Service
+	message := &ratespb.ServiceRates{
+		Service: &ratespb.InsuranceService{
+			Id:                     service.ID,
+			Name:                   service.Name,
+			Description:            service.Description,
+			BillingCodeType:        service.BillingCodeType,
+			BillingCodeTypeVersion: service.BillingCodeTypeVersion,
+			BillingCode:            service.BillingCode,
+			NegotiationArrangement: service.NegotiationArrangement,
+		},
+		Rates:  make([]*ratespb.NegotiatedRate, len(result.Rates)),
+		Prices: make([]*ratespb.NegotiatedPrice, len(result.Prices)),
+	}
+
+	if run := result.Run; run != nil {
+		message.Source = &ratespb.Source{
+			RunId:               run.ID,
+			ReportingEntityName: run.ReportingEntityName,
+			ReportingEntityType: run.ReportingEntityType,
+			PlanName:            run.PlanName,
+			PlanIdType:          run.PlanIDType,
+			PlanId:              run.PlanID,
+			PlanMarketType:      run.PlanMarketType,
+			LastUpdatedOn:       run.LastUpdatedOn,
+		}
+	}
+
+	for i, rate := range result.Rates {
+		message.Rates[i] = &ratespb.NegotiatedRate{
+			Id:                 rate.ID,
+			NegotiatedType:     negotiatedType(rate.NegotiatedType),
+			NegotiatedRate:     rate.NegotiatedRate,
+			ExpirationDate:     rate.ExpirationDate,
+			BillingClass:       billingClass(rate.BillingClass),
+			ServiceCodes:       rate.ServiceCodes,
+			ProviderReferences: int32s(rate.ProviderReferences),
+			EffectiveRate:      rate.EffectiveRate,
+			Comparable:         rate.EffectiveRate != nil,
+			MedicareAmount:     rate.MedicareAmount,
+			PctOfMedicare:      rate.PctOfMedicare,
+		}
+	}
+	for i, price := range result.Prices {
+		message.Prices[i] = &ratespb.NegotiatedPrice{
+			Id:               price.ID,
+			NegotiatedType:   negotiatedType(price.NegotiatedType),
+			NegotiatedRate:   price.NegotiatedRate,
+			ExpirationDate:   price.ExpirationDate,
+			BillingClass:     billingClass(price.BillingClass),
+			ServiceCodes:     price.ServiceCodes,
+			ProviderGroupIds: int32s(price.ProviderGroupIDs),
+			EffectiveRate:    price.EffectiveRate,
+			Comparable:       price.EffectiveRate != nil,
+			MedicareAmount:   price.MedicareAmount,
+			PctOfMedicare:    price.PctOfMedicare,
+		}
+	}
+
+	return message
+}
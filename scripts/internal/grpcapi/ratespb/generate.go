@@ -0,0 +1,5 @@
+// Package ratespb holds the protobuf messages and gRPC stubs of the rate
+// service, generated from rates.proto.
+package ratespb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative rates.proto
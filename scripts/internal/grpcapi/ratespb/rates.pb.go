@@ -0,0 +1,1258 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: rates.proto
+
+package ratespb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type NegotiatedType int32
+
+const (
+	NegotiatedType_NEGOTIATED_TYPE_UNSPECIFIED NegotiatedType = 0
+	NegotiatedType_NEGOTIATED_TYPE_NEGOTIATED  NegotiatedType = 1
+	// negotiated_rate is a percentage, not a dollar amount
+	NegotiatedType_NEGOTIATED_TYPE_PERCENTAGE NegotiatedType = 2
+)
+
+// Enum value maps for NegotiatedType.
+var (
+	NegotiatedType_name = map[int32]string{
+		0: "NEGOTIATED_TYPE_UNSPECIFIED",
+		1: "NEGOTIATED_TYPE_NEGOTIATED",
+		2: "NEGOTIATED_TYPE_PERCENTAGE",
+	}
+	NegotiatedType_value = map[string]int32{
+		"NEGOTIATED_TYPE_UNSPECIFIED": 0,
+		"NEGOTIATED_TYPE_NEGOTIATED":  1,
+		"NEGOTIATED_TYPE_PERCENTAGE":  2,
+	}
+)
+
+func (x NegotiatedType) Enum() *NegotiatedType {
+	p := new(NegotiatedType)
+	*p = x
+	return p
+}
+
+func (x NegotiatedType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (NegotiatedType) Descriptor() protoreflect.EnumDescriptor {
+	return file_rates_proto_enumTypes[0].Descriptor()
+}
+
+func (NegotiatedType) Type() protoreflect.EnumType {
+	return &file_rates_proto_enumTypes[0]
+}
+
+func (x NegotiatedType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use NegotiatedType.Descriptor instead.
+func (NegotiatedType) EnumDescriptor() ([]byte, []int) {
+	return file_rates_proto_rawDescGZIP(), []int{0}
+}
+
+type BillingClass int32
+
+const (
+	BillingClass_BILLING_CLASS_UNSPECIFIED   BillingClass = 0
+	BillingClass_BILLING_CLASS_PROFESSIONAL  BillingClass = 1
+	BillingClass_BILLING_CLASS_INSTITUTIONAL BillingClass = 2
+)
+
+// Enum value maps for BillingClass.
+var (
+	BillingClass_name = map[int32]string{
+		0: "BILLING_CLASS_UNSPECIFIED",
+		1: "BILLING_CLASS_PROFESSIONAL",
+		2: "BILLING_CLASS_INSTITUTIONAL",
+	}
+	BillingClass_value = map[string]int32{
+		"BILLING_CLASS_UNSPECIFIED":   0,
+		"BILLING_CLASS_PROFESSIONAL":  1,
+		"BILLING_CLASS_INSTITUTIONAL": 2,
+	}
+)
+
+func (x BillingClass) Enum() *BillingClass {
+	p := new(BillingClass)
+	*p = x
+	return p
+}
+
+func (x BillingClass) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BillingClass) Descriptor() protoreflect.EnumDescriptor {
+	return file_rates_proto_enumTypes[1].Descriptor()
+}
+
+func (BillingClass) Type() protoreflect.EnumType {
+	return &file_rates_proto_enumTypes[1]
+}
+
+func (x BillingClass) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BillingClass.Descriptor instead.
+func (BillingClass) EnumDescriptor() ([]byte, []int) {
+	return file_rates_proto_rawDescGZIP(), []int{1}
+}
+
+// InsuranceService is an in-network item of a payer file
+type InsuranceService struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id                     int64  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name                   string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description            string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	BillingCodeType        string `protobuf:"bytes,4,opt,name=billing_code_type,json=billingCodeType,proto3" json:"billing_code_type,omitempty"`
+	BillingCodeTypeVersion string `protobuf:"bytes,5,opt,name=billing_code_type_version,json=billingCodeTypeVersion,proto3" json:"billing_code_type_version,omitempty"`
+	BillingCode            string `protobuf:"bytes,6,opt,name=billing_code,json=billingCode,proto3" json:"billing_code,omitempty"`
+	NegotiationArrangement string `protobuf:"bytes,7,opt,name=negotiation_arrangement,json=negotiationArrangement,proto3" json:"negotiation_arrangement,omitempty"`
+}
+
+func (x *InsuranceService) Reset() {
+	*x = InsuranceService{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rates_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *InsuranceService) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InsuranceService) ProtoMessage() {}
+
+func (x *InsuranceService) ProtoReflect() protoreflect.Message {
+	mi := &file_rates_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InsuranceService.ProtoReflect.Descriptor instead.
+func (*InsuranceService) Descriptor() ([]byte, []int) {
+	return file_rates_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *InsuranceService) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *InsuranceService) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *InsuranceService) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *InsuranceService) GetBillingCodeType() string {
+	if x != nil {
+		return x.BillingCodeType
+	}
+	return ""
+}
+
+func (x *InsuranceService) GetBillingCodeTypeVersion() string {
+	if x != nil {
+		return x.BillingCodeTypeVersion
+	}
+	return ""
+}
+
+func (x *InsuranceService) GetBillingCode() string {
+	if x != nil {
+		return x.BillingCode
+	}
+	return ""
+}
+
+func (x *InsuranceService) GetNegotiationArrangement() string {
+	if x != nil {
+		return x.NegotiationArrangement
+	}
+	return ""
+}
+
+// NegotiatedRate is a current rate of a service
+type NegotiatedRate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id             int64          `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	NegotiatedType NegotiatedType `protobuf:"varint,2,opt,name=negotiated_type,json=negotiatedType,proto3,enum=healthcaresaver.rates.v1.NegotiatedType" json:"negotiated_type,omitempty"`
+	NegotiatedRate float64        `protobuf:"fixed64,3,opt,name=negotiated_rate,json=negotiatedRate,proto3" json:"negotiated_rate,omitempty"`
+	// YYYY-MM-DD
+	ExpirationDate string       `protobuf:"bytes,4,opt,name=expiration_date,json=expirationDate,proto3" json:"expiration_date,omitempty"`
+	BillingClass   BillingClass `protobuf:"varint,5,opt,name=billing_class,json=billingClass,proto3,enum=healthcaresaver.rates.v1.BillingClass" json:"billing_class,omitempty"`
+	ServiceCodes   []string     `protobuf:"bytes,6,rep,name=service_codes,json=serviceCodes,proto3" json:"service_codes,omitempty"`
+	// Provider group ids within the source run
+	ProviderReferences []int32 `protobuf:"varint,7,rep,packed,name=provider_references,json=providerReferences,proto3" json:"provider_references,omitempty"`
+	// Dollar amount: percentage rates applied to the reference fee, unset
+	// without one
+	EffectiveRate *float64 `protobuf:"fixed64,8,opt,name=effective_rate,json=effectiveRate,proto3,oneof" json:"effective_rate,omitempty"`
+	Comparable    bool     `protobuf:"varint,9,opt,name=comparable,proto3" json:"comparable,omitempty"`
+	// National Medicare amount of the billing class, and effective_rate as a
+	// percent of it; unset when Medicare does not price the code
+	MedicareAmount *float64 `protobuf:"fixed64,10,opt,name=medicare_amount,json=medicareAmount,proto3,oneof" json:"medicare_amount,omitempty"`
+	PctOfMedicare  *float64 `protobuf:"fixed64,11,opt,name=pct_of_medicare,json=pctOfMedicare,proto3,oneof" json:"pct_of_medicare,omitempty"`
+}
+
+func (x *NegotiatedRate) Reset() {
+	*x = NegotiatedRate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rates_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NegotiatedRate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NegotiatedRate) ProtoMessage() {}
+
+func (x *NegotiatedRate) ProtoReflect() protoreflect.Message {
+	mi := &file_rates_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NegotiatedRate.ProtoReflect.Descriptor instead.
+func (*NegotiatedRate) Descriptor() ([]byte, []int) {
+	return file_rates_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *NegotiatedRate) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *NegotiatedRate) GetNegotiatedType() NegotiatedType {
+	if x != nil {
+		return x.NegotiatedType
+	}
+	return NegotiatedType_NEGOTIATED_TYPE_UNSPECIFIED
+}
+
+func (x *NegotiatedRate) GetNegotiatedRate() float64 {
+	if x != nil {
+		return x.NegotiatedRate
+	}
+	return 0
+}
+
+func (x *NegotiatedRate) GetExpirationDate() string {
+	if x != nil {
+		return x.ExpirationDate
+	}
+	return ""
+}
+
+func (x *NegotiatedRate) GetBillingClass() BillingClass {
+	if x != nil {
+		return x.BillingClass
+	}
+	return BillingClass_BILLING_CLASS_UNSPECIFIED
+}
+
+func (x *NegotiatedRate) GetServiceCodes() []string {
+	if x != nil {
+		return x.ServiceCodes
+	}
+	return nil
+}
+
+func (x *NegotiatedRate) GetProviderReferences() []int32 {
+	if x != nil {
+		return x.ProviderReferences
+	}
+	return nil
+}
+
+func (x *NegotiatedRate) GetEffectiveRate() float64 {
+	if x != nil && x.EffectiveRate != nil {
+		return *x.EffectiveRate
+	}
+	return 0
+}
+
+func (x *NegotiatedRate) GetComparable() bool {
+	if x != nil {
+		return x.Comparable
+	}
+	return false
+}
+
+func (x *NegotiatedRate) GetMedicareAmount() float64 {
+	if x != nil && x.MedicareAmount != nil {
+		return *x.MedicareAmount
+	}
+	return 0
+}
+
+func (x *NegotiatedRate) GetPctOfMedicare() float64 {
+	if x != nil && x.PctOfMedicare != nil {
+		return *x.PctOfMedicare
+	}
+	return 0
+}
+
+// NegotiatedPrice is a distinct price of a service: its current rates with
+// the same type, rate, expiration date, billing class and service codes
+type NegotiatedPrice struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Lowest id of the rates of the price
+	Id             int64          `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	NegotiatedType NegotiatedType `protobuf:"varint,2,opt,name=negotiated_type,json=negotiatedType,proto3,enum=healthcaresaver.rates.v1.NegotiatedType" json:"negotiated_type,omitempty"`
+	NegotiatedRate float64        `protobuf:"fixed64,3,opt,name=negotiated_rate,json=negotiatedRate,proto3" json:"negotiated_rate,omitempty"`
+	// YYYY-MM-DD
+	ExpirationDate string       `protobuf:"bytes,4,opt,name=expiration_date,json=expirationDate,proto3" json:"expiration_date,omitempty"`
+	BillingClass   BillingClass `protobuf:"varint,5,opt,name=billing_class,json=billingClass,proto3,enum=healthcaresaver.rates.v1.BillingClass" json:"billing_class,omitempty"`
+	ServiceCodes   []string     `protobuf:"bytes,6,rep,name=service_codes,json=serviceCodes,proto3" json:"service_codes,omitempty"`
+	// Provider group ids of all of the rates, within the source run
+	ProviderGroupIds []int32 `protobuf:"varint,7,rep,packed,name=provider_group_ids,json=providerGroupIds,proto3" json:"provider_group_ids,omitempty"`
+	// Dollar amount: percentage rates applied to the reference fee, unset
+	// without one
+	EffectiveRate *float64 `protobuf:"fixed64,8,opt,name=effective_rate,json=effectiveRate,proto3,oneof" json:"effective_rate,omitempty"`
+	Comparable    bool     `protobuf:"varint,9,opt,name=comparable,proto3" json:"comparable,omitempty"`
+	// National Medicare amount of the billing class, and effective_rate as a
+	// percent of it; unset when Medicare does not price the code
+	MedicareAmount *float64 `protobuf:"fixed64,10,opt,name=medicare_amount,json=medicareAmount,proto3,oneof" json:"medicare_amount,omitempty"`
+	PctOfMedicare  *float64 `protobuf:"fixed64,11,opt,name=pct_of_medicare,json=pctOfMedicare,proto3,oneof" json:"pct_of_medicare,omitempty"`
+}
+
+func (x *NegotiatedPrice) Reset() {
+	*x = NegotiatedPrice{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rates_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NegotiatedPrice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NegotiatedPrice) ProtoMessage() {}
+
+func (x *NegotiatedPrice) ProtoReflect() protoreflect.Message {
+	mi := &file_rates_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NegotiatedPrice.ProtoReflect.Descriptor instead.
+func (*NegotiatedPrice) Descriptor() ([]byte, []int) {
+	return file_rates_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *NegotiatedPrice) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *NegotiatedPrice) GetNegotiatedType() NegotiatedType {
+	if x != nil {
+		return x.NegotiatedType
+	}
+	return NegotiatedType_NEGOTIATED_TYPE_UNSPECIFIED
+}
+
+func (x *NegotiatedPrice) GetNegotiatedRate() float64 {
+	if x != nil {
+		return x.NegotiatedRate
+	}
+	return 0
+}
+
+func (x *NegotiatedPrice) GetExpirationDate() string {
+	if x != nil {
+		return x.ExpirationDate
+	}
+	return ""
+}
+
+func (x *NegotiatedPrice) GetBillingClass() BillingClass {
+	if x != nil {
+		return x.BillingClass
+	}
+	return BillingClass_BILLING_CLASS_UNSPECIFIED
+}
+
+func (x *NegotiatedPrice) GetServiceCodes() []string {
+	if x != nil {
+		return x.ServiceCodes
+	}
+	return nil
+}
+
+func (x *NegotiatedPrice) GetProviderGroupIds() []int32 {
+	if x != nil {
+		return x.ProviderGroupIds
+	}
+	return nil
+}
+
+func (x *NegotiatedPrice) GetEffectiveRate() float64 {
+	if x != nil && x.EffectiveRate != nil {
+		return *x.EffectiveRate
+	}
+	return 0
+}
+
+func (x *NegotiatedPrice) GetComparable() bool {
+	if x != nil {
+		return x.Comparable
+	}
+	return false
+}
+
+func (x *NegotiatedPrice) GetMedicareAmount() float64 {
+	if x != nil && x.MedicareAmount != nil {
+		return *x.MedicareAmount
+	}
+	return 0
+}
+
+func (x *NegotiatedPrice) GetPctOfMedicare() float64 {
+	if x != nil && x.PctOfMedicare != nil {
+		return *x.PctOfMedicare
+	}
+	return 0
+}
+
+// Source is the ingestion run (payer file) a service was loaded from
+type Source struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RunId               int64  `protobuf:"varint,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	ReportingEntityName string `protobuf:"bytes,2,opt,name=reporting_entity_name,json=reportingEntityName,proto3" json:"reporting_entity_name,omitempty"`
+	ReportingEntityType string `protobuf:"bytes,3,opt,name=reporting_entity_type,json=reportingEntityType,proto3" json:"reporting_entity_type,omitempty"`
+	PlanName            string `protobuf:"bytes,4,opt,name=plan_name,json=planName,proto3" json:"plan_name,omitempty"`
+	PlanIdType          string `protobuf:"bytes,5,opt,name=plan_id_type,json=planIdType,proto3" json:"plan_id_type,omitempty"`
+	PlanId              string `protobuf:"bytes,6,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`
+	PlanMarketType      string `protobuf:"bytes,7,opt,name=plan_market_type,json=planMarketType,proto3" json:"plan_market_type,omitempty"`
+	// YYYY-MM-DD
+	LastUpdatedOn string `protobuf:"bytes,8,opt,name=last_updated_on,json=lastUpdatedOn,proto3" json:"last_updated_on,omitempty"`
+}
+
+func (x *Source) Reset() {
+	*x = Source{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rates_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Source) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Source) ProtoMessage() {}
+
+func (x *Source) ProtoReflect() protoreflect.Message {
+	mi := &file_rates_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Source.ProtoReflect.Descriptor instead.
+func (*Source) Descriptor() ([]byte, []int) {
+	return file_rates_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Source) GetRunId() int64 {
+	if x != nil {
+		return x.RunId
+	}
+	return 0
+}
+
+func (x *Source) GetReportingEntityName() string {
+	if x != nil {
+		return x.ReportingEntityName
+	}
+	return ""
+}
+
+func (x *Source) GetReportingEntityType() string {
+	if x != nil {
+		return x.ReportingEntityType
+	}
+	return ""
+}
+
+func (x *Source) GetPlanName() string {
+	if x != nil {
+		return x.PlanName
+	}
+	return ""
+}
+
+func (x *Source) GetPlanIdType() string {
+	if x != nil {
+		return x.PlanIdType
+	}
+	return ""
+}
+
+func (x *Source) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+func (x *Source) GetPlanMarketType() string {
+	if x != nil {
+		return x.PlanMarketType
+	}
+	return ""
+}
+
+func (x *Source) GetLastUpdatedOn() string {
+	if x != nil {
+		return x.LastUpdatedOn
+	}
+	return ""
+}
+
+// ServiceRates is a service with its rates and prices
+type ServiceRates struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Service *InsuranceService `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	// Unset for data loaded before the run ledger
+	Source *Source            `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	Rates  []*NegotiatedRate  `protobuf:"bytes,3,rep,name=rates,proto3" json:"rates,omitempty"`
+	Prices []*NegotiatedPrice `protobuf:"bytes,4,rep,name=prices,proto3" json:"prices,omitempty"`
+}
+
+func (x *ServiceRates) Reset() {
+	*x = ServiceRates{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rates_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ServiceRates) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceRates) ProtoMessage() {}
+
+func (x *ServiceRates) ProtoReflect() protoreflect.Message {
+	mi := &file_rates_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceRates.ProtoReflect.Descriptor instead.
+func (*ServiceRates) Descriptor() ([]byte, []int) {
+	return file_rates_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ServiceRates) GetService() *InsuranceService {
+	if x != nil {
+		return x.Service
+	}
+	return nil
+}
+
+func (x *ServiceRates) GetSource() *Source {
+	if x != nil {
+		return x.Source
+	}
+	return nil
+}
+
+func (x *ServiceRates) GetRates() []*NegotiatedRate {
+	if x != nil {
+		return x.Rates
+	}
+	return nil
+}
+
+func (x *ServiceRates) GetPrices() []*NegotiatedPrice {
+	if x != nil {
+		return x.Prices
+	}
+	return nil
+}
+
+type LookupRatesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	BillingCodeType string `protobuf:"bytes,1,opt,name=billing_code_type,json=billingCodeType,proto3" json:"billing_code_type,omitempty"`
+	BillingCode     string `protobuf:"bytes,2,opt,name=billing_code,json=billingCode,proto3" json:"billing_code,omitempty"`
+	Npi             string `protobuf:"bytes,3,opt,name=npi,proto3" json:"npi,omitempty"`
+	// Plan name or plan id
+	Plan string `protobuf:"bytes,4,opt,name=plan,proto3" json:"plan,omitempty"`
+	// Reporting entity name
+	Payer        string       `protobuf:"bytes,5,opt,name=payer,proto3" json:"payer,omitempty"`
+	BillingClass BillingClass `protobuf:"varint,6,opt,name=billing_class,json=billingClass,proto3,enum=healthcaresaver.rates.v1.BillingClass" json:"billing_class,omitempty"`
+}
+
+func (x *LookupRatesRequest) Reset() {
+	*x = LookupRatesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rates_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LookupRatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupRatesRequest) ProtoMessage() {}
+
+func (x *LookupRatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rates_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupRatesRequest.ProtoReflect.Descriptor instead.
+func (*LookupRatesRequest) Descriptor() ([]byte, []int) {
+	return file_rates_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *LookupRatesRequest) GetBillingCodeType() string {
+	if x != nil {
+		return x.BillingCodeType
+	}
+	return ""
+}
+
+func (x *LookupRatesRequest) GetBillingCode() string {
+	if x != nil {
+		return x.BillingCode
+	}
+	return ""
+}
+
+func (x *LookupRatesRequest) GetNpi() string {
+	if x != nil {
+		return x.Npi
+	}
+	return ""
+}
+
+func (x *LookupRatesRequest) GetPlan() string {
+	if x != nil {
+		return x.Plan
+	}
+	return ""
+}
+
+func (x *LookupRatesRequest) GetPayer() string {
+	if x != nil {
+		return x.Payer
+	}
+	return ""
+}
+
+func (x *LookupRatesRequest) GetBillingClass() BillingClass {
+	if x != nil {
+		return x.BillingClass
+	}
+	return BillingClass_BILLING_CLASS_UNSPECIFIED
+}
+
+type LookupRatesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Services []*ServiceRates `protobuf:"bytes,1,rep,name=services,proto3" json:"services,omitempty"`
+}
+
+func (x *LookupRatesResponse) Reset() {
+	*x = LookupRatesResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rates_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LookupRatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupRatesResponse) ProtoMessage() {}
+
+func (x *LookupRatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rates_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupRatesResponse.ProtoReflect.Descriptor instead.
+func (*LookupRatesResponse) Descriptor() ([]byte, []int) {
+	return file_rates_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LookupRatesResponse) GetServices() []*ServiceRates {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+type ExportRatesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Empty exports every billing code type
+	BillingCodeType string `protobuf:"bytes,1,opt,name=billing_code_type,json=billingCodeType,proto3" json:"billing_code_type,omitempty"`
+	// Empty exports every billing code
+	BillingCodes []string `protobuf:"bytes,2,rep,name=billing_codes,json=billingCodes,proto3" json:"billing_codes,omitempty"`
+	// Reporting entity name
+	Payer string `protobuf:"bytes,3,opt,name=payer,proto3" json:"payer,omitempty"`
+	// Plan name or plan id
+	Plan string `protobuf:"bytes,4,opt,name=plan,proto3" json:"plan,omitempty"`
+	// Services read from the database at a time, 0 for the default
+	BatchSize int32 `protobuf:"varint,5,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
+}
+
+func (x *ExportRatesRequest) Reset() {
+	*x = ExportRatesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rates_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportRatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRatesRequest) ProtoMessage() {}
+
+func (x *ExportRatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rates_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRatesRequest.ProtoReflect.Descriptor instead.
+func (*ExportRatesRequest) Descriptor() ([]byte, []int) {
+	return file_rates_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ExportRatesRequest) GetBillingCodeType() string {
+	if x != nil {
+		return x.BillingCodeType
+	}
+	return ""
+}
+
+func (x *ExportRatesRequest) GetBillingCodes() []string {
+	if x != nil {
+		return x.BillingCodes
+	}
+	return nil
+}
+
+func (x *ExportRatesRequest) GetPayer() string {
+	if x != nil {
+		return x.Payer
+	}
+	return ""
+}
+
+func (x *ExportRatesRequest) GetPlan() string {
+	if x != nil {
+		return x.Plan
+	}
+	return ""
+}
+
+func (x *ExportRatesRequest) GetBatchSize() int32 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+var File_rates_proto protoreflect.FileDescriptor
+
+var file_rates_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x18, 0x68,
+	0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73, 0x61, 0x76, 0x65, 0x72, 0x2e, 0x72,
+	0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x22, 0x9b, 0x02, 0x0a, 0x10, 0x49, 0x6e, 0x73, 0x75,
+	0x72, 0x61, 0x6e, 0x63, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x0e, 0x0a, 0x02,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x12, 0x20, 0x0a, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x12, 0x2a, 0x0a, 0x11, 0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x63, 0x6f,
+	0x64, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x62,
+	0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x64, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x39,
+	0x0a, 0x19, 0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x5f, 0x74,
+	0x79, 0x70, 0x65, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x16, 0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x64, 0x65, 0x54, 0x79,
+	0x70, 0x65, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x0a, 0x0c, 0x62, 0x69, 0x6c,
+	0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x37, 0x0a, 0x17,
+	0x6e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x61, 0x72, 0x72, 0x61,
+	0x6e, 0x67, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x16, 0x6e,
+	0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x41, 0x72, 0x72, 0x61, 0x6e, 0x67,
+	0x65, 0x6d, 0x65, 0x6e, 0x74, 0x22, 0xca, 0x04, 0x0a, 0x0e, 0x4e, 0x65, 0x67, 0x6f, 0x74, 0x69,
+	0x61, 0x74, 0x65, 0x64, 0x52, 0x61, 0x74, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x51, 0x0a, 0x0f, 0x6e, 0x65, 0x67, 0x6f,
+	0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x28, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73, 0x61,
+	0x76, 0x65, 0x72, 0x2e, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x65, 0x67,
+	0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0e, 0x6e, 0x65, 0x67,
+	0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x54, 0x79, 0x70, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x6e,
+	0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x0e, 0x6e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64,
+	0x52, 0x61, 0x74, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x5f, 0x64, 0x61, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x65,
+	0x78, 0x70, 0x69, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x44, 0x61, 0x74, 0x65, 0x12, 0x4b, 0x0a,
+	0x0d, 0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x26, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72,
+	0x65, 0x73, 0x61, 0x76, 0x65, 0x72, 0x2e, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x42, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x52, 0x0c, 0x62, 0x69,
+	0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0c, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x43, 0x6f, 0x64, 0x65, 0x73, 0x12,
+	0x2f, 0x0a, 0x13, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x5f, 0x72, 0x65, 0x66, 0x65,
+	0x72, 0x65, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x05, 0x52, 0x12, 0x70, 0x72,
+	0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x73,
+	0x12, 0x2a, 0x0a, 0x0e, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x72, 0x61,
+	0x74, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x0d, 0x65, 0x66, 0x66, 0x65,
+	0x63, 0x74, 0x69, 0x76, 0x65, 0x52, 0x61, 0x74, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1e, 0x0a, 0x0a,
+	0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x61, 0x72, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x2c, 0x0a, 0x0f,
+	0x6d, 0x65, 0x64, 0x69, 0x63, 0x61, 0x72, 0x65, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52, 0x0e, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x61, 0x72,
+	0x65, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x88, 0x01, 0x01, 0x12, 0x2b, 0x0a, 0x0f, 0x70, 0x63,
+	0x74, 0x5f, 0x6f, 0x66, 0x5f, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x61, 0x72, 0x65, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x02, 0x52, 0x0d, 0x70, 0x63, 0x74, 0x4f, 0x66, 0x4d, 0x65, 0x64, 0x69,
+	0x63, 0x61, 0x72, 0x65, 0x88, 0x01, 0x01, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x65, 0x66, 0x66, 0x65,
+	0x63, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x6d,
+	0x65, 0x64, 0x69, 0x63, 0x61, 0x72, 0x65, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x12,
+	0x0a, 0x10, 0x5f, 0x70, 0x63, 0x74, 0x5f, 0x6f, 0x66, 0x5f, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x61,
+	0x72, 0x65, 0x22, 0xc8, 0x04, 0x0a, 0x0f, 0x4e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65,
+	0x64, 0x50, 0x72, 0x69, 0x63, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x51, 0x0a, 0x0f, 0x6e, 0x65, 0x67, 0x6f, 0x74, 0x69,
+	0x61, 0x74, 0x65, 0x64, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x28, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73, 0x61, 0x76, 0x65,
+	0x72, 0x2e, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x65, 0x67, 0x6f, 0x74,
+	0x69, 0x61, 0x74, 0x65, 0x64, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0e, 0x6e, 0x65, 0x67, 0x6f, 0x74,
+	0x69, 0x61, 0x74, 0x65, 0x64, 0x54, 0x79, 0x70, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x6e, 0x65, 0x67,
+	0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x0e, 0x6e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x52, 0x61,
+	0x74, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x65, 0x78, 0x70, 0x69, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x64, 0x61, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x44, 0x61, 0x74, 0x65, 0x12, 0x4b, 0x0a, 0x0d, 0x62,
+	0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0e, 0x32, 0x26, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73,
+	0x61, 0x76, 0x65, 0x72, 0x2e, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69,
+	0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x52, 0x0c, 0x62, 0x69, 0x6c, 0x6c,
+	0x69, 0x6e, 0x67, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x0c, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x43, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x2c, 0x0a,
+	0x12, 0x70, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x5f, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x5f,
+	0x69, 0x64, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x05, 0x52, 0x10, 0x70, 0x72, 0x6f, 0x76, 0x69,
+	0x64, 0x65, 0x72, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x49, 0x64, 0x73, 0x12, 0x2a, 0x0a, 0x0e, 0x65,
+	0x66, 0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x18, 0x08, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x0d, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65,
+	0x52, 0x61, 0x74, 0x65, 0x88, 0x01, 0x01, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x61,
+	0x72, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x63, 0x6f, 0x6d,
+	0x70, 0x61, 0x72, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x2c, 0x0a, 0x0f, 0x6d, 0x65, 0x64, 0x69, 0x63,
+	0x61, 0x72, 0x65, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01,
+	0x48, 0x01, 0x52, 0x0e, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x61, 0x72, 0x65, 0x41, 0x6d, 0x6f, 0x75,
+	0x6e, 0x74, 0x88, 0x01, 0x01, 0x12, 0x2b, 0x0a, 0x0f, 0x70, 0x63, 0x74, 0x5f, 0x6f, 0x66, 0x5f,
+	0x6d, 0x65, 0x64, 0x69, 0x63, 0x61, 0x72, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x48, 0x02,
+	0x52, 0x0d, 0x70, 0x63, 0x74, 0x4f, 0x66, 0x4d, 0x65, 0x64, 0x69, 0x63, 0x61, 0x72, 0x65, 0x88,
+	0x01, 0x01, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65,
+	0x5f, 0x72, 0x61, 0x74, 0x65, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x61,
+	0x72, 0x65, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x70, 0x63,
+	0x74, 0x5f, 0x6f, 0x66, 0x5f, 0x6d, 0x65, 0x64, 0x69, 0x63, 0x61, 0x72, 0x65, 0x22, 0xb1, 0x02,
+	0x0a, 0x06, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x15, 0x0a, 0x06, 0x72, 0x75, 0x6e, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x72, 0x75, 0x6e, 0x49, 0x64, 0x12,
+	0x32, 0x0a, 0x15, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x65, 0x6e, 0x74,
+	0x69, 0x74, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13,
+	0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x32, 0x0a, 0x15, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x69, 0x6e, 0x67,
+	0x5f, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x13, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74,
+	0x69, 0x74, 0x79, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6c, 0x61, 0x6e, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x6e,
+	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0c, 0x70, 0x6c, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x5f,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x70, 0x6c, 0x61, 0x6e,
+	0x49, 0x64, 0x54, 0x79, 0x70, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x6c, 0x61, 0x6e, 0x5f, 0x69,
+	0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6c, 0x61, 0x6e, 0x49, 0x64, 0x12,
+	0x28, 0x0a, 0x10, 0x70, 0x6c, 0x61, 0x6e, 0x5f, 0x6d, 0x61, 0x72, 0x6b, 0x65, 0x74, 0x5f, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x70, 0x6c, 0x61, 0x6e, 0x4d,
+	0x61, 0x72, 0x6b, 0x65, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x26, 0x0a, 0x0f, 0x6c, 0x61, 0x73,
+	0x74, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x6f, 0x6e, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x4f,
+	0x6e, 0x22, 0x91, 0x02, 0x0a, 0x0c, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x52, 0x61, 0x74,
+	0x65, 0x73, 0x12, 0x44, 0x0a, 0x07, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x2a, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65,
+	0x73, 0x61, 0x76, 0x65, 0x72, 0x2e, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x49,
+	0x6e, 0x73, 0x75, 0x72, 0x61, 0x6e, 0x63, 0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x52,
+	0x07, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x38, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74,
+	0x68, 0x63, 0x61, 0x72, 0x65, 0x73, 0x61, 0x76, 0x65, 0x72, 0x2e, 0x72, 0x61, 0x74, 0x65, 0x73,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x06, 0x73, 0x6f, 0x75, 0x72,
+	0x63, 0x65, 0x12, 0x3e, 0x0a, 0x05, 0x72, 0x61, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x28, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73, 0x61,
+	0x76, 0x65, 0x72, 0x2e, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x65, 0x67,
+	0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x52, 0x61, 0x74, 0x65, 0x52, 0x05, 0x72, 0x61, 0x74,
+	0x65, 0x73, 0x12, 0x41, 0x0a, 0x06, 0x70, 0x72, 0x69, 0x63, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x29, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73,
+	0x61, 0x76, 0x65, 0x72, 0x2e, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4e, 0x65,
+	0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x50, 0x72, 0x69, 0x63, 0x65, 0x52, 0x06, 0x70,
+	0x72, 0x69, 0x63, 0x65, 0x73, 0x22, 0xec, 0x01, 0x0a, 0x12, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70,
+	0x52, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x11,
+	0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x5f, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67,
+	0x43, 0x6f, 0x64, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x62, 0x69, 0x6c, 0x6c,
+	0x69, 0x6e, 0x67, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b,
+	0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6e,
+	0x70, 0x69, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6e, 0x70, 0x69, 0x12, 0x12, 0x0a,
+	0x04, 0x70, 0x6c, 0x61, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x6c, 0x61,
+	0x6e, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x61, 0x79, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x70, 0x61, 0x79, 0x65, 0x72, 0x12, 0x4b, 0x0a, 0x0d, 0x62, 0x69, 0x6c, 0x6c, 0x69,
+	0x6e, 0x67, 0x5f, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x26,
+	0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73, 0x61, 0x76, 0x65, 0x72,
+	0x2e, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x69, 0x6c, 0x6c, 0x69, 0x6e,
+	0x67, 0x43, 0x6c, 0x61, 0x73, 0x73, 0x52, 0x0c, 0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x43,
+	0x6c, 0x61, 0x73, 0x73, 0x22, 0x59, 0x0a, 0x13, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x61,
+	0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x42, 0x0a, 0x08, 0x73,
+	0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x26, 0x2e,
+	0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73, 0x61, 0x76, 0x65, 0x72, 0x2e,
+	0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65,
+	0x52, 0x61, 0x74, 0x65, 0x73, 0x52, 0x08, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x22,
+	0xae, 0x01, 0x0a, 0x12, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x61, 0x74, 0x65, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2a, 0x0a, 0x11, 0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e,
+	0x67, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0f, 0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x64, 0x65, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x62, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x5f, 0x63, 0x6f,
+	0x64, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x62, 0x69, 0x6c, 0x6c, 0x69,
+	0x6e, 0x67, 0x43, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x61, 0x79, 0x65, 0x72,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x70, 0x61, 0x79, 0x65, 0x72, 0x12, 0x12, 0x0a,
+	0x04, 0x70, 0x6c, 0x61, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x6c, 0x61,
+	0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x61, 0x74, 0x63, 0x68, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x62, 0x61, 0x74, 0x63, 0x68, 0x53, 0x69, 0x7a, 0x65,
+	0x2a, 0x71, 0x0a, 0x0e, 0x4e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x1f, 0x0a, 0x1b, 0x4e, 0x45, 0x47, 0x4f, 0x54, 0x49, 0x41, 0x54, 0x45, 0x44,
+	0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45,
+	0x44, 0x10, 0x00, 0x12, 0x1e, 0x0a, 0x1a, 0x4e, 0x45, 0x47, 0x4f, 0x54, 0x49, 0x41, 0x54, 0x45,
+	0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x4e, 0x45, 0x47, 0x4f, 0x54, 0x49, 0x41, 0x54, 0x45,
+	0x44, 0x10, 0x01, 0x12, 0x1e, 0x0a, 0x1a, 0x4e, 0x45, 0x47, 0x4f, 0x54, 0x49, 0x41, 0x54, 0x45,
+	0x44, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x5f, 0x50, 0x45, 0x52, 0x43, 0x45, 0x4e, 0x54, 0x41, 0x47,
+	0x45, 0x10, 0x02, 0x2a, 0x6e, 0x0a, 0x0c, 0x42, 0x69, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x43, 0x6c,
+	0x61, 0x73, 0x73, 0x12, 0x1d, 0x0a, 0x19, 0x42, 0x49, 0x4c, 0x4c, 0x49, 0x4e, 0x47, 0x5f, 0x43,
+	0x4c, 0x41, 0x53, 0x53, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44,
+	0x10, 0x00, 0x12, 0x1e, 0x0a, 0x1a, 0x42, 0x49, 0x4c, 0x4c, 0x49, 0x4e, 0x47, 0x5f, 0x43, 0x4c,
+	0x41, 0x53, 0x53, 0x5f, 0x50, 0x52, 0x4f, 0x46, 0x45, 0x53, 0x53, 0x49, 0x4f, 0x4e, 0x41, 0x4c,
+	0x10, 0x01, 0x12, 0x1f, 0x0a, 0x1b, 0x42, 0x49, 0x4c, 0x4c, 0x49, 0x4e, 0x47, 0x5f, 0x43, 0x4c,
+	0x41, 0x53, 0x53, 0x5f, 0x49, 0x4e, 0x53, 0x54, 0x49, 0x54, 0x55, 0x54, 0x49, 0x4f, 0x4e, 0x41,
+	0x4c, 0x10, 0x02, 0x32, 0xe0, 0x01, 0x0a, 0x0b, 0x52, 0x61, 0x74, 0x65, 0x53, 0x65, 0x72, 0x76,
+	0x69, 0x63, 0x65, 0x12, 0x6a, 0x0a, 0x0b, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x52, 0x61, 0x74,
+	0x65, 0x73, 0x12, 0x2c, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73,
+	0x61, 0x76, 0x65, 0x72, 0x2e, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x6f,
+	0x6f, 0x6b, 0x75, 0x70, 0x52, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x2d, 0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73, 0x61, 0x76,
+	0x65, 0x72, 0x2e, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x6f, 0x6f, 0x6b,
+	0x75, 0x70, 0x52, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x65, 0x0a, 0x0b, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x61, 0x74, 0x65, 0x73, 0x12, 0x2c,
+	0x2e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73, 0x61, 0x76, 0x65, 0x72,
+	0x2e, 0x72, 0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74,
+	0x52, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x26, 0x2e, 0x68,
+	0x65, 0x61, 0x6c, 0x74, 0x68, 0x63, 0x61, 0x72, 0x65, 0x73, 0x61, 0x76, 0x65, 0x72, 0x2e, 0x72,
+	0x61, 0x74, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x52,
+	0x61, 0x74, 0x65, 0x73, 0x30, 0x01, 0x42, 0x32, 0x5a, 0x30, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68,
+	0x63, 0x61, 0x72, 0x65, 0x2d, 0x73, 0x61, 0x76, 0x65, 0x72, 0x2d, 0x69, 0x6e, 0x67, 0x65, 0x73,
+	0x74, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x61,
+	0x70, 0x69, 0x2f, 0x72, 0x61, 0x74, 0x65, 0x73, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x33,
+}
+
+var (
+	file_rates_proto_rawDescOnce sync.Once
+	file_rates_proto_rawDescData = file_rates_proto_rawDesc
+)
+
+func file_rates_proto_rawDescGZIP() []byte {
+	file_rates_proto_rawDescOnce.Do(func() {
+		file_rates_proto_rawDescData = protoimpl.X.CompressGZIP(file_rates_proto_rawDescData)
+	})
+	return file_rates_proto_rawDescData
+}
+
+var file_rates_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_rates_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_rates_proto_goTypes = []any{
+	(NegotiatedType)(0),         // 0: healthcaresaver.rates.v1.NegotiatedType
+	(BillingClass)(0),           // 1: healthcaresaver.rates.v1.BillingClass
+	(*InsuranceService)(nil),    // 2: healthcaresaver.rates.v1.InsuranceService
+	(*NegotiatedRate)(nil),      // 3: healthcaresaver.rates.v1.NegotiatedRate
+	(*NegotiatedPrice)(nil),     // 4: healthcaresaver.rates.v1.NegotiatedPrice
+	(*Source)(nil),              // 5: healthcaresaver.rates.v1.Source
+	(*ServiceRates)(nil),        // 6: healthcaresaver.rates.v1.ServiceRates
+	(*LookupRatesRequest)(nil),  // 7: healthcaresaver.rates.v1.LookupRatesRequest
+	(*LookupRatesResponse)(nil), // 8: healthcaresaver.rates.v1.LookupRatesResponse
+	(*ExportRatesRequest)(nil),  // 9: healthcaresaver.rates.v1.ExportRatesRequest
+}
+var file_rates_proto_depIdxs = []int32{
+	0,  // 0: healthcaresaver.rates.v1.NegotiatedRate.negotiated_type:type_name -> healthcaresaver.rates.v1.NegotiatedType
+	1,  // 1: healthcaresaver.rates.v1.NegotiatedRate.billing_class:type_name -> healthcaresaver.rates.v1.BillingClass
+	0,  // 2: healthcaresaver.rates.v1.NegotiatedPrice.negotiated_type:type_name -> healthcaresaver.rates.v1.NegotiatedType
+	1,  // 3: healthcaresaver.rates.v1.NegotiatedPrice.billing_class:type_name -> healthcaresaver.rates.v1.BillingClass
+	2,  // 4: healthcaresaver.rates.v1.ServiceRates.service:type_name -> healthcaresaver.rates.v1.InsuranceService
+	5,  // 5: healthcaresaver.rates.v1.ServiceRates.source:type_name -> healthcaresaver.rates.v1.Source
+	3,  // 6: healthcaresaver.rates.v1.ServiceRates.rates:type_name -> healthcaresaver.rates.v1.NegotiatedRate
+	4,  // 7: healthcaresaver.rates.v1.ServiceRates.prices:type_name -> healthcaresaver.rates.v1.NegotiatedPrice
+	1,  // 8: healthcaresaver.rates.v1.LookupRatesRequest.billing_class:type_name -> healthcaresaver.rates.v1.BillingClass
+	6,  // 9: healthcaresaver.rates.v1.LookupRatesResponse.services:type_name -> healthcaresaver.rates.v1.ServiceRates
+	7,  // 10: healthcaresaver.rates.v1.RateService.LookupRates:input_type -> healthcaresaver.rates.v1.LookupRatesRequest
+	9,  // 11: healthcaresaver.rates.v1.RateService.ExportRates:input_type -> healthcaresaver.rates.v1.ExportRatesRequest
+	8,  // 12: healthcaresaver.rates.v1.RateService.LookupRates:output_type -> healthcaresaver.rates.v1.LookupRatesResponse
+	6,  // 13: healthcaresaver.rates.v1.RateService.ExportRates:output_type -> healthcaresaver.rates.v1.ServiceRates
+	12, // [12:14] is the sub-list for method output_type
+	10, // [10:12] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_rates_proto_init() }
+func file_rates_proto_init() {
+	if File_rates_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_rates_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*InsuranceService); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rates_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*NegotiatedRate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rates_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*NegotiatedPrice); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rates_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*Source); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rates_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*ServiceRates); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rates_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*LookupRatesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rates_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*LookupRatesResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rates_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*ExportRatesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_rates_proto_msgTypes[1].OneofWrappers = []any{}
+	file_rates_proto_msgTypes[2].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rates_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_rates_proto_goTypes,
+		DependencyIndexes: file_rates_proto_depIdxs,
+		EnumInfos:         file_rates_proto_enumTypes,
+		MessageInfos:      file_rates_proto_msgTypes,
+	}.Build()
+	File_rates_proto = out.File
+	file_rates_proto_rawDesc = nil
+	file_rates_proto_goTypes = nil
+	file_rates_proto_depIdxs = nil
+}
@@ -0,0 +1,173 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: rates.proto
+
+package ratespb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RateService_LookupRates_FullMethodName = "/healthcaresaver.rates.v1.RateService/LookupRates"
+	RateService_ExportRates_FullMethodName = "/healthcaresaver.rates.v1.RateService/ExportRates"
+)
+
+// RateServiceClient is the client API for RateService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// RateService gives typed access to the current negotiated rates
+type RateServiceClient interface {
+	// LookupRates returns the current rates of a billing code at a provider
+	LookupRates(ctx context.Context, in *LookupRatesRequest, opts ...grpc.CallOption) (*LookupRatesResponse, error)
+	// ExportRates streams every matching service with all of its current
+	// rates and prices, one message per service, in service id order
+	ExportRates(ctx context.Context, in *ExportRatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ServiceRates], error)
+}
+
+type rateServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRateServiceClient(cc grpc.ClientConnInterface) RateServiceClient {
+	return &rateServiceClient{cc}
+}
+
+func (c *rateServiceClient) LookupRates(ctx context.Context, in *LookupRatesRequest, opts ...grpc.CallOption) (*LookupRatesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LookupRatesResponse)
+	err := c.cc.Invoke(ctx, RateService_LookupRates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rateServiceClient) ExportRates(ctx context.Context, in *ExportRatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ServiceRates], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RateService_ServiceDesc.Streams[0], RateService_ExportRates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportRatesRequest, ServiceRates]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RateService_ExportRatesClient = grpc.ServerStreamingClient[ServiceRates]
+
+// RateServiceServer is the server API for RateService service.
+// All implementations must embed UnimplementedRateServiceServer
+// for forward compatibility.
+//
+// RateService gives typed access to the current negotiated rates
+type RateServiceServer interface {
+	// LookupRates returns the current rates of a billing code at a provider
+	LookupRates(context.Context, *LookupRatesRequest) (*LookupRatesResponse, error)
+	// ExportRates streams every matching service with all of its current
+	// rates and prices, one message per service, in service id order
+	ExportRates(*ExportRatesRequest, grpc.ServerStreamingServer[ServiceRates]) error
+	mustEmbedUnimplementedRateServiceServer()
+}
+
+// UnimplementedRateServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRateServiceServer struct{}
+
+func (UnimplementedRateServiceServer) LookupRates(context.Context, *LookupRatesRequest) (*LookupRatesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupRates not implemented")
+}
+func (UnimplementedRateServiceServer) ExportRates(*ExportRatesRequest, grpc.ServerStreamingServer[ServiceRates]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportRates not implemented")
+}
+func (UnimplementedRateServiceServer) mustEmbedUnimplementedRateServiceServer() {}
+func (UnimplementedRateServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeRateServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RateServiceServer will
+// result in compilation errors.
+type UnsafeRateServiceServer interface {
+	mustEmbedUnimplementedRateServiceServer()
+}
+
+func RegisterRateServiceServer(s grpc.ServiceRegistrar, srv RateServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRateServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RateService_ServiceDesc, srv)
+}
+
+func _RateService_LookupRates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupRatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateServiceServer).LookupRates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateService_LookupRates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateServiceServer).LookupRates(ctx, req.(*LookupRatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RateService_ExportRates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RateServiceServer).ExportRates(m, &grpc.GenericServerStream[ExportRatesRequest, ServiceRates]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RateService_ExportRatesServer = grpc.ServerStreamingServer[ServiceRates]
+
+// RateService_ServiceDesc is the grpc.ServiceDesc for RateService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RateService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "healthcaresaver.rates.v1.RateService",
+	HandlerType: (*RateServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "LookupRates",
+			Handler:    _RateService_LookupRates_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportRates",
+			Handler:       _RateService_ExportRates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rates.proto",
+}
@@ -0,0 +1,136 @@
+// Package grpcapi serves negotiated rates over gRPC, for internal services
+// that want typed, low-latency access. It reads through the same store as
+// the HTTP API.
+package grpcapi
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"healthcare-saver-ingest/internal/auth"
+	"healthcare-saver-ingest/internal/grpcapi/ratespb"
+	"healthcare-saver-ingest/internal/store"
+)
+
+// maxExportBatchSize is the largest batch size an export may ask for
+const maxExportBatchSize = 1000
+
+// Server implements ratespb.RateServiceServer over a store
+type Server struct {
+	ratespb.UnimplementedRateServiceServer
+	store *store.Store
+}
+
+// NewServer creates a gRPC server with the rate service registered. With a
+// limiter, every call needs an API key within its limits, like the HTTP API.
+func NewServer(st *store.Store, limiter *auth.Limiter) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{logUnary}
+	stream := []grpc.StreamServerInterceptor{logStream}
+	if limiter != nil {
+		unary = append(unary, authUnary(limiter))
+		stream = append(stream, authStream(limiter))
+	}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+	ratespb.RegisterRateServiceServer(server, &Server{store: st})
+	return server
+}
+
+// LookupRates implements ratespb.RateServiceServer
+func (s *Server) LookupRates(ctx context.Context, req *ratespb.LookupRatesRequest) (*ratespb.LookupRatesResponse, error) {
+	if req.BillingCodeType == "" || req.BillingCode == "" {
+		return nil, status.Error(codes.InvalidArgument, "billing_code_type and billing_code are required")
+	}
+	if req.Npi == "" {
+		return nil, status.Error(codes.InvalidArgument, "npi is required")
+	}
+	if !validNPI(req.Npi) {
+		return nil, status.Error(codes.InvalidArgument, "npi must be 10 digits")
+	}
+
+	results, err := s.store.LookupRates(ctx, store.LookupFilter{
+		BillingCodeType: req.BillingCodeType,
+		BillingCode:     req.BillingCode,
+		NPI:             req.Npi,
+		Payer:           req.Payer,
+		Plan:            req.Plan,
+		BillingClass:    billingClassName(req.BillingClass),
+	})
+	if err != nil {
+		return nil, internalError(err)
+	}
+
+	response := &ratespb.LookupRatesResponse{Services: make([]*ratespb.ServiceRates, len(results))}
+	for i, result := range results {
+		response.Services[i] = toServiceRates(result)
+	}
+	return response, nil
+}
+
+// ExportRates implements ratespb.RateServiceServer
+func (s *Server) ExportRates(req *ratespb.ExportRatesRequest, stream ratespb.RateService_ExportRatesServer) error {
+	if req.BatchSize < 0 || req.BatchSize > maxExportBatchSize {
+		return status.Errorf(codes.InvalidArgument, "batch_size must be between 0 and %d", maxExportBatchSize)
+	}
+
+	err := s.store.ExportRates(stream.Context(), store.ExportFilter{
+		BillingCodeType: req.BillingCodeType,
+		BillingCodes:    req.BillingCodes,
+		Payer:           req.Payer,
+		Plan:            req.Plan,
+	}, int(req.BatchSize), func(result store.ServiceRates) error {
+		return stream.Send(toServiceRates(result))
+	})
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		if ctxErr := stream.Context().Err(); ctxErr != nil {
+			return status.FromContextError(ctxErr).Err()
+		}
+		return internalError(err)
+	}
+	return nil
+}
+
+// internalError logs an unexpected error and hides its details from clients
+func internalError(err error) error {
+	log.Printf("❌ gRPC request failed: %v", err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// validNPI reports whether npi has the 10 digits of an NPI
+func validNPI(npi string) bool {
+	if len(npi) != 10 {
+		return false
+	}
+	for _, c := range npi {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// logUnary logs unary calls like the HTTP server logs requests
+func logUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	log.Printf("gRPC %s %s %s", info.FullMethod, status.Code(err), time.Since(start).Round(time.Microsecond))
+	return resp, err
+}
+
+// logStream logs streaming calls when they end
+func logStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	log.Printf("gRPC %s %s %s", info.FullMethod, status.Code(err), time.Since(start).Round(time.Microsecond))
+	return err
+}
@@ -0,0 +1,100 @@
+// Package medicare computes Medicare benchmark amounts from the CMS fee
+// schedules loaded by the ingestion tool: the Physician Fee Schedule (RVUs,
+// GPCIs and conversion factor) for professional rates and the OPPS payment
+// rates (Addendum B) for institutional ones. Employers compare networks as a
+// percent of these amounts.
+package medicare
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// AmountSQL returns the expression for the Medicare amount of a billing code
+// and billing class, given as SQL expressions. Professional amounts are
+// priced in the PFS locality given by the expression locality ("NULL" for the
+// national amount, with every GPCI at 1), using the non-facility practice
+// expense unless the code is only paid in facilities. Institutional amounts
+// are the national OPPS payment rates. The expression is NULL for codes the
+// schedules do not price.
+func AmountSQL(codeType, code, billingClass, locality string) string {
+	return fmt.Sprintf(`(CASE WHEN %[1]s NOT IN ('CPT', 'HCPCS') THEN NULL
+		WHEN %[3]s = 'professional' THEN (
+			SELECT ROUND((v.work_rvu * COALESCE(g.work_gpci, 1)
+				+ COALESCE(v.nonfacility_pe_rvu, v.facility_pe_rvu) * COALESCE(g.pe_gpci, 1)
+				+ v.mp_rvu * COALESCE(g.mp_gpci, 1)) * v.conversion_factor, 2)
+			FROM medicare_pfs_rvus v
+			LEFT JOIN medicare_gpcis g ON g.locality_id = %[4]s
+			WHERE v.billing_code = %[2]s)
+		ELSE (SELECT o.payment_rate FROM medicare_opps_rates o WHERE o.billing_code = %[2]s) END)`,
+		codeType, code, billingClass, locality)
+}
+
+// Amounts returns the Medicare amounts of a billing code by billing class in
+// a PFS locality ("" for national amounts)
+func Amounts(ctx context.Context, db *sql.DB, codeType, code, locality string) (map[string]float64, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT k.billing_class, `+AmountSQL("c.billing_code_type", "c.billing_code", "k.billing_class", "c.locality_id")+`
+		FROM (SELECT ? AS billing_code_type, ? AS billing_code, NULLIF(?, '') AS locality_id) c
+		CROSS JOIN (SELECT 'professional' AS billing_class UNION ALL SELECT 'institutional') k
+	`, codeType, code, locality)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Medicare amounts: %v", err)
+	}
+	defer rows.Close()
+
+	amounts := make(map[string]float64)
+	for rows.Next() {
+		var billingClass string
+		var amount sql.NullFloat64
+		if err := rows.Scan(&billingClass, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan Medicare amount: %v", err)
+		}
+		if amount.Valid && amount.Float64 > 0 {
+			amounts[billingClass] = amount.Float64
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Medicare amounts: %v", err)
+	}
+	return amounts, nil
+}
+
+// ProviderLocality returns the PFS locality of a provider by the ZIP code of
+// its practice address, or "" when the provider or its ZIP code is unknown
+func ProviderLocality(ctx context.Context, db *sql.DB, npi string) (string, error) {
+	var locality string
+	err := db.QueryRowContext(ctx, `
+		SELECT l.locality_id FROM providers p
+		JOIN medicare_zip_localities l ON l.zip = p.address_zip5
+		WHERE p.npi = ?
+	`, npi).Scan(&locality)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find Medicare locality: %v", err)
+	}
+	return locality, nil
+}
+
+// Pct returns a dollar amount as a percent of a Medicare amount, to one
+// decimal, or nil when either is unknown
+func Pct(amount, medicareAmount *float64) *float64 {
+	if amount == nil || medicareAmount == nil || *medicareAmount <= 0 {
+		return nil
+	}
+	pct := math.Round(*amount / *medicareAmount * 1000) / 10
+	return &pct
+}
+
+// Lookup returns the amount of a billing class as a pointer, nil when absent
+func Lookup(amounts map[string]float64, billingClass string) *float64 {
+	amount, ok := amounts[billingClass]
+	if !ok {
+		return nil
+	}
+	return &amount
+}
@@ -0,0 +1,166 @@
+package medicare
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// testDB opens the MySQL database of TEST_MYSQL_DSN with a single connection,
+// so temporary tables shadow the real ones for every query of the test
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// loadSchedules creates temporary fee schedule tables holding a facility and
+// non-facility priced office visit, a facility-only knee replacement, one
+// locality and the OPPS rate of an MRI
+func loadSchedules(t *testing.T, db *sql.DB) {
+	t.Helper()
+	for _, stmt := range []string{
+		`CREATE TEMPORARY TABLE medicare_pfs_rvus (billing_code VARCHAR(50) PRIMARY KEY,
+			work_rvu DECIMAL(8,2) NOT NULL, nonfacility_pe_rvu DECIMAL(8,2) NULL, facility_pe_rvu DECIMAL(8,2) NULL,
+			mp_rvu DECIMAL(8,2) NOT NULL, conversion_factor DECIMAL(10,4) NOT NULL)`,
+		`CREATE TEMPORARY TABLE medicare_gpcis (locality_id VARCHAR(10) PRIMARY KEY,
+			work_gpci DECIMAL(6,3) NOT NULL, pe_gpci DECIMAL(6,3) NOT NULL, mp_gpci DECIMAL(6,3) NOT NULL)`,
+		`CREATE TEMPORARY TABLE medicare_opps_rates (billing_code VARCHAR(50) PRIMARY KEY, payment_rate DECIMAL(15,2) NOT NULL)`,
+		`INSERT INTO medicare_pfs_rvus VALUES ('99213', 1.30, 1.43, 0.55, 0.10, 33.2875), ('27447', 19.60, NULL, 11.00, 3.90, 33.2875)`,
+		`INSERT INTO medicare_gpcis VALUES ('0111201', 1.000, 1.050, 0.800)`,
+		`INSERT INTO medicare_opps_rates VALUES ('70551', 245.67)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to load schedules: %v", err)
+		}
+	}
+}
+
+func TestAmountSQL(t *testing.T) {
+	db := testDB(t)
+	loadSchedules(t, db)
+
+	for _, tc := range []struct {
+		name               string
+		codeType, code     string
+		locality           string
+		professional, inst *float64
+	}{
+		// (1.30 + 1.43 + 0.10) × 33.2875
+		{"national, non-facility practice expense", "CPT", "99213", "", ptr(94.20), nil},
+		// (1.30 × 1 + 1.43 × 1.05 + 0.10 × 0.8) × 33.2875
+		{"locality", "CPT", "99213", "0111201", ptr(95.92), nil},
+		{"locality without GPCIs", "CPT", "99213", "9999999", ptr(94.20), nil},
+		// (19.60 + 11.00 + 3.90) × 33.2875
+		{"facility practice expense of a facility-only code", "CPT", "27447", "", ptr(1148.42), nil},
+		{"OPPS payment rate", "HCPCS", "70551", "", nil, ptr(245.67)},
+		{"code type Medicare does not price", "MS-DRG", "99213", "", nil, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			amounts, err := Amounts(context.Background(), db, tc.codeType, tc.code, tc.locality)
+			if err != nil {
+				t.Fatalf("Amounts: %v", err)
+			}
+			for billingClass, want := range map[string]*float64{"professional": tc.professional, "institutional": tc.inst} {
+				got := Lookup(amounts, billingClass)
+				if (got == nil) != (want == nil) || (got != nil && *got != *want) {
+					t.Errorf("%s amount = %v, want %v", billingClass, deref(got), deref(want))
+				}
+			}
+		})
+	}
+}
+
+func TestAmounts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	// Classes the schedules do not price come back NULL and are left out
+	mock.ExpectQuery("FROM medicare_pfs_rvus v").WithArgs("CPT", "99213", "0111201").
+		WillReturnRows(sqlmock.NewRows([]string{"billing_class", "amount"}).
+			AddRow("professional", 95.92).AddRow("institutional", nil))
+	amounts, err := Amounts(context.Background(), db, "CPT", "99213", "0111201")
+	if err != nil {
+		t.Fatalf("Amounts: %v", err)
+	}
+	if len(amounts) != 1 || amounts["professional"] != 95.92 {
+		t.Errorf("amounts = %v, want only the professional amount", amounts)
+	}
+
+	mock.ExpectQuery("FROM medicare_pfs_rvus v").WithArgs("CPT", "99999", "").
+		WillReturnRows(sqlmock.NewRows([]string{"billing_class", "amount"}).
+			AddRow("professional", 0).AddRow("institutional", nil))
+	if amounts, err := Amounts(context.Background(), db, "CPT", "99999", ""); err != nil || len(amounts) != 0 {
+		t.Errorf("Amounts of a zero-RVU code = %v, %v, want none", amounts, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProviderLocality(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to create mock database: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM providers p").WithArgs("1234567893").
+		WillReturnRows(sqlmock.NewRows([]string{"locality_id"}).AddRow("0111201"))
+	mock.ExpectQuery("FROM providers p").WithArgs("1111111111").
+		WillReturnRows(sqlmock.NewRows([]string{"locality_id"}))
+
+	for _, tc := range []struct{ npi, want string }{{"1234567893", "0111201"}, {"1111111111", ""}} {
+		if locality, err := ProviderLocality(context.Background(), db, tc.npi); err != nil || locality != tc.want {
+			t.Errorf("ProviderLocality(%s) = %q, %v, want %q", tc.npi, locality, err, tc.want)
+		}
+	}
+}
+
+func TestPct(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		amount, medicare *float64
+		want             *float64
+	}{
+		{"percent to one decimal", ptr(150), ptr(94.20), ptr(159.2)},
+		{"below Medicare", ptr(80), ptr(100), ptr(80)},
+		{"unknown amount", nil, ptr(100), nil},
+		{"unknown Medicare amount", ptr(150), nil, nil},
+		{"zero Medicare amount", ptr(150), ptr(0), nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Pct(tc.amount, tc.medicare)
+			if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+				t.Errorf("Pct = %v, want %v", deref(got), deref(tc.want))
+			}
+		})
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
+
+// deref formats an optional amount for test messages
+func deref(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -0,0 +1,137 @@
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Name sets the component name of v's struct type, for types whose Go name
+// is ambiguous or unexported. It must be called before the type is used.
+func (d *Document) Name(v interface{}, name string) {
+	d.names[reflect.TypeOf(v)] = name
+}
+
+// SchemaOf returns the schema of the JSON encoding of v's type. Named
+// structs become component schemas named after the type and are referred
+// to. Fields follow their json tags: omitempty fields are optional, pointer
+// fields are nullable, an enum:"Name" tag refers to an enumeration added with
+// Enum and a format:"..." tag sets the format of a string.
+func (d *Document) SchemaOf(v interface{}) *Schema {
+	return d.schema(reflect.TypeOf(v))
+}
+
+// schema returns the schema of a type
+func (d *Document) schema(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Pointer:
+		return nullable(d.schema(t.Elem()))
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schema(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		return d.component(t)
+	}
+	panic(fmt.Sprintf("openapi: unsupported type %v", t))
+}
+
+// component returns a reference to the component schema of a named struct,
+// adding it the first time
+func (d *Document) component(t reflect.Type) *Schema {
+	if name, ok := d.types[t]; ok {
+		return Ref(name)
+	}
+
+	// Unexported request and response types get exported names
+	name, ok := d.names[t]
+	if !ok {
+		name = exportName(t.Name())
+	}
+	if _, taken := d.Components.Schemas[name]; taken {
+		panic(fmt.Sprintf("openapi: two types named %s", name))
+	}
+
+	// Register the name first, for recursive types
+	d.types[t] = name
+	d.Components.Schemas[name] = &Schema{}
+	*d.Components.Schemas[name] = *d.structSchema(t)
+	return Ref(name)
+}
+
+// structSchema returns the object schema of a struct's fields
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	d.addFields(s, t)
+	return s
+}
+
+// addFields adds the JSON fields of a struct to an object schema, including
+// those of embedded structs
+func (d *Document) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			d.addFields(s, field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := d.schema(field.Type)
+		if enum := field.Tag.Get("enum"); enum != "" {
+			property = Ref(enum)
+			if field.Type.Kind() == reflect.Pointer {
+				property = nullable(property)
+			}
+		}
+		if format := field.Tag.Get("format"); format != "" {
+			property.Format = format
+		}
+
+		s.Properties[name] = property
+		if !strings.Contains(options, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// nullable returns a schema that also allows null. References cannot have
+// siblings in OpenAPI 3.0, so they are wrapped in allOf.
+func nullable(s *Schema) *Schema {
+	if s.Ref != "" {
+		return &Schema{AllOf: []*Schema{s}, Nullable: true}
+	}
+	s.Nullable = true
+	return s
+}
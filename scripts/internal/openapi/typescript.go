@@ -0,0 +1,324 @@
+package openapi
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TypeScript writes a TypeScript module with an interface for every
+// component schema and a fetch-based client with a method per operation
+func (d *Document) TypeScript(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("// Code generated from the OpenAPI document of the price API; DO NOT EDIT.\n")
+	b.WriteString("// Regenerate with `npm run api:generate`.\n")
+
+	names := make([]string, 0, len(d.Components.Schemas))
+	for name := range d.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := d.Components.Schemas[name]
+		b.WriteString("\n")
+		writeDoc(&b, "", s.Description)
+		if s.Type == "object" && len(s.Properties) > 0 {
+			fmt.Fprintf(&b, "export interface %s %s\n", name, d.tsObject(s, ""))
+		} else {
+			fmt.Fprintf(&b, "export type %s = %s;\n", name, d.tsType(s, ""))
+		}
+	}
+
+	var methods strings.Builder
+	var err error
+	d.Operations(func(method, path string, op *Operation) {
+		if err == nil {
+			err = d.tsOperation(&b, &methods, method, path, op)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	b.WriteString(tsClientHead)
+	b.WriteString(methods.String())
+	b.WriteString(tsClientTail)
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// tsType returns the TypeScript type of a schema; indent is the indentation
+// of the line the type starts on
+func (d *Document) tsType(s *Schema, indent string) string {
+	t := d.tsBaseType(s, indent)
+	if s.Nullable {
+		t += " | null"
+	}
+	return t
+}
+
+// tsBaseType returns the TypeScript type of a schema, ignoring nullable
+func (d *Document) tsBaseType(s *Schema, indent string) string {
+	switch {
+	case s.Ref != "":
+		return refName(s.Ref)
+	case len(s.AllOf) == 1:
+		return d.tsType(s.AllOf[0], indent)
+	case len(s.AnyOf) > 0:
+		types := make([]string, len(s.AnyOf))
+		for i, part := range s.AnyOf {
+			types[i] = d.tsType(part, indent)
+		}
+		return strings.Join(types, " | ")
+	}
+
+	switch s.Type {
+	case "string":
+		if len(s.Enum) > 0 {
+			values := make([]string, len(s.Enum))
+			for i, value := range s.Enum {
+				values[i] = strconv.Quote(value)
+			}
+			return strings.Join(values, " | ")
+		}
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		item := d.tsType(s.Items, indent)
+		if strings.Contains(item, " | ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if len(s.Properties) > 0 {
+			return d.tsObject(s, indent)
+		}
+		if s.AdditionalProperties != nil {
+			return "Record<string, " + d.tsType(s.AdditionalProperties, indent) + ">"
+		}
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+// tsObject returns the TypeScript object type of an object schema
+func (d *Document) tsObject(s *Schema, indent string) string {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range names {
+		property := s.Properties[name]
+		optional := "?"
+		if contains(s.Required, name) {
+			optional = ""
+		}
+		writeDoc(&b, indent+"  ", property.Description)
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, tsName(name), optional, d.tsType(property, indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// tsOperation writes the parameter interface of an operation to types and
+// its client method to methods
+func (d *Document) tsOperation(types, methods *strings.Builder, method, path string, op *Operation) error {
+	response, err := d.successSchema(op)
+	if err != nil {
+		return err
+	}
+	result := "unknown"
+	if response != nil {
+		result = d.tsType(response, "    ")
+	}
+
+	var args []string
+	query := "{}"
+	urlPath := strconv.Quote(path)
+
+	if len(op.Parameters) > 0 {
+		paramsType := exportName(op.OperationID) + "Params"
+		params := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		var queryFields []string
+		for _, p := range op.Parameters {
+			schema := *p.Schema
+			if schema.Description == "" {
+				schema.Description = p.Description
+			}
+			params.Properties[p.Name] = &schema
+			if p.Required {
+				params.Required = append(params.Required, p.Name)
+			}
+			switch p.In {
+			case "path":
+				urlPath = strings.ReplaceAll(urlPath, "{"+p.Name+"}", "${encodeURIComponent(String(params."+p.Name+"))}")
+			case "query":
+				queryFields = append(queryFields, tsName(p.Name)+": params."+p.Name)
+			}
+		}
+		if len(queryFields) > 0 {
+			query = "{ " + strings.Join(queryFields, ", ") + " }"
+		}
+		if strings.Contains(urlPath, "${") {
+			urlPath = "`" + urlPath[1:len(urlPath)-1] + "`"
+		}
+
+		types.WriteString("\n")
+		writeDoc(types, "", "Parameters of "+op.OperationID)
+		fmt.Fprintf(types, "export interface %s %s\n", paramsType, d.tsObject(params, ""))
+
+		if len(params.Required) == 0 {
+			args = append(args, "params: "+paramsType+" = {}")
+		} else {
+			args = append(args, "params: "+paramsType)
+		}
+	}
+
+	body := "undefined"
+	if op.RequestBody != nil {
+		args = append(args, "body: "+d.tsType(op.RequestBody.Content["application/json"].Schema, "    "))
+		body = "body"
+	}
+	args = append(args, "init?: RequestInit")
+
+	summary := op.Summary
+	if op.Description != "" {
+		summary += "\n\n" + op.Description
+	}
+	writeDoc(methods, "    ", summary)
+	fmt.Fprintf(methods, "    %s: (%s) =>\n      request<%s>(%q, %s, %s, %s, init),\n",
+		op.OperationID, strings.Join(args, ", "), result, strings.ToUpper(method), urlPath, query, body)
+	return nil
+}
+
+// successSchema returns the body schema of the 2xx response of an operation
+func (d *Document) successSchema(op *Operation) (*Schema, error) {
+	for code, response := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			if media, ok := response.Content["application/json"]; ok {
+				return media.Schema, nil
+			}
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("operation %s has no success response", op.OperationID)
+}
+
+// writeDoc writes a JSDoc comment, or nothing without text
+func writeDoc(b *strings.Builder, indent, text string) {
+	if text == "" {
+		return
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, text)
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s *%s\n", indent, strings.TrimRight(" "+line, " "))
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+// tsName returns a property name, quoted unless it is an identifier
+func tsName(name string) string {
+	for i, c := range name {
+		if !(c == '_' || c == '$' || unicode.IsLetter(c) || (i > 0 && unicode.IsDigit(c))) {
+			return strconv.Quote(name)
+		}
+	}
+	return name
+}
+
+// exportName returns an identifier with its first letter in upper case
+func exportName(name string) string {
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+const tsClientHead = `
+/** Options of createClient */
+export interface ClientOptions {
+  /** Base URL of the API, e.g. http://localhost:8080 */
+  baseUrl: string;
+  /** API key, for servers that require one */
+  apiKey?: string;
+  /** fetch implementation, the global fetch by default */
+  fetch?: typeof fetch;
+}
+
+/** Thrown for responses with an error status */
+export class ApiError extends Error {
+  readonly status: number;
+  readonly body: ErrorResponse | undefined;
+  /** Seconds to wait before retrying, from Retry-After */
+  readonly retryAfter: number | undefined;
+
+  constructor(status: number, body: ErrorResponse | undefined, retryAfter: number | undefined) {
+    super(body?.error ?? ` + "`request failed with status ${status}`" + `);
+    this.name = "ApiError";
+    this.status = status;
+    this.body = body;
+    this.retryAfter = retryAfter;
+  }
+}
+
+type Query = Record<string, string | number | boolean | undefined>;
+
+/** Creates a client of the price API */
+export function createClient(options: ClientOptions) {
+  const doFetch = options.fetch ?? fetch;
+
+  async function request<T>(method: string, path: string, query: Query, body: unknown, init?: RequestInit): Promise<T> {
+    const params = new URLSearchParams();
+    for (const [name, value] of Object.entries(query)) {
+      if (value !== undefined) {
+        params.set(name, String(value));
+      }
+    }
+    const search = params.toString();
+
+    const headers = new Headers(init?.headers);
+    if (options.apiKey) {
+      headers.set("Authorization", ` + "`Bearer ${options.apiKey}`" + `);
+    }
+    if (body !== undefined) {
+      headers.set("Content-Type", "application/json");
+    }
+
+    const response = await doFetch(` + "`${options.baseUrl}${path}${search ? `?${search}` : \"\"}`" + `, {
+      ...init,
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (!response.ok) {
+      const error = (await response.json().catch(() => undefined)) as ErrorResponse | undefined;
+      const retryAfter = response.headers.get("Retry-After");
+      throw new ApiError(response.status, error, retryAfter ? Number(retryAfter) : undefined);
+    }
+    return (await response.json()) as T;
+  }
+
+  return {
+`
+
+const tsClientTail = `  };
+}
+
+/** A client of the price API */
+export type Client = ReturnType<typeof createClient>;
+`
@@ -0,0 +1,241 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateJSON checks a JSON document against a schema
+func (d *Document) ValidateJSON(s *Schema, data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %v", err)
+	}
+	return d.Validate(s, value)
+}
+
+// Validate checks a value decoded by encoding/json with UseNumber against a
+// schema. Errors name the path of the offending value, like
+// results[0].cashPrice.
+func (d *Document) Validate(s *Schema, value interface{}) error {
+	return d.validate(s, value, "")
+}
+
+// validate checks value at path against s
+func (d *Document) validate(s *Schema, value interface{}, path string) error {
+	s, err := d.resolve(s)
+	if err != nil {
+		return err
+	}
+
+	if value == nil {
+		if s.Nullable || (s.Type == "" && len(s.AllOf) == 0 && len(s.AnyOf) == 0) {
+			return nil
+		}
+		return pathError(path, "must not be null")
+	}
+	for _, part := range s.AllOf {
+		if err := d.validate(part, value, path); err != nil {
+			return err
+		}
+	}
+
+	if len(s.AnyOf) > 0 {
+		var first error
+		for _, part := range s.AnyOf {
+			err := d.validate(part, value, path)
+			if err == nil {
+				return nil
+			}
+			if first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return pathError(path, "must be an object")
+		}
+		return d.validateObject(s, object, path)
+
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return pathError(path, "must be an array")
+		}
+		for i, item := range array {
+			if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return pathError(path, "must be a string")
+		}
+		return validateString(s, str, path)
+
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return pathError(path, "must be a number")
+		}
+		n, err := number.Float64()
+		if err != nil {
+			return pathError(path, "must be a number")
+		}
+		return validateNumber(s, n, path)
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return pathError(path, "must be a boolean")
+		}
+	}
+	return nil
+}
+
+// validateObject checks the properties of an object. Properties the schema
+// does not know are allowed, as OpenAPI does by default.
+func (d *Document) validateObject(s *Schema, object map[string]interface{}, path string) error {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			return pathError(join(path, name), "is required")
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property := s.Properties[name]
+		if property == nil {
+			property = s.AdditionalProperties
+		}
+		if property == nil {
+			continue
+		}
+		if err := d.validate(property, object[name], join(path, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateString checks the enumeration, pattern and format of a string
+func validateString(s *Schema, str, path string) error {
+	if len(s.Enum) > 0 && !contains(s.Enum, str) {
+		return pathError(path, "must be one of "+strings.Join(s.Enum, ", "))
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", s.Pattern, err)
+		}
+		if !re.MatchString(str) {
+			return pathError(path, "must match "+s.Pattern)
+		}
+	}
+	switch s.Format {
+	case "date":
+		if _, err := time.Parse(time.DateOnly, str); err != nil {
+			return pathError(path, "must be a date (YYYY-MM-DD)")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			return pathError(path, "must be a date-time")
+		}
+	}
+	return nil
+}
+
+// validateNumber checks the type and range of a number
+func validateNumber(s *Schema, n float64, path string) error {
+	if s.Type == "integer" && n != math.Trunc(n) {
+		return pathError(path, "must be an integer")
+	}
+	if s.Minimum != nil && n < *s.Minimum {
+		return pathError(path, "must be at least "+formatNumber(*s.Minimum))
+	}
+	if s.Maximum != nil && n > *s.Maximum {
+		return pathError(path, "must be at most "+formatNumber(*s.Maximum))
+	}
+	return nil
+}
+
+// ValidateParameter checks the raw value of a path or query parameter
+func (d *Document) ValidateParameter(p Parameter, raw string) error {
+	s, err := d.resolve(p.Schema)
+	if err != nil {
+		return err
+	}
+
+	path := p.Name
+	switch s.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return pathError(path, "must be a number")
+		}
+		return validateNumber(s, n, path)
+	case "boolean":
+		if _, err := strconv.ParseBool(raw); err != nil {
+			return pathError(path, "must be a boolean")
+		}
+		return nil
+	default:
+		return validateString(s, raw, path)
+	}
+}
+
+// Float returns a pointer to a bound of a schema
+func Float(n float64) *float64 {
+	return &n
+}
+
+// pathError returns an error about the value at path
+func pathError(path, message string) error {
+	if path == "" {
+		return fmt.Errorf("value %s", message)
+	}
+	return fmt.Errorf("%s %s", path, message)
+}
+
+// join returns the path of a property
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// contains reports whether values has value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNumber formats a bound without a needless fraction
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
@@ -0,0 +1,459 @@
+// Package outlier flags implausible negotiated rates for review. Payer files
+// carry obvious garbage, like $0.01 MRIs or $9,999,999 office visits, that
+// would skew every statistic built on them. Dollar rates are compared with
+// the robust statistics (median and MAD) of the current rates of their
+// billing code and billing class; prices spread multiplicatively, so the
+// robust z-score is computed on the log of the rate. Flags land in the
+// rate_outliers review table, and extreme rates can be quarantined: they stay
+// in negotiated_rates but the active_negotiated_rates view hides them.
+package outlier
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// madScale makes the MAD a consistent estimator of the standard deviation of
+// normally distributed values
+const madScale = 1.4826
+
+// minLogScale is the smallest spread assumed for a group, in log units. Many
+// codes have identical rates (MAD 0), which would flag any other rate.
+const minLogScale = 0.25
+
+// Options are the detection thresholds
+type Options struct {
+	// Threshold is the absolute robust z-score above which a rate is flagged
+	Threshold float64
+	// QuarantineThreshold is the absolute robust z-score above which a rate is extreme
+	QuarantineThreshold float64
+	// MaxPercentage is the largest plausible percentage rate
+	MaxPercentage float64
+	// MinSample is the number of dollar rates a group needs for z-scores
+	MinSample int
+}
+
+// DefaultOptions flag rates about 3.5 times off the median of a group with
+// identical rates, and quarantine those 12 times off
+var DefaultOptions = Options{Threshold: 5, QuarantineThreshold: 10, MaxPercentage: 1000, MinSample: 5}
+
+// Code is the unit of analysis: the current rates of one billing code across
+// all payers
+type Code struct {
+	BillingCodeType string
+	BillingCode     string
+}
+
+// Result counts the rates an analysis flagged and quarantined
+type Result struct {
+	Flagged     int64
+	Quarantined int64
+	// QuarantinedCodes are the codes whose published rates changed
+	QuarantinedCodes []Code
+}
+
+// Detector analyzes rates into the rate_outliers table
+type Detector struct {
+	db   *sql.DB
+	opts Options
+
+	// RunID is the run being ingested, 0 for none: its rates count as current
+	// before a staged run is activated, and only its rates are quarantined
+	RunID int64
+	// Quarantine hides extreme rates, those of RunID when it is set and any
+	// current rate otherwise
+	Quarantine bool
+}
+
+// New creates a detector on an open database
+func New(db *sql.DB, opts Options) *Detector {
+	return &Detector{db: db, opts: opts}
+}
+
+// RunCodes returns the billing codes of the rates a run inserted, extended
+// or closed, whose populations it changed
+func (d *Detector) RunCodes(ctx context.Context, runID int64) ([]Code, error) {
+	return d.codes(ctx, `
+		SELECT DISTINCT s.billing_code_type, s.billing_code
+		FROM negotiated_rates r
+		JOIN insurance_services s ON s.id = r.service_id
+		WHERE r.run_id = ? OR r.last_seen_run_id = ? OR r.closed_run_id = ?
+	`, runID, runID, runID)
+}
+
+// AllCodes returns every billing code with current rates or flags,
+// optionally only one billing code
+func (d *Detector) AllCodes(ctx context.Context, codeType, code string) ([]Code, error) {
+	rateWhere, outlierWhere, args := "", "", []interface{}{}
+	if code != "" {
+		rateWhere = " AND s.billing_code_type = ? AND s.billing_code = ?"
+		outlierWhere = " WHERE billing_code_type = ? AND billing_code = ?"
+		args = []interface{}{codeType, code, codeType, code}
+	}
+	return d.codes(ctx, `
+		SELECT s.billing_code_type, s.billing_code
+		FROM active_insurance_services s
+		JOIN active_negotiated_rates r ON r.service_id = s.id
+		WHERE r.valid_to IS NULL`+rateWhere+`
+		UNION
+		SELECT billing_code_type, billing_code FROM rate_outliers`+outlierWhere+`
+		ORDER BY 1, 2
+	`, args...)
+}
+
+// codes runs a query returning billing code types and billing codes
+func (d *Detector) codes(ctx context.Context, query string, args ...interface{}) ([]Code, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query billing codes: %v", err)
+	}
+	defer rows.Close()
+
+	var codes []Code
+	for rows.Next() {
+		var c Code
+		if err := rows.Scan(&c.BillingCodeType, &c.BillingCode); err != nil {
+			return nil, fmt.Errorf("failed to scan billing code: %v", err)
+		}
+		codes = append(codes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read billing codes: %v", err)
+	}
+	return codes, nil
+}
+
+// Analyze flags the outliers of codes with the given number of workers,
+// calling progress (if not nil) after each code
+func (d *Detector) Analyze(ctx context.Context, codes []Code, workers int, progress func(done int)) (Result, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		done     int
+		result   Result
+		firstErr error
+	)
+	next := make(chan Code)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range next {
+				flagged, quarantined, err := d.analyzeCode(ctx, c)
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("failed to analyze %s %s: %v", c.BillingCodeType, c.BillingCode, err)
+					cancel()
+				}
+				result.Flagged += flagged
+				result.Quarantined += quarantined
+				if quarantined > 0 {
+					result.QuarantinedCodes = append(result.QuarantinedCodes, c)
+				}
+				done++
+				if err == nil && progress != nil {
+					progress(done)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, c := range codes {
+		select {
+		case next <- c:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return result, firstErr
+	}
+	return result, ctx.Err()
+}
+
+// rate is a current rate of a billing code
+type rate struct {
+	id                           int64
+	runID                        sql.NullInt64
+	billingClass, negotiatedType string
+	value                        float64
+	inRun                        bool
+}
+
+// stats are the robust statistics of the dollar rates of a billing class
+type stats struct {
+	count       int
+	median, mad float64 // dollars
+	logMedian   float64
+	logScale    float64
+}
+
+// finding is a flagged rate
+type finding struct {
+	rate    rate
+	stats   *stats
+	z       *float64
+	reason  string
+	extreme bool
+}
+
+// analyzeCode replaces the flags of a billing code and quarantines its
+// extreme rates. Released rates, accepted by a reviewer, are not flagged again.
+func (d *Detector) analyzeCode(ctx context.Context, c Code) (flagged, quarantined int64, err error) {
+	rates, err := d.loadRates(ctx, c)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	released := make(map[int64]bool)
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT negotiated_rate_id FROM rate_outliers
+		WHERE billing_code_type = ? AND billing_code = ? AND status = 'released'
+	`, c.BillingCodeType, c.BillingCode)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query released rates: %v", err)
+	}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("failed to scan released rate: %v", err)
+		}
+		released[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("failed to read released rates: %v", err)
+	}
+
+	var findings []finding
+	for _, f := range d.detect(rates) {
+		if !released[f.rate.id] {
+			findings = append(findings, f)
+		}
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM rate_outliers WHERE billing_code_type = ? AND billing_code = ? AND status = 'flagged'
+	`, c.BillingCodeType, c.BillingCode)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to delete flags: %v", err)
+	}
+
+	for _, f := range findings {
+		status := "flagged"
+		if f.extreme && d.Quarantine && (d.RunID == 0 || f.rate.inRun) {
+			status = "quarantined"
+			if _, err := tx.ExecContext(ctx, "UPDATE negotiated_rates SET quarantined = 1 WHERE id = ?", f.rate.id); err != nil {
+				return 0, 0, fmt.Errorf("failed to quarantine rate %d: %v", f.rate.id, err)
+			}
+			quarantined++
+		}
+
+		var median, mad sql.NullFloat64
+		sampleSize := 0
+		if f.stats != nil {
+			median = sql.NullFloat64{Float64: f.stats.median, Valid: true}
+			mad = sql.NullFloat64{Float64: f.stats.mad, Valid: true}
+			sampleSize = f.stats.count
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO rate_outliers (negotiated_rate_id, run_id, billing_code_type, billing_code, billing_class,
+				negotiated_type, negotiated_rate, median, mad, sample_size, robust_z, reason, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, f.rate.id, f.rate.runID, c.BillingCodeType, c.BillingCode, f.rate.billingClass,
+			f.rate.negotiatedType, f.rate.value, median, mad, sampleSize, f.z, f.reason, status)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to flag rate %d: %v", f.rate.id, err)
+		}
+		flagged++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return flagged, quarantined, nil
+}
+
+// loadRates returns the current rates of a billing code that are not
+// quarantined: the published ones and those of the run being ingested
+func (d *Detector) loadRates(ctx context.Context, c Code) ([]rate, error) {
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT r.id, r.run_id, r.billing_class, r.negotiated_type, r.negotiated_rate,
+			COALESCE(r.run_id = ? OR r.last_seen_run_id = ?, 0)
+		FROM insurance_services s
+		JOIN negotiated_rates r ON r.service_id = s.id
+		LEFT JOIN active_negotiated_rates a ON a.id = r.id
+		WHERE s.billing_code_type = ? AND s.billing_code = ? AND r.valid_to IS NULL AND r.quarantined = 0
+			AND (a.id IS NOT NULL OR r.run_id = ? OR r.last_seen_run_id = ?)
+		ORDER BY r.id
+	`, d.RunID, d.RunID, c.BillingCodeType, c.BillingCode, d.RunID, d.RunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rates: %v", err)
+	}
+	defer rows.Close()
+
+	var rates []rate
+	for rows.Next() {
+		var r rate
+		if err := rows.Scan(&r.id, &r.runID, &r.billingClass, &r.negotiatedType, &r.value, &r.inRun); err != nil {
+			return nil, fmt.Errorf("failed to scan rate: %v", err)
+		}
+		rates = append(rates, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rates: %v", err)
+	}
+	return rates, nil
+}
+
+// detect returns the outliers among the current rates of a billing code
+func (d *Detector) detect(rates []rate) []finding {
+	groups := make(map[string][]float64)
+	for _, r := range rates {
+		if r.negotiatedType == "negotiated" && r.value > 0 {
+			groups[r.billingClass] = append(groups[r.billingClass], r.value)
+		}
+	}
+	classStats := make(map[string]*stats, len(groups))
+	for class, values := range groups {
+		if len(values) >= d.opts.MinSample {
+			classStats[class] = robustStats(values)
+		}
+	}
+
+	var findings []finding
+	for _, r := range rates {
+		switch {
+		case r.value <= 0:
+			findings = append(findings, finding{rate: r, reason: "rate is zero or negative", extreme: true})
+		case r.negotiatedType == "percentage":
+			if r.value > d.opts.MaxPercentage {
+				findings = append(findings, finding{rate: r, extreme: true,
+					reason: fmt.Sprintf("percentage rate %g is over %g", r.value, d.opts.MaxPercentage)})
+			}
+		default:
+			s := classStats[r.billingClass]
+			if s == nil {
+				continue
+			}
+			z := (math.Log(r.value) - s.logMedian) / s.logScale
+			if math.Abs(z) <= d.opts.Threshold {
+				continue
+			}
+			z = math.Round(z*100) / 100
+			ratio, direction := r.value/s.median, "above"
+			if ratio < 1 {
+				ratio, direction = 1/ratio, "below"
+			}
+			findings = append(findings, finding{rate: r, stats: s, z: &z,
+				extreme: math.Abs(z) > d.opts.QuarantineThreshold,
+				reason: fmt.Sprintf("%s times %s the %s median of %.2f (robust z %.1f)",
+					formatRatio(ratio), direction, r.billingClass, s.median, z)})
+		}
+	}
+	return findings
+}
+
+// robustStats returns the median and MAD of positive values, in dollars and
+// in log units
+func robustStats(values []float64) *stats {
+	logs := make([]float64, len(values))
+	for i, v := range values {
+		logs[i] = math.Log(v)
+	}
+	s := &stats{count: len(values)}
+	s.median, s.mad = medianMAD(values)
+	logMedian, logMAD := medianMAD(logs)
+	s.logMedian = logMedian
+	s.logScale = math.Max(logMAD*madScale, minLogScale)
+	return s
+}
+
+// medianMAD returns the median of values and their median absolute deviation
+// from it
+func medianMAD(values []float64) (float64, float64) {
+	m := median(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - m)
+	}
+	return m, median(deviations)
+}
+
+// median returns the median of values without reordering them
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// formatRatio renders a ratio with one decimal, or none once it is large
+func formatRatio(ratio float64) string {
+	if ratio >= 100 {
+		return fmt.Sprintf("%.0f", ratio)
+	}
+	return fmt.Sprintf("%.1f", ratio)
+}
+
+// Release accepts a flagged or quarantined rate after review: it is published
+// again and never flagged again. It returns the billing code of the rate.
+func (d *Detector) Release(ctx context.Context, rateID int64) (Code, error) {
+	var c Code
+	err := d.db.QueryRowContext(ctx, `
+		SELECT billing_code_type, billing_code FROM rate_outliers WHERE negotiated_rate_id = ?
+	`, rateID).Scan(&c.BillingCodeType, &c.BillingCode)
+	if err == sql.ErrNoRows {
+		return c, fmt.Errorf("rate %d is not flagged", rateID)
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to find flagged rate: %v", err)
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return c, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE rate_outliers SET status = 'released', reviewed_at = CURRENT_TIMESTAMP WHERE negotiated_rate_id = ?
+	`, rateID)
+	if err != nil {
+		return c, fmt.Errorf("failed to release rate: %v", err)
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE negotiated_rates SET quarantined = 0 WHERE id = ?", rateID); err != nil {
+		return c, fmt.Errorf("failed to publish rate: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c, fmt.Errorf("failed to commit transaction: %v", err)
+	}
+	return c, nil
+}
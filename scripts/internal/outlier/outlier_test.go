@@ -0,0 +1,106 @@
+package outlier
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMedianMAD(t *testing.T) {
+	for _, tc := range []struct {
+		values      []float64
+		median, mad float64
+	}{
+		{[]float64{3, 1, 2}, 2, 1},
+		{[]float64{1, 2, 3, 4}, 2.5, 1},
+		{[]float64{100, 100, 100, 100, 9999999}, 100, 0},
+		{[]float64{7}, 7, 0},
+	} {
+		values := append([]float64(nil), tc.values...)
+		median, mad := medianMAD(values)
+		if median != tc.median || mad != tc.mad {
+			t.Errorf("medianMAD(%v) = %v, %v, want %v, %v", tc.values, median, mad, tc.median, tc.mad)
+		}
+		for i := range values {
+			if values[i] != tc.values[i] {
+				t.Errorf("medianMAD reordered %v into %v", tc.values, values)
+				break
+			}
+		}
+	}
+}
+
+func TestRobustStats(t *testing.T) {
+	s := robustStats([]float64{100, 200, 400})
+	if s.count != 3 || s.median != 200 || s.mad != 100 {
+		t.Errorf("dollar statistics = %+v, want count 3, median 200, MAD 100", s)
+	}
+	if math.Abs(s.logMedian-math.Log(200)) > 1e-12 || math.Abs(s.logScale-math.Log(2)*madScale) > 1e-12 {
+		t.Errorf("log median %v, scale %v, want ln 200 and ln 2 times %v", s.logMedian, s.logScale, madScale)
+	}
+
+	// Identical rates have no spread; the floor keeps z-scores finite
+	if s := robustStats([]float64{100, 100, 100}); s.logScale != minLogScale {
+		t.Errorf("log scale of identical rates = %v, want %v", s.logScale, minLogScale)
+	}
+}
+
+// group returns n dollar rates of a billing class, with ids from first
+func group(first int64, n int, class string, value float64) []rate {
+	rates := make([]rate, n)
+	for i := range rates {
+		rates[i] = rate{id: first + int64(i), billingClass: class, negotiatedType: "negotiated", value: value}
+	}
+	return rates
+}
+
+func TestDetect(t *testing.T) {
+	d := New(nil, DefaultOptions)
+
+	// Five identical professional rates of $100: with the minimum spread, the
+	// z-score of a rate is four times its log ratio to the median
+	rates := group(1, 5, "professional", 100)
+	rates = append(rates,
+		rate{id: 10, billingClass: "professional", negotiatedType: "negotiated", value: 300},  // z 4.4
+		rate{id: 11, billingClass: "professional", negotiatedType: "negotiated", value: 400},  // z 5.5
+		rate{id: 12, billingClass: "professional", negotiatedType: "negotiated", value: 1500}, // z 10.8
+		rate{id: 13, billingClass: "professional", negotiatedType: "negotiated", value: 0.01},
+		rate{id: 14, billingClass: "professional", negotiatedType: "negotiated", value: 0},
+		rate{id: 15, billingClass: "professional", negotiatedType: "percentage", value: 80},
+		rate{id: 16, billingClass: "professional", negotiatedType: "percentage", value: 6400},
+	)
+	// Too few institutional rates for z-scores
+	rates = append(rates, group(20, 3, "institutional", 1000)...)
+	rates = append(rates, rate{id: 30, billingClass: "institutional", negotiatedType: "negotiated", value: 1e7})
+
+	want := map[int64]struct {
+		extreme bool
+		reason  string
+	}{
+		11: {false, "4.0 times above the professional median of 100.00 (robust z 5.5)"},
+		12: {true, "15.0 times above the professional median of 100.00 (robust z 10.8)"},
+		13: {true, "10000 times below the professional median of 100.00 (robust z -36.8)"},
+		14: {true, "rate is zero or negative"},
+		16: {true, "percentage rate 6400 is over 1000"},
+	}
+
+	findings := d.detect(rates)
+	if len(findings) != len(want) {
+		t.Errorf("%d findings, want %d: %+v", len(findings), len(want), findings)
+	}
+	for _, f := range findings {
+		w, ok := want[f.rate.id]
+		if !ok {
+			t.Errorf("rate %d (%v) flagged: %s", f.rate.id, f.rate.value, f.reason)
+			continue
+		}
+		if f.extreme != w.extreme || f.reason != w.reason {
+			t.Errorf("rate %d = extreme %v, %q, want extreme %v, %q", f.rate.id, f.extreme, f.reason, w.extreme, w.reason)
+		}
+		if (f.z != nil) != (f.stats != nil) {
+			t.Errorf("rate %d has z-score %v with statistics %v", f.rate.id, f.z, f.stats)
+		}
+		if f.stats != nil && (f.stats.count != 9 || f.stats.median != 100) {
+			t.Errorf("rate %d statistics = %+v, want the 9 positive professional dollar rates", f.rate.id, f.stats)
+		}
+	}
+}
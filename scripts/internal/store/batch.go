@@ -0,0 +1,651 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"healthcare-saver-ingest/internal/medicare"
+)
+
+// The lookups in this file take many keys at once, so callers that resolve
+// nested data (the GraphQL API) load each level with one query instead of
+// one query per parent.
+
+// Service is a row of insurance_services
+type Service struct {
+	ID                     int64
+	RunID                  *int64
+	Name                   string
+	Description            string
+	BillingCodeType        string
+	BillingCodeTypeVersion string
+	BillingCode            string
+	NegotiationArrangement string
+}
+
+// ServiceFilter narrows a service listing
+type ServiceFilter struct {
+	Query           string // full-text search over names and descriptions
+	BillingCodeType string
+	BillingCode     string
+}
+
+// Rate is a current row of negotiated_rates
+type Rate struct {
+	ID                 int64
+	ServiceID          int64
+	RunID              *int64
+	NegotiatedType     string
+	NegotiatedRate     float64
+	EffectiveRate      *float64 // dollar amount, nil for percentage rates without a reference fee
+	MedicareAmount     *float64 // national Medicare amount of the billing class
+	PctOfMedicare      *float64
+	ExpirationDate     string
+	BillingClass       string
+	ServiceCodes       []string
+	ProviderReferences []int
+}
+
+// RateListFilter narrows the rates of each service
+type RateListFilter struct {
+	BillingClass   string
+	NegotiatedType string
+}
+
+// Price is a distinct price of a service: its current rates that share a
+// negotiated type, rate, expiration date, billing class and service codes,
+// with the provider groups of all of them within the run that last saw them
+type Price struct {
+	ID               int64 // lowest id of the rates
+	RunID            int64
+	NegotiatedType   string
+	NegotiatedRate   float64
+	EffectiveRate    *float64 // dollar amount, nil for percentage rates without a reference fee
+	MedicareAmount   *float64 // national Medicare amount of the billing class
+	PctOfMedicare    *float64
+	ExpirationDate   string
+	BillingClass     string
+	ServiceCodes     []string
+	ProviderGroupIDs []int
+}
+
+// ProviderGroupRef identifies a provider group; provider group ids are only
+// unique within the run (file) that defines them
+type ProviderGroupRef struct {
+	RunID           int64
+	ProviderGroupID int
+}
+
+// ProviderGroupMember is one NPI and TIN of a provider group
+type ProviderGroupMember struct {
+	NPI      string
+	TINType  string
+	TINValue string
+}
+
+// Run is a row of ingestion_runs
+type Run struct {
+	ID                  int64
+	FilePath            string
+	ReportingEntityName string
+	ReportingEntityType string
+	PlanName            string
+	PlanIDType          string
+	PlanID              string
+	PlanMarketType      string
+	LastUpdatedOn       string
+	Status              string
+	ServicesCount       int
+	RatesCount          int
+}
+
+// ReportingEntity is a payer with the runs whose data is currently served
+type ReportingEntity struct {
+	Name string
+	Type string
+	Runs []Run // newest first
+}
+
+// Compliance summarizes how complete and fresh a reporting entity's
+// currently served data is
+type Compliance struct {
+	LastUpdatedOn                string
+	DaysSinceUpdate              *int
+	ServiceCount                 int
+	RateCount                    int
+	ExpiredRateCount             int
+	PercentageRateCount          int
+	UnresolvedProviderGroupCount int
+	HasPlanInfo                  bool
+}
+
+// int64Args returns ids as query arguments
+func int64Args(ids []int64) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
+
+// stringArgs returns values as query arguments
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
+
+// serviceColumns are the columns scanned by scanService
+const serviceColumns = `s.id, s.run_id, s.name, COALESCE(s.description, ''), s.billing_code_type,
+	s.billing_code_type_version, s.billing_code, s.negotiation_arrangement`
+
+// scanService scans the columns listed in serviceColumns
+func scanService(row rowScanner) (Service, error) {
+	var service Service
+	err := row.Scan(&service.ID, &service.RunID, &service.Name, &service.Description, &service.BillingCodeType,
+		&service.BillingCodeTypeVersion, &service.BillingCode, &service.NegotiationArrangement)
+	if err != nil {
+		return service, fmt.Errorf("failed to scan service: %v", err)
+	}
+	return service, nil
+}
+
+// Services lists active services ordered by billing code, or by relevance
+// when filter.Query is set
+func (s *Store) Services(ctx context.Context, filter ServiceFilter, limit, offset int) ([]Service, error) {
+	var (
+		conditions = []string{"1 = 1"}
+		args       []interface{}
+		orderBy    = "s.billing_code_type, s.billing_code, s.id"
+	)
+	from := "active_insurance_services s"
+	if filter.Query != "" {
+		// Match against the base table so the full-text index is used
+		from = `insurance_services fs
+			JOIN active_insurance_services s ON s.id = fs.id`
+		conditions = append(conditions, "MATCH(fs.name, fs.description) AGAINST (? IN NATURAL LANGUAGE MODE)")
+		args = append(args, filter.Query)
+		orderBy = "MATCH(fs.name, fs.description) AGAINST (? IN NATURAL LANGUAGE MODE) DESC, s.id"
+	}
+	if filter.BillingCodeType != "" {
+		conditions = append(conditions, "s.billing_code_type = ?")
+		args = append(args, filter.BillingCodeType)
+	}
+	if filter.BillingCode != "" {
+		conditions = append(conditions, "s.billing_code = ?")
+		args = append(args, filter.BillingCode)
+	}
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM %s
+		WHERE %s
+		ORDER BY %s
+		LIMIT ? OFFSET ?
+	`, serviceColumns, from, strings.Join(conditions, " AND "), orderBy), append(args, limit, offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query services: %v", err)
+	}
+	defer rows.Close()
+
+	services := []Service{}
+	for rows.Next() {
+		service, err := scanService(rows)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read services: %v", err)
+	}
+	return services, nil
+}
+
+// ServicesByID returns the active services with the given ids
+func (s *Store) ServicesByID(ctx context.Context, ids []int64) (map[int64]Service, error) {
+	services := make(map[int64]Service, len(ids))
+	if len(ids) == 0 {
+		return services, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM active_insurance_services s WHERE s.id IN (%s)
+	`, serviceColumns, placeholders(len(ids), "?")), int64Args(ids)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query services: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		service, err := scanService(rows)
+		if err != nil {
+			return nil, err
+		}
+		services[service.ID] = service
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read services: %v", err)
+	}
+	return services, nil
+}
+
+// RatesByService returns up to limit current rates of each service, lowest
+// first. A limit of 0 returns every rate.
+func (s *Store) RatesByService(ctx context.Context, serviceIDs []int64, filter RateListFilter, limit int) (map[int64][]Rate, error) {
+	if len(serviceIDs) == 0 {
+		return map[int64][]Rate{}, nil
+	}
+
+	conditions := []string{fmt.Sprintf("r.service_id IN (%s)", placeholders(len(serviceIDs), "?")), "r.valid_to IS NULL"}
+	args := int64Args(serviceIDs)
+	if filter.BillingClass != "" {
+		conditions = append(conditions, "r.billing_class = ?")
+		args = append(args, filter.BillingClass)
+	}
+	if filter.NegotiatedType != "" {
+		conditions = append(conditions, "r.negotiated_type = ?")
+		args = append(args, filter.NegotiatedType)
+	}
+	return s.queryRates(ctx, strings.Join(conditions, " AND "), args, limit)
+}
+
+// queryRates returns up to limit rates of each service matching where, which
+// may use the aliases of rateFrom, lowest first. A limit of 0 returns every
+// rate.
+func (s *Store) queryRates(ctx context.Context, where string, args []interface{}, limit int) (map[int64][]Rate, error) {
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, service_id, run_id, negotiated_type, negotiated_rate, effective_rate, medicare_amount, expiration_date,
+			billing_class, service_codes, provider_references
+		FROM (
+			SELECT r.id, r.service_id, r.run_id, r.negotiated_type, r.negotiated_rate,
+				`+effectiveRateSQL("s", "r")+` AS effective_rate,
+				`+medicare.AmountSQL("s.billing_code_type", "s.billing_code", "r.billing_class", "NULL")+` AS medicare_amount,
+				DATE_FORMAT(r.expiration_date, '%Y-%m-%d') AS expiration_date, r.billing_class,
+				r.service_codes, r.provider_references,
+				ROW_NUMBER() OVER (PARTITION BY r.service_id ORDER BY r.negotiated_rate, r.id) AS n
+			`+rateFrom+`
+			WHERE `+where+`
+		) ranked
+		WHERE n <= ?
+		ORDER BY service_id, n
+	`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rates: %v", err)
+	}
+	defer rows.Close()
+
+	rates := make(map[int64][]Rate)
+	for rows.Next() {
+		var (
+			rate                      Rate
+			serviceCodesJSON, refJSON []byte
+		)
+		var effective sql.NullFloat64
+		err := rows.Scan(&rate.ID, &rate.ServiceID, &rate.RunID, &rate.NegotiatedType, &rate.NegotiatedRate, &effective,
+			&rate.MedicareAmount, &rate.ExpirationDate, &rate.BillingClass, &serviceCodesJSON, &refJSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rate: %v", err)
+		}
+		rate.EffectiveRate = nullFloat(effective)
+		rate.PctOfMedicare = medicare.Pct(rate.EffectiveRate, rate.MedicareAmount)
+		if err := json.Unmarshal(serviceCodesJSON, &rate.ServiceCodes); err != nil {
+			return nil, fmt.Errorf("failed to parse service codes of rate %d: %v", rate.ID, err)
+		}
+		if err := json.Unmarshal(refJSON, &rate.ProviderReferences); err != nil {
+			return nil, fmt.Errorf("failed to parse provider references of rate %d: %v", rate.ID, err)
+		}
+		rates[rate.ServiceID] = append(rates[rate.ServiceID], rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rates: %v", err)
+	}
+	return rates, nil
+}
+
+// PricesByService returns up to limit distinct prices of each service, lowest
+// first. A limit of 0 returns every price.
+func (s *Store) PricesByService(ctx context.Context, serviceIDs []int64, limit int) (map[int64][]Price, error) {
+	if len(serviceIDs) == 0 {
+		return map[int64][]Price{}, nil
+	}
+	where := fmt.Sprintf("r.service_id IN (%s) AND r.valid_to IS NULL", placeholders(len(serviceIDs), "?"))
+	return s.queryPrices(ctx, where, int64Args(serviceIDs), limit)
+}
+
+// queryPrices returns up to limit prices of each service, grouping the rates
+// matching where, which may use the aliases of rateFrom, lowest first. A
+// limit of 0 returns every price.
+func (s *Store) queryPrices(ctx context.Context, where string, args []interface{}, limit int) (map[int64][]Price, error) {
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+
+	// The inner query groups the rates into prices; the rate of a price's
+	// lowest id then supplies the columns the amounts are computed from
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT service_id, run_id, id, negotiated_type, negotiated_rate, effective_rate, medicare_amount, expiration_date,
+			billing_class, service_codes, provider_group_ids
+		FROM (
+			SELECT p.service_id, p.run_id, p.id, r.negotiated_type, r.negotiated_rate,
+				`+effectiveRateSQL("s", "r")+` AS effective_rate,
+				`+medicare.AmountSQL("s.billing_code_type", "s.billing_code", "r.billing_class", "NULL")+` AS medicare_amount,
+				DATE_FORMAT(r.expiration_date, '%Y-%m-%d') AS expiration_date, r.billing_class,
+				r.service_codes, p.provider_group_ids,
+				ROW_NUMBER() OVER (PARTITION BY p.service_id ORDER BY r.negotiated_rate, p.id) AS n
+			FROM (
+				SELECT r.service_id, COALESCE(r.last_seen_run_id, 0) AS run_id, MIN(r.id) AS id,
+					CONCAT('[', COALESCE(GROUP_CONCAT(DISTINCT rpg.provider_group_id ORDER BY rpg.provider_group_id), ''), ']')
+						AS provider_group_ids
+				`+rateFrom+`
+				LEFT JOIN negotiated_rate_provider_groups rpg ON rpg.negotiated_rate_id = r.id
+				WHERE `+where+`
+				GROUP BY r.service_id, r.last_seen_run_id, r.negotiated_type, r.negotiated_rate, r.expiration_date,
+					r.billing_class, CAST(r.service_codes AS CHAR)
+			) p
+			JOIN active_negotiated_rates r ON r.id = p.id
+			JOIN active_insurance_services s ON s.id = p.service_id
+		) ranked
+		WHERE n <= ?
+		ORDER BY service_id, n
+	`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prices: %v", err)
+	}
+	defer rows.Close()
+
+	prices := make(map[int64][]Price)
+	for rows.Next() {
+		var (
+			serviceID           int64
+			price               Price
+			codesJSON, groupIDs []byte
+			effective           sql.NullFloat64
+		)
+		err := rows.Scan(&serviceID, &price.RunID, &price.ID, &price.NegotiatedType, &price.NegotiatedRate, &effective,
+			&price.MedicareAmount, &price.ExpirationDate, &price.BillingClass, &codesJSON, &groupIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price: %v", err)
+		}
+		price.EffectiveRate = nullFloat(effective)
+		price.PctOfMedicare = medicare.Pct(price.EffectiveRate, price.MedicareAmount)
+		if err := json.Unmarshal(codesJSON, &price.ServiceCodes); err != nil {
+			return nil, fmt.Errorf("failed to parse service codes of price %d: %v", price.ID, err)
+		}
+		if err := json.Unmarshal(groupIDs, &price.ProviderGroupIDs); err != nil {
+			return nil, fmt.Errorf("failed to parse provider groups of price %d: %v", price.ID, err)
+		}
+		prices[serviceID] = append(prices[serviceID], price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read prices: %v", err)
+	}
+	return prices, nil
+}
+
+// ProviderGroupsByRate returns the provider groups of each rate
+func (s *Store) ProviderGroupsByRate(ctx context.Context, rateIDs []int64) (map[int64][]ProviderGroupRef, error) {
+	groups := make(map[int64][]ProviderGroupRef, len(rateIDs))
+	if len(rateIDs) == 0 {
+		return groups, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT pg.negotiated_rate_id, COALESCE(r.last_seen_run_id, 0), pg.provider_group_id
+		FROM negotiated_rate_provider_groups pg
+		JOIN active_negotiated_rates r ON r.id = pg.negotiated_rate_id
+		WHERE pg.negotiated_rate_id IN (%s)
+		ORDER BY pg.negotiated_rate_id, pg.provider_group_id
+	`, placeholders(len(rateIDs), "?")), int64Args(rateIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query provider groups: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rateID int64
+			ref    ProviderGroupRef
+		)
+		if err := rows.Scan(&rateID, &ref.RunID, &ref.ProviderGroupID); err != nil {
+			return nil, fmt.Errorf("failed to scan provider group: %v", err)
+		}
+		groups[rateID] = append(groups[rateID], ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read provider groups: %v", err)
+	}
+	return groups, nil
+}
+
+// ProviderGroupMembers returns the NPIs and TINs of each provider group
+func (s *Store) ProviderGroupMembers(ctx context.Context, refs []ProviderGroupRef) (map[ProviderGroupRef][]ProviderGroupMember, error) {
+	members := make(map[ProviderGroupRef][]ProviderGroupMember, len(refs))
+	if len(refs) == 0 {
+		return members, nil
+	}
+
+	args := make([]interface{}, 0, len(refs)*2)
+	for _, ref := range refs {
+		args = append(args, ref.RunID, ref.ProviderGroupID)
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT run_id, provider_group_id, npi, tin_type, tin_value
+		FROM provider_group_npis
+		WHERE (run_id, provider_group_id) IN (%s)
+		ORDER BY run_id, provider_group_id, npi, tin_value
+	`, placeholders(len(refs), "(?, ?)")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query provider group members: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			ref    ProviderGroupRef
+			member ProviderGroupMember
+		)
+		if err := rows.Scan(&ref.RunID, &ref.ProviderGroupID, &member.NPI, &member.TINType, &member.TINValue); err != nil {
+			return nil, fmt.Errorf("failed to scan provider group member: %v", err)
+		}
+		members[ref] = append(members[ref], member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read provider group members: %v", err)
+	}
+	return members, nil
+}
+
+// ProvidersByNPI returns the directory entries of the given NPIs
+func (s *Store) ProvidersByNPI(ctx context.Context, npis []string) (map[string]Provider, error) {
+	providers := make(map[string]Provider, len(npis))
+	if len(npis) == 0 {
+		return providers, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM providers p WHERE p.npi IN (%s)
+	`, providerColumns, placeholders(len(npis), "?")), stringArgs(npis)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query providers: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		provider, err := scanProvider(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan provider: %v", err)
+		}
+		providers[provider.NPI] = provider
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read providers: %v", err)
+	}
+	return providers, nil
+}
+
+// runColumns are the columns scanned by scanRun
+const runColumns = `ir.id, ir.file_path, COALESCE(ir.reporting_entity_name, ''), COALESCE(ir.reporting_entity_type, ''),
+	COALESCE(ir.plan_name, ''), COALESCE(ir.plan_id_type, ''), COALESCE(ir.plan_id, ''), COALESCE(ir.plan_market_type, ''),
+	COALESCE(DATE_FORMAT(ir.last_updated_on, '%Y-%m-%d'), ''), ir.status, ir.services_count, ir.rates_count`
+
+// servedRuns are the conditions for runs whose data the active views serve,
+// matching the view definitions of the ingestion tool
+const servedRuns = `ir.status = 'completed'
+	AND (v.run_id = ir.id OR (v.run_id IS NULL AND ir.staging = 0))`
+
+// scanRun scans the columns listed in runColumns
+func scanRun(row rowScanner) (Run, error) {
+	var run Run
+	err := row.Scan(&run.ID, &run.FilePath, &run.ReportingEntityName, &run.ReportingEntityType,
+		&run.PlanName, &run.PlanIDType, &run.PlanID, &run.PlanMarketType,
+		&run.LastUpdatedOn, &run.Status, &run.ServicesCount, &run.RatesCount)
+	if err != nil {
+		return run, fmt.Errorf("failed to scan run: %v", err)
+	}
+	return run, nil
+}
+
+// RunsByID returns the ingestion runs with the given ids
+func (s *Store) RunsByID(ctx context.Context, ids []int64) (map[int64]Run, error) {
+	runs := make(map[int64]Run, len(ids))
+	if len(ids) == 0 {
+		return runs, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s FROM ingestion_runs ir WHERE ir.id IN (%s)
+	`, runColumns, placeholders(len(ids), "?")), int64Args(ids)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs[run.ID] = run
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read runs: %v", err)
+	}
+	return runs, nil
+}
+
+// ReportingEntities returns the reporting entities with served data, by
+// name. Without names it returns every one.
+func (s *Store) ReportingEntities(ctx context.Context, names []string) ([]ReportingEntity, error) {
+	condition, args := "ir.reporting_entity_name IS NOT NULL", []interface{}{}
+	if names != nil {
+		if len(names) == 0 {
+			return []ReportingEntity{}, nil
+		}
+		condition = fmt.Sprintf("ir.reporting_entity_name IN (%s)", placeholders(len(names), "?"))
+		args = stringArgs(names)
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM ingestion_runs ir
+		LEFT JOIN active_file_versions v ON v.reporting_entity_name = ir.reporting_entity_name
+		WHERE %s AND %s
+		ORDER BY ir.reporting_entity_name, ir.id DESC
+	`, runColumns, condition, servedRuns), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reporting entities: %v", err)
+	}
+	defer rows.Close()
+
+	entities := []ReportingEntity{}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		if len(entities) == 0 || entities[len(entities)-1].Name != run.ReportingEntityName {
+			entities = append(entities, ReportingEntity{Name: run.ReportingEntityName, Type: run.ReportingEntityType})
+		}
+		last := &entities[len(entities)-1]
+		last.Runs = append(last.Runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reporting entities: %v", err)
+	}
+	return entities, nil
+}
+
+// ComplianceByEntity summarizes the served data of each reporting entity
+func (s *Store) ComplianceByEntity(ctx context.Context, names []string) (map[string]Compliance, error) {
+	result := make(map[string]Compliance, len(names))
+	if len(names) == 0 {
+		return result, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT ir.reporting_entity_name,
+			COALESCE(DATE_FORMAT(MAX(ir.last_updated_on), '%%Y-%%m-%%d'), ''),
+			DATEDIFF(CURDATE(), MAX(ir.last_updated_on)),
+			SUM(ir.services_count), SUM(ir.rates_count),
+			MIN(ir.plan_name IS NOT NULL OR ir.plan_id IS NOT NULL),
+			(SELECT COUNT(*) FROM active_negotiated_rates r
+				JOIN ingestion_runs ir2 ON ir2.id = r.run_id
+				WHERE ir2.reporting_entity_name = ir.reporting_entity_name
+				AND r.valid_to IS NULL AND r.expiration_date < CURDATE()),
+			(SELECT COUNT(*) FROM active_negotiated_rates r
+				JOIN ingestion_runs ir2 ON ir2.id = r.run_id
+				WHERE ir2.reporting_entity_name = ir.reporting_entity_name
+				AND r.valid_to IS NULL AND r.negotiated_type = 'percentage'),
+			(SELECT COUNT(DISTINCT r.last_seen_run_id, pg.provider_group_id) FROM negotiated_rate_provider_groups pg
+				JOIN active_negotiated_rates r ON r.id = pg.negotiated_rate_id
+				JOIN ingestion_runs ir2 ON ir2.id = r.run_id
+				WHERE ir2.reporting_entity_name = ir.reporting_entity_name
+				AND NOT EXISTS (SELECT 1 FROM provider_group_npis pn
+					WHERE pn.run_id = r.last_seen_run_id AND pn.provider_group_id = pg.provider_group_id))
+		FROM ingestion_runs ir
+		LEFT JOIN active_file_versions v ON v.reporting_entity_name = ir.reporting_entity_name
+		WHERE ir.reporting_entity_name IN (%s) AND %s
+		GROUP BY ir.reporting_entity_name
+	`, placeholders(len(names), "?"), servedRuns), stringArgs(names)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query compliance: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			name            string
+			compliance      Compliance
+			daysSinceUpdate sql.NullInt64
+		)
+		err := rows.Scan(&name, &compliance.LastUpdatedOn, &daysSinceUpdate, &compliance.ServiceCount,
+			&compliance.RateCount, &compliance.HasPlanInfo, &compliance.ExpiredRateCount,
+			&compliance.PercentageRateCount, &compliance.UnresolvedProviderGroupCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan compliance: %v", err)
+		}
+		if daysSinceUpdate.Valid {
+			days := int(daysSinceUpdate.Int64)
+			compliance.DaysSinceUpdate = &days
+		}
+		result[name] = compliance
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read compliance: %v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,220 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"healthcare-saver-ingest/internal/medicare"
+)
+
+// CompareParams selects the rates compared across payers
+type CompareParams struct {
+	BillingCodeType string
+	BillingCode     string
+	NPI             string
+	// Base is the dollar amount percentage rates apply to (e.g. the
+	// provider's billed charge); 0 applies them to the reference fee of each
+	// billing class, and leaves them non-comparable without one
+	Base float64
+	// AsOf is the date (YYYY-MM-DD) expiration dates are checked against
+	AsOf string
+}
+
+// PayerComparison is one payer and plan's rate for a billing class
+type PayerComparison struct {
+	Rank           *int     `json:"rank"`
+	PayerName      string   `json:"payerName"`
+	PlanName       string   `json:"planName"`
+	PlanID         string   `json:"planId"`
+	BillingClass   string   `json:"billingClass" enum:"BillingClass"`
+	NegotiatedType string   `json:"negotiatedType" enum:"NegotiatedType"`
+	NegotiatedRate float64  `json:"negotiatedRate"`
+	EffectiveRate  *float64 `json:"effectiveRate"`
+	Comparable     bool     `json:"comparable"`
+	MedicareAmount *float64 `json:"medicareAmount"`
+	PctOfMedicare  *float64 `json:"pctOfMedicare"`
+	ExpirationDate string   `json:"expirationDate"`
+	Expired        bool     `json:"expired"`
+	RateCount      int      `json:"rateCount"`
+}
+
+// PayerComparisonResult compares every payer's rate for a code at a provider
+type PayerComparisonResult struct {
+	BillingCodeType  string             `json:"billingCodeType"`
+	BillingCode      string             `json:"billingCode"`
+	Name             string             `json:"name"`
+	NPI              string             `json:"npi"`
+	AsOf             string             `json:"asOf"`
+	Base             *float64           `json:"base"`
+	ReferenceFees    map[string]float64 `json:"referenceFees"`    // by billing class, for percentage rates without a base
+	MedicareLocality string             `json:"medicareLocality"` // PFS locality of the npi's provider, "" for national Medicare amounts
+	Payers           []PayerComparison  `json:"payers"`
+}
+
+// comparisonKey groups rates by payer, plan and billing class
+type comparisonKey struct {
+	payer, planName, planID, billingClass string
+}
+
+// ComparePayers returns, for every payer, plan and billing class, the lowest
+// current rate of a billing code at a provider. Percentage rates are converted
+// to dollars against the given base or the reference fee. Within each billing
+// class, comparable and unexpired rates are ranked from lowest (1) to highest.
+func (s *Store) ComparePayers(ctx context.Context, params CompareParams) (*PayerComparisonResult, error) {
+	result := &PayerComparisonResult{
+		BillingCodeType: params.BillingCodeType,
+		BillingCode:     params.BillingCode,
+		NPI:             params.NPI,
+		AsOf:            params.AsOf,
+		Payers:          []PayerComparison{},
+	}
+	if params.Base > 0 {
+		result.Base = &params.Base
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT billing_code_type, name FROM active_insurance_services
+		WHERE billing_code_type = ? AND billing_code = ?
+		ORDER BY id LIMIT 1
+	`, params.BillingCodeType, params.BillingCode).Scan(&result.BillingCodeType, &result.Name)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find billing code: %v", err)
+	}
+
+	fees, err := s.referenceFees(ctx, params.BillingCodeType, params.BillingCode)
+	if err != nil {
+		return nil, err
+	}
+	result.ReferenceFees = fees
+
+	if result.MedicareLocality, err = medicare.ProviderLocality(ctx, s.db, params.NPI); err != nil {
+		return nil, err
+	}
+	amounts, err := medicare.Amounts(ctx, s.db, params.BillingCodeType, params.BillingCode, result.MedicareLocality)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := rateConditions(params.BillingCodeType, params.BillingCode, RateFilter{NPI: params.NPI}, nil)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COALESCE(ir.reporting_entity_name, ''), COALESCE(ir.plan_name, ''), COALESCE(ir.plan_id, ''),
+			r.billing_class, r.negotiated_type, r.negotiated_rate, DATE_FORMAT(r.expiration_date, '%Y-%m-%d')
+	`+rateFrom+`
+		WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payer rates: %v", err)
+	}
+	defer rows.Close()
+
+	index := make(map[comparisonKey]int)
+	for rows.Next() {
+		var (
+			key  comparisonKey
+			rate PayerComparison
+		)
+		err := rows.Scan(&key.payer, &key.planName, &key.planID, &key.billingClass,
+			&rate.NegotiatedType, &rate.NegotiatedRate, &rate.ExpirationDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payer rate: %v", err)
+		}
+
+		rate.PayerName = payerName(key.payer)
+		rate.PlanName = key.planName
+		rate.PlanID = key.planID
+		rate.BillingClass = key.billingClass
+		rate.Expired = rate.ExpirationDate < params.AsOf
+		rate.EffectiveRate = effectiveRate(rate.NegotiatedType, rate.NegotiatedRate, rateBase(params.Base, fees, key.billingClass))
+		rate.Comparable = rate.EffectiveRate != nil
+		rate.MedicareAmount = medicare.Lookup(amounts, key.billingClass)
+		rate.PctOfMedicare = medicare.Pct(rate.EffectiveRate, rate.MedicareAmount)
+		rate.RateCount = 1
+
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(result.Payers)
+			result.Payers = append(result.Payers, rate)
+			continue
+		}
+
+		rate.RateCount = result.Payers[i].RateCount + 1
+		if betterComparison(rate, result.Payers[i]) {
+			result.Payers[i] = rate
+		} else {
+			result.Payers[i].RateCount = rate.RateCount
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read payer rates: %v", err)
+	}
+
+	rankPayers(result.Payers)
+	return result, nil
+}
+
+// effectiveRate returns the dollar amount of a rate, or nil when a percentage
+// rate has no base to apply to
+func effectiveRate(negotiatedType string, rate, base float64) *float64 {
+	switch negotiatedType {
+	case "negotiated":
+		return &rate
+	case "percentage":
+		if base > 0 {
+			amount := round2(base * rate / 100)
+			return &amount
+		}
+	}
+	return nil
+}
+
+// betterComparison reports whether a should represent its payer instead of b:
+// unexpired beats expired, comparable beats non-comparable, then lower wins
+func betterComparison(a, b PayerComparison) bool {
+	if a.Expired != b.Expired {
+		return !a.Expired
+	}
+	if a.Comparable != b.Comparable {
+		return a.Comparable
+	}
+	if a.Comparable {
+		return *a.EffectiveRate < *b.EffectiveRate
+	}
+	return a.NegotiatedRate < b.NegotiatedRate
+}
+
+// rankPayers sorts comparisons by billing class and rank, and ranks the
+// comparable, unexpired ones within each billing class from lowest to highest
+func rankPayers(payers []PayerComparison) {
+	ranked := func(p PayerComparison) bool { return p.Comparable && !p.Expired }
+
+	sort.SliceStable(payers, func(i, j int) bool {
+		a, b := payers[i], payers[j]
+		if a.BillingClass != b.BillingClass {
+			return a.BillingClass < b.BillingClass
+		}
+		if ranked(a) != ranked(b) {
+			return ranked(a)
+		}
+		if ranked(a) && *a.EffectiveRate != *b.EffectiveRate {
+			return *a.EffectiveRate < *b.EffectiveRate
+		}
+		return a.PayerName < b.PayerName
+	})
+
+	rank, class := 0, ""
+	for i := range payers {
+		if payers[i].BillingClass != class {
+			rank, class = 0, payers[i].BillingClass
+		}
+		if ranked(payers[i]) {
+			rank++
+			r := rank
+			payers[i].Rank = &r
+		}
+	}
+}
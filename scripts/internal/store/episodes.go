@@ -0,0 +1,248 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"healthcare-saver-ingest/internal/episode"
+)
+
+// Sources of the rate of an episode component
+const (
+	SourceProvider    = "provider"     // the provider's own rates with the payer
+	SourcePayerMedian = "payer_median" // the payer's median rate in the market
+	SourceNone        = "none"         // no rate
+)
+
+// EpisodeLine is the price of one component of an episode. Rate and Amount
+// are nil, and the source none, when neither the provider nor the payer has
+// a rate.
+type EpisodeLine struct {
+	BillingCodeType string   `json:"billingCodeType"`
+	BillingCode     string   `json:"billingCode"`
+	BillingClass    string   `json:"billingClass" enum:"BillingClass"`
+	Description     string   `json:"description"`
+	Units           int      `json:"units"`
+	Optional        bool     `json:"optional"`
+	Rate            *float64 `json:"rate"`   // median effective rate per unit
+	Amount          *float64 `json:"amount"` // rate times units
+	Source          string   `json:"source" enum:"EpisodeRateSource"`
+	RateCount       int      `json:"rateCount"`
+}
+
+// EpisodePrice is the price of an episode at one provider organization (TIN)
+// under one payer. The first line is the primary component. A price is
+// complete when every required component has the organization's own rates;
+// one with components priced at the payer's median is an estimate.
+type EpisodePrice struct {
+	PayerName    string        `json:"payerName"`
+	TIN          string        `json:"tin"`
+	ProviderName string        `json:"providerName"` // a provider of the TIN in the directory, "" when none is
+	Total        float64       `json:"total"`
+	Complete     bool          `json:"complete"`
+	Estimated    []string      `json:"estimated"` // required components priced at the payer's median
+	Missing      []string      `json:"missing"`   // required components without any rate
+	Lines        []EpisodeLine `json:"lines"`
+}
+
+// rank orders prices: complete ones, then estimates, then prices missing
+// components
+func (p EpisodePrice) rank() int {
+	switch {
+	case p.Complete:
+		return 0
+	case len(p.Missing) == 0:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// EpisodePrices is one page of the prices of an episode in a market, complete
+// episodes first, then estimates, then incomplete ones, cheapest first
+type EpisodePrices struct {
+	Episode  episode.Episode `json:"episode"`
+	Market   string          `json:"market"`
+	Stats    RateStats       `json:"stats"` // of the totals of complete episodes
+	Prices   []EpisodePrice  `json:"prices"`
+	Total    int             `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"pageSize"`
+}
+
+// componentRates are the current effective rates of an episode component in
+// a market
+type componentRates struct {
+	byProvider map[[2]string][]float64 // by payer and TIN
+	byPayer    map[string][]float64    // each rate once, whatever its TINs
+	names      map[string]string       // provider name by TIN
+}
+
+// EpisodePrices assembles the price of an episode for every payer and
+// provider organization with a current rate of its primary component in the
+// market. Organizations are TINs, since facilities and the physicians working
+// there bill separately but often under one TIN. Each component is priced at
+// the median of the organization's rates with the payer; when it has none,
+// at the median of the payer's rates in the market, as the patient would
+// likely get the component from another provider in the payer's network,
+// which makes the price an estimate. pageSize 0 returns every price.
+func (s *Store) EpisodePrices(ctx context.Context, ep episode.Episode, market Market, payer string, page, pageSize int) (*EpisodePrices, error) {
+	area, err := s.nearby(ctx, market.Near)
+	if err != nil {
+		return nil, err
+	}
+
+	components := ep.Components()
+	rates := make([]*componentRates, len(components))
+	for i, component := range components {
+		rates[i], err = s.componentRates(ctx, component, market, payer, area)
+		if err != nil {
+			return nil, fmt.Errorf("failed to price %s: %v", component, err)
+		}
+	}
+
+	var prices []EpisodePrice
+	var totals []float64
+	for key := range rates[0].byProvider {
+		price := EpisodePrice{
+			PayerName:    key[0],
+			TIN:          key[1],
+			ProviderName: rates[0].names[key[1]],
+			Complete:     true,
+			Estimated:    []string{},
+			Missing:      []string{},
+		}
+		for i, component := range components {
+			line := EpisodeLine{
+				BillingCodeType: component.BillingCodeType,
+				BillingCode:     component.BillingCode,
+				BillingClass:    component.BillingClass,
+				Description:     component.Description,
+				Units:           component.Units,
+				Optional:        component.Optional,
+				Source:          SourceNone,
+			}
+			values, source := rates[i].byProvider[key], SourceProvider
+			if len(values) == 0 {
+				values, source = rates[i].byPayer[key[0]], SourcePayerMedian
+			}
+			if len(values) > 0 {
+				stats := ComputeStats(values)
+				amount := round2(stats.Median * float64(component.Units))
+				line.Rate, line.Amount = &stats.Median, &amount
+				line.Source, line.RateCount = source, stats.Count
+				price.Total += amount
+				if source == SourcePayerMedian && !component.Optional {
+					price.Complete = false
+					price.Estimated = append(price.Estimated, component.String())
+				}
+			} else if !component.Optional {
+				price.Complete = false
+				price.Missing = append(price.Missing, component.String())
+			}
+			price.Lines = append(price.Lines, line)
+		}
+		price.Total = round2(price.Total)
+		if price.Complete {
+			totals = append(totals, price.Total)
+		}
+		prices = append(prices, price)
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		a, b := prices[i], prices[j]
+		if a.rank() != b.rank() {
+			return a.rank() < b.rank()
+		}
+		if a.Total != b.Total {
+			return a.Total < b.Total
+		}
+		if a.PayerName != b.PayerName {
+			return a.PayerName < b.PayerName
+		}
+		return a.TIN < b.TIN
+	})
+
+	result := &EpisodePrices{
+		Episode:  ep,
+		Market:   market.String(),
+		Stats:    ComputeStats(totals),
+		Prices:   prices,
+		Total:    len(prices),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if pageSize > 0 {
+		start := min((page-1)*pageSize, len(prices))
+		result.Prices = prices[start:min(start+pageSize, len(prices))]
+	}
+	if result.Prices == nil {
+		result.Prices = []EpisodePrice{}
+	}
+	return result, nil
+}
+
+// componentRates loads the current effective rates of a component in a
+// market by payer and TIN
+func (s *Store) componentRates(ctx context.Context, component episode.Component, market Market, payer string, area *nearbyArea) (*componentRates, error) {
+	where, args := rateConditions(component.BillingCodeType, component.BillingCode, RateFilter{Payer: payer}, nil)
+	conditions := []string{where, "r.billing_class = ?"}
+	args = append(args, component.BillingClass)
+	join := "LEFT JOIN providers p ON p.npi = pn.npi"
+	if market.State != "" || area != nil {
+		join = "JOIN providers p ON p.npi = pn.npi"
+	}
+	if market.State != "" {
+		conditions = append(conditions, "p.address_state = ?")
+		args = append(args, market.State)
+	}
+	if area != nil {
+		conditions = append(conditions, fmt.Sprintf("p.address_zip5 IN (%s)", placeholders(len(area.zips), "?")))
+		args = append(args, area.zipArgs()...)
+	}
+	effective := effectiveRateSQL("s", "r")
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.id, COALESCE(ir.reporting_entity_name, ''), pn.tin_value, `+effective+`, COALESCE(MIN(p.name), '')
+	`+rateFrom+`
+		JOIN negotiated_rate_provider_groups pg ON pg.negotiated_rate_id = r.id
+		JOIN provider_group_npis pn ON pn.provider_group_id = pg.provider_group_id AND pn.run_id = r.last_seen_run_id
+		`+join+`
+		WHERE `+strings.Join(conditions, " AND ")+` AND `+effective+` IS NOT NULL
+		GROUP BY 1, 2, 3, 4
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rates: %v", err)
+	}
+	defer rows.Close()
+
+	rates := &componentRates{
+		byProvider: make(map[[2]string][]float64),
+		byPayer:    make(map[string][]float64),
+		names:      make(map[string]string),
+	}
+	seen := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		var payerName, tin, name string
+		var rate float64
+		if err := rows.Scan(&id, &payerName, &tin, &rate, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan rate: %v", err)
+		}
+		key := [2]string{payerName, tin}
+		rates.byProvider[key] = append(rates.byProvider[key], rate)
+		if !seen[id] {
+			seen[id] = true
+			rates.byPayer[payerName] = append(rates.byPayer[payerName], rate)
+		}
+		if current, ok := rates.names[tin]; name != "" && (!ok || current == "" || name < current) {
+			rates.names[tin] = name
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rates: %v", err)
+	}
+	return rates, nil
+}
@@ -0,0 +1,301 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+)
+
+// EstimateItem is one billing code of an episode. Without a billing class,
+// every billing class with rates becomes its own line, so a procedure is
+// estimated with both its facility (institutional) and professional fees.
+type EstimateItem struct {
+	BillingCodeType string `json:"billingCodeType"`
+	BillingCode     string `json:"billingCode"`
+	BillingClass    string `json:"billingClass,omitempty" enum:"BillingClass"`
+	Units           int    `json:"units,omitempty"` // defaults to 1
+}
+
+// Benefits is the member's remaining cost sharing for the plan year
+type Benefits struct {
+	DeductibleRemaining  float64  `json:"deductibleRemaining,omitempty"`
+	CoinsurancePercent   float64  `json:"coinsurancePercent,omitempty"`
+	Copay                float64  `json:"copay,omitempty"`                // once per episode
+	OutOfPocketRemaining *float64 `json:"outOfPocketRemaining,omitempty"` // nil means no cap
+}
+
+// EstimateParams describes an episode at one provider under one plan
+type EstimateParams struct {
+	Items           []EstimateItem
+	NPI             string
+	ProviderGroupID int
+	RunID           int64 // run of ProviderGroupID
+	Payer           string
+	Plan            string
+	Base            float64 // amount percentage rates apply to, 0 for the reference fee
+	AsOf            string  // rates expiring before this date are left out
+	Benefits        Benefits
+}
+
+// CostRange is an amount for the lowest, median and highest applicable rates
+type CostRange struct {
+	Low     float64 `json:"low"`
+	Typical float64 `json:"typical"`
+	High    float64 `json:"high"`
+}
+
+// CostShare splits the member's cost of the typical estimate
+type CostShare struct {
+	Copay       float64 `json:"copay"`
+	Deductible  float64 `json:"deductible"`
+	Coinsurance float64 `json:"coinsurance"`
+}
+
+// EstimateLine is the estimate of one billing code and billing class. The
+// amounts are nil when no rate applies.
+type EstimateLine struct {
+	BillingCodeType    string     `json:"billingCodeType"`
+	BillingCode        string     `json:"billingCode"`
+	BillingClass       string     `json:"billingClass,omitempty" enum:"BillingClass"` // omitted when neither the item nor a rate has one
+	ServiceName        string     `json:"serviceName"`
+	Units              int        `json:"units"`
+	RateCount          int        `json:"rateCount"`
+	NonComparableRates int        `json:"nonComparableRates"` // percentage rates without a base or reference fee
+	Allowed            *CostRange `json:"allowed"`
+	MemberCost         *CostRange `json:"memberCost"`
+	PlanPays           *CostRange `json:"planPays"`
+	CostShare          *CostShare `json:"costShare"`
+}
+
+// Estimate is the itemized out-of-pocket estimate of an episode
+type Estimate struct {
+	Lines      []EstimateLine `json:"lines"`
+	Allowed    CostRange      `json:"allowed"`
+	MemberCost CostRange      `json:"memberCost"`
+	PlanPays   CostRange      `json:"planPays"`
+	CostShare  CostShare      `json:"costShare"`
+	Notes      []string       `json:"notes"`
+}
+
+// EstimateCost estimates what a member pays for an episode. Each line's
+// allowed amount ranges over the provider's current rates for the code and
+// billing class (lowest, median, highest); the member's benefits are then
+// applied to the lines in order, so the deductible is used up by the first
+// lines of the episode.
+func (s *Store) EstimateCost(ctx context.Context, params EstimateParams) (*Estimate, error) {
+	estimate := &Estimate{Lines: []EstimateLine{}, Notes: []string{}}
+
+	for _, item := range params.Items {
+		lines, err := s.estimateLines(ctx, params, item)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			switch {
+			case line.ServiceName == "":
+				estimate.Notes = append(estimate.Notes, fmt.Sprintf("%s %s: unknown billing code", line.BillingCodeType, line.BillingCode))
+			case line.RateCount == 0:
+				estimate.Notes = append(estimate.Notes, fmt.Sprintf("%s %s: no applicable rate at this provider", line.BillingCodeType, line.BillingCode))
+			}
+			if line.NonComparableRates > 0 {
+				estimate.Notes = append(estimate.Notes, fmt.Sprintf("%s %s: %d percentage rates left out (no base amount or reference fee)",
+					line.BillingCodeType, line.BillingCode, line.NonComparableRates))
+			}
+		}
+		estimate.Lines = append(estimate.Lines, lines...)
+	}
+
+	if params.Payer == "" && params.Plan == "" {
+		estimate.Notes = append(estimate.Notes, "no payer or plan given: the range covers every payer's rates")
+	}
+
+	applyBenefits(estimate, params.Benefits)
+	return estimate, nil
+}
+
+// estimateLines loads the applicable rates of one item, one line per billing
+// class
+func (s *Store) estimateLines(ctx context.Context, params EstimateParams, item EstimateItem) ([]EstimateLine, error) {
+	units := item.Units
+	if units < 1 {
+		units = 1
+	}
+	line := EstimateLine{
+		BillingCodeType: item.BillingCodeType,
+		BillingCode:     item.BillingCode,
+		BillingClass:    item.BillingClass,
+		Units:           units,
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT name FROM active_insurance_services
+		WHERE billing_code_type = ? AND billing_code = ?
+		ORDER BY id LIMIT 1
+	`, item.BillingCodeType, item.BillingCode).Scan(&line.ServiceName)
+	if err == sql.ErrNoRows {
+		return []EstimateLine{line}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find billing code: %v", err)
+	}
+
+	fees, err := s.referenceFees(ctx, item.BillingCodeType, item.BillingCode)
+	if err != nil {
+		return nil, err
+	}
+
+	where, args := rateConditions(item.BillingCodeType, item.BillingCode, RateFilter{
+		Payer:           params.Payer,
+		Plan:            params.Plan,
+		NPI:             params.NPI,
+		ProviderGroupID: params.ProviderGroupID,
+		RunID:           params.RunID,
+	}, nil)
+	where += " AND r.expiration_date >= ?"
+	args = append(args, params.AsOf)
+	if item.BillingClass != "" {
+		where += " AND r.billing_class = ?"
+		args = append(args, item.BillingClass)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.billing_class, r.negotiated_type, r.negotiated_rate
+	`+rateFrom+`
+		WHERE `+where+`
+		ORDER BY r.billing_class
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rates: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		lines  []EstimateLine
+		values []float64
+	)
+	flush := func() {
+		if len(values) > 0 {
+			last := &lines[len(lines)-1]
+			last.RateCount = len(values)
+			stats := ComputeStats(values)
+			last.Allowed = &CostRange{
+				Low:     round2(stats.Min * float64(units)),
+				Typical: round2(stats.Median * float64(units)),
+				High:    round2(stats.Max * float64(units)),
+			}
+			values = nil
+		}
+	}
+
+	for rows.Next() {
+		var billingClass, negotiatedType string
+		var rate float64
+		if err := rows.Scan(&billingClass, &negotiatedType, &rate); err != nil {
+			return nil, fmt.Errorf("failed to scan rate: %v", err)
+		}
+
+		if len(lines) == 0 || lines[len(lines)-1].BillingClass != billingClass {
+			flush()
+			next := line
+			next.BillingClass = billingClass
+			lines = append(lines, next)
+		}
+		if amount := effectiveRate(negotiatedType, rate, rateBase(params.Base, fees, billingClass)); amount != nil {
+			values = append(values, *amount)
+		} else {
+			lines[len(lines)-1].NonComparableRates++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rates: %v", err)
+	}
+	flush()
+
+	if len(lines) == 0 {
+		return []EstimateLine{line}, nil
+	}
+	return lines, nil
+}
+
+// applyBenefits fills the member and plan amounts of an estimate. For each of
+// the low, typical and high scenarios the copay is charged once on the first
+// priced line, the rest of each line's allowed amount goes to the remaining
+// deductible and then to coinsurance, and the member never pays more than
+// the out-of-pocket maximum remaining.
+func applyBenefits(estimate *Estimate, benefits Benefits) {
+	// Each scenario picks its amount out of a range
+	scenarios := []func(*CostRange) *float64{
+		func(r *CostRange) *float64 { return &r.Low },
+		func(r *CostRange) *float64 { return &r.Typical },
+		func(r *CostRange) *float64 { return &r.High },
+	}
+
+	for i, pick := range scenarios {
+		deductible := benefits.DeductibleRemaining
+		oop := math.Inf(1)
+		if benefits.OutOfPocketRemaining != nil {
+			oop = *benefits.OutOfPocketRemaining
+		}
+		copayDue := true
+
+		for j := range estimate.Lines {
+			line := &estimate.Lines[j]
+			if line.Allowed == nil {
+				continue
+			}
+			if line.MemberCost == nil {
+				line.MemberCost, line.PlanPays, line.CostShare = &CostRange{}, &CostRange{}, &CostShare{}
+			}
+
+			allowed := *pick(line.Allowed)
+			var share CostShare
+
+			// pay takes up to amount from the remaining out-of-pocket maximum
+			pay := func(amount float64) float64 {
+				amount = math.Min(amount, oop)
+				oop -= amount
+				return amount
+			}
+
+			rest := allowed
+			if copayDue {
+				copay := math.Min(benefits.Copay, rest)
+				share.Copay = pay(copay)
+				rest -= copay
+				copayDue = false
+			}
+			toDeductible := math.Min(rest, deductible)
+			deductible -= toDeductible
+			share.Deductible = pay(toDeductible)
+			rest -= toDeductible
+			share.Coinsurance = pay(rest * benefits.CoinsurancePercent / 100)
+
+			member := round2(share.Copay + share.Deductible + share.Coinsurance)
+			*pick(line.MemberCost) = member
+			*pick(line.PlanPays) = round2(allowed - member)
+			*pick(&estimate.Allowed) += allowed
+			*pick(&estimate.MemberCost) += member
+
+			// The itemized split is that of the typical scenario
+			if i == 1 {
+				*line.CostShare = CostShare{round2(share.Copay), round2(share.Deductible), round2(share.Coinsurance)}
+				estimate.CostShare.Copay += share.Copay
+				estimate.CostShare.Deductible += share.Deductible
+				estimate.CostShare.Coinsurance += share.Coinsurance
+			}
+		}
+	}
+
+	for _, pick := range scenarios {
+		allowed := pick(&estimate.Allowed)
+		member := pick(&estimate.MemberCost)
+		*allowed, *member = round2(*allowed), round2(*member)
+		*pick(&estimate.PlanPays) = round2(*allowed - *member)
+	}
+	estimate.CostShare = CostShare{
+		round2(estimate.CostShare.Copay),
+		round2(estimate.CostShare.Deductible),
+		round2(estimate.CostShare.Coinsurance),
+	}
+}
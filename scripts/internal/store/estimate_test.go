@@ -0,0 +1,98 @@
+package store
+
+import "testing"
+
+// flat returns a range with the same low, typical and high amount
+func flat(amount float64) *CostRange {
+	return &CostRange{amount, amount, amount}
+}
+
+func TestApplyBenefits(t *testing.T) {
+	oop := func(amount float64) *float64 { return &amount }
+
+	for _, tc := range []struct {
+		name     string
+		allowed  []*CostRange
+		benefits Benefits
+		member   []float64 // typical member cost by line, -1 for unpriced lines
+		share    CostShare
+	}{
+		{
+			name:     "copay once, then deductible, then coinsurance",
+			allowed:  []*CostRange{flat(1000), flat(500)},
+			benefits: Benefits{Copay: 50, DeductibleRemaining: 300, CoinsurancePercent: 20},
+			member:   []float64{480, 100},
+			share:    CostShare{Copay: 50, Deductible: 300, Coinsurance: 230},
+		},
+		{
+			name:     "out-of-pocket maximum",
+			allowed:  []*CostRange{flat(1000), flat(500)},
+			benefits: Benefits{Copay: 50, DeductibleRemaining: 300, CoinsurancePercent: 20, OutOfPocketRemaining: oop(200)},
+			member:   []float64{200, 0},
+			share:    CostShare{Copay: 50, Deductible: 150},
+		},
+		{
+			name:     "copay on the first priced line",
+			allowed:  []*CostRange{nil, flat(30), flat(100)},
+			benefits: Benefits{Copay: 50},
+			member:   []float64{-1, 30, 0},
+			share:    CostShare{Copay: 30},
+		},
+		{
+			name:    "no cost sharing",
+			allowed: []*CostRange{flat(250)},
+			member:  []float64{0},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			estimate := &Estimate{}
+			for _, allowed := range tc.allowed {
+				estimate.Lines = append(estimate.Lines, EstimateLine{Allowed: allowed})
+			}
+			applyBenefits(estimate, tc.benefits)
+
+			var allowed, member float64
+			for i, line := range estimate.Lines {
+				if tc.member[i] < 0 {
+					if line.MemberCost != nil {
+						t.Errorf("line %d without an allowed amount has member cost %+v", i, *line.MemberCost)
+					}
+					continue
+				}
+				if line.MemberCost.Typical != tc.member[i] {
+					t.Errorf("line %d member cost = %v, want %v", i, line.MemberCost.Typical, tc.member[i])
+				}
+				if want := line.Allowed.Typical - tc.member[i]; line.PlanPays.Typical != want {
+					t.Errorf("line %d plan pays %v, want %v", i, line.PlanPays.Typical, want)
+				}
+				allowed += line.Allowed.Typical
+				member += tc.member[i]
+			}
+			if estimate.Allowed.Typical != allowed || estimate.MemberCost.Typical != member || estimate.PlanPays.Typical != allowed-member {
+				t.Errorf("totals = allowed %v, member %v, plan %v, want %v, %v, %v",
+					estimate.Allowed.Typical, estimate.MemberCost.Typical, estimate.PlanPays.Typical, allowed, member, allowed-member)
+			}
+			if estimate.CostShare != tc.share {
+				t.Errorf("cost share = %+v, want %+v", estimate.CostShare, tc.share)
+			}
+		})
+	}
+}
+
+// TestApplyBenefitsScenarios checks that the low, typical and high scenarios
+// each start from the full deductible
+func TestApplyBenefitsScenarios(t *testing.T) {
+	estimate := &Estimate{Lines: []EstimateLine{{Allowed: &CostRange{Low: 800, Typical: 1000, High: 1200}}}}
+	applyBenefits(estimate, Benefits{DeductibleRemaining: 1000, CoinsurancePercent: 20})
+
+	want := CostRange{Low: 800, Typical: 1000, High: 1040}
+	if *estimate.Lines[0].MemberCost != want || estimate.MemberCost != want {
+		t.Errorf("member cost = %+v, total %+v, want %+v", *estimate.Lines[0].MemberCost, estimate.MemberCost, want)
+	}
+	if want := (CostRange{Low: 0, Typical: 0, High: 160}); estimate.PlanPays != want {
+		t.Errorf("plan pays %+v, want %+v", estimate.PlanPays, want)
+	}
+	if want := (CostShare{Deductible: 1000}); estimate.CostShare != want {
+		t.Errorf("cost share = %+v, want the typical scenario's %+v", estimate.CostShare, want)
+	}
+}
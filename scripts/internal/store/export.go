@@ -0,0 +1,204 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultExportBatchSize is the number of services an export reads at a time
+const DefaultExportBatchSize = 200
+
+// ServiceRates is a service with its current rates, their distinct prices
+// and the run that loaded it
+type ServiceRates struct {
+	Service Service
+	Run     *Run // nil for data loaded before the run ledger
+	Rates   []Rate
+	Prices  []Price
+}
+
+// LookupFilter selects the rates of one billing code
+type LookupFilter struct {
+	BillingCodeType string
+	BillingCode     string
+	NPI             string // provider NPI, resolved through the run's provider groups
+	Payer           string // reporting entity name
+	Plan            string // plan name or plan id
+	BillingClass    string
+}
+
+// LookupRates returns the services of a billing code that have current rates
+// matching filter, each with those rates and their prices
+func (s *Store) LookupRates(ctx context.Context, filter LookupFilter) ([]ServiceRates, error) {
+	where, args := rateConditions(filter.BillingCodeType, filter.BillingCode, RateFilter{
+		Payer: filter.Payer,
+		Plan:  filter.Plan,
+		NPI:   filter.NPI,
+	}, nil)
+	if filter.BillingClass != "" {
+		where += " AND r.billing_class = ?"
+		args = append(args, filter.BillingClass)
+	}
+	rates, err := s.queryRates(ctx, where, args, 0)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := s.queryPrices(ctx, where, args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(rates))
+	for id := range rates {
+		ids = append(ids, id)
+	}
+	services, err := s.ServicesByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return s.serviceRates(ctx, services, rates, prices)
+}
+
+// ExportFilter narrows a rate export
+type ExportFilter struct {
+	BillingCodeType string
+	BillingCodes    []string
+	Payer           string // reporting entity name
+	Plan            string // plan name or plan id
+}
+
+// ExportRates calls fn for every active service matching filter, in id
+// order, with all of its current rates and prices. Services are read in
+// batches of batchSize by id, so no cursor stays open while fn runs and
+// memory is bounded by one batch. An error returned by fn stops the export.
+func (s *Store) ExportRates(ctx context.Context, filter ExportFilter, batchSize int, fn func(ServiceRates) error) error {
+	if batchSize <= 0 {
+		batchSize = DefaultExportBatchSize
+	}
+
+	conditions := []string{"s.id > ?"}
+	var args []interface{}
+	if filter.BillingCodeType != "" {
+		conditions = append(conditions, "s.billing_code_type = ?")
+		args = append(args, filter.BillingCodeType)
+	}
+	if len(filter.BillingCodes) > 0 {
+		conditions = append(conditions, fmt.Sprintf("s.billing_code IN (%s)", placeholders(len(filter.BillingCodes), "?")))
+		args = append(args, stringArgs(filter.BillingCodes)...)
+	}
+	if filter.Payer != "" {
+		conditions = append(conditions, "ir.reporting_entity_name = ?")
+		args = append(args, filter.Payer)
+	}
+	if filter.Plan != "" {
+		conditions = append(conditions, "(ir.plan_name = ? OR ir.plan_id = ?)")
+		args = append(args, filter.Plan, filter.Plan)
+	}
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM active_insurance_services s
+		LEFT JOIN ingestion_runs ir ON ir.id = s.run_id
+		WHERE %s
+		ORDER BY s.id
+		LIMIT ?
+	`, serviceColumns, strings.Join(conditions, " AND "))
+
+	var lastID int64
+	for {
+		batch, err := s.exportBatch(ctx, query, append(append([]interface{}{lastID}, args...), batchSize))
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		ids := make([]int64, len(batch))
+		services := make(map[int64]Service, len(batch))
+		for i, service := range batch {
+			ids[i] = service.ID
+			services[service.ID] = service
+		}
+		rates, err := s.RatesByService(ctx, ids, RateListFilter{}, 0)
+		if err != nil {
+			return err
+		}
+		prices, err := s.PricesByService(ctx, ids, 0)
+		if err != nil {
+			return err
+		}
+		results, err := s.serviceRates(ctx, services, rates, prices)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			if err := fn(result); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < batchSize {
+			return nil
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
+
+// exportBatch reads one batch of services of an export
+func (s *Store) exportBatch(ctx context.Context, query string, args []interface{}) ([]Service, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query services: %v", err)
+	}
+	defer rows.Close()
+
+	var services []Service
+	for rows.Next() {
+		service, err := scanService(rows)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read services: %v", err)
+	}
+	return services, nil
+}
+
+// serviceRates combines services with their rates, prices and runs, in
+// service id order
+func (s *Store) serviceRates(ctx context.Context, services map[int64]Service, rates map[int64][]Rate, prices map[int64][]Price) ([]ServiceRates, error) {
+	var runIDs []int64
+	for _, service := range services {
+		if service.RunID != nil {
+			runIDs = append(runIDs, *service.RunID)
+		}
+	}
+	runs, err := s.RunsByID(ctx, runIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ServiceRates, 0, len(services))
+	for _, service := range services {
+		result := ServiceRates{
+			Service: service,
+			Rates:   rates[service.ID],
+			Prices:  prices[service.ID],
+		}
+		if service.RunID != nil {
+			if run, ok := runs[*service.RunID]; ok {
+				result.Run = &run
+			}
+		}
+		results = append(results, result)
+	}
+	slices.SortFunc(results, func(a, b ServiceRates) int {
+		return cmp.Compare(a.Service.ID, b.Service.ID)
+	})
+	return results, nil
+}
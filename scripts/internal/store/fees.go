@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"fmt"
+)
+
+// effectiveRateSQL returns the expression for the effective dollar amount of
+// the rate or price aliased rate of the service aliased service. Percentage
+// rates store a percentage such as 64.00, not dollars, so they are applied to
+// the reference fee of the billing code and billing class; without one the
+// amount is NULL and the rate is not comparable to dollar rates.
+func effectiveRateSQL(service, rate string) string {
+	return fmt.Sprintf(`(CASE WHEN %[2]s.negotiated_type = 'negotiated' THEN %[2]s.negotiated_rate
+		ELSE ROUND((SELECT f.amount FROM reference_fees f
+			WHERE f.billing_code_type = %[1]s.billing_code_type AND f.billing_code = %[1]s.billing_code
+				AND f.billing_class = %[2]s.billing_class) * %[2]s.negotiated_rate / 100, 2) END)`, service, rate)
+}
+
+// referenceFees returns the reference fees of a billing code by billing class
+func (s *Store) referenceFees(ctx context.Context, codeType, code string) (map[string]float64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT billing_class, amount FROM reference_fees WHERE billing_code_type = ? AND billing_code = ?
+	`, codeType, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reference fees: %v", err)
+	}
+	defer rows.Close()
+
+	fees := make(map[string]float64)
+	for rows.Next() {
+		var billingClass string
+		var amount float64
+		if err := rows.Scan(&billingClass, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan reference fee: %v", err)
+		}
+		fees[billingClass] = amount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reference fees: %v", err)
+	}
+	return fees, nil
+}
+
+// rateBase returns the base percentage rates of a billing class apply to:
+// the caller's base when given, else the reference fee, else 0
+func rateBase(base float64, fees map[string]float64, billingClass string) float64 {
+	if base > 0 {
+		return base
+	}
+	return fees[billingClass]
+}
@@ -0,0 +1,32 @@
+package store
+
+import "testing"
+
+func TestEffectiveRate(t *testing.T) {
+	fees := map[string]float64{"professional": 250}
+
+	for _, tc := range []struct {
+		name           string
+		negotiatedType string
+		rate, base     float64
+		want           float64 // -1 when not comparable
+	}{
+		{"dollar rate", "negotiated", 180.5, 0, 180.5},
+		{"dollar rate ignores the base", "negotiated", 180.5, 250, 180.5},
+		{"percentage of the reference fee", "percentage", 64, rateBase(0, fees, "professional"), 160},
+		{"percentage of the caller's base", "percentage", 64, rateBase(1000, fees, "professional"), 640},
+		{"percentage rounded to cents", "percentage", 33.333, 100, 33.33},
+		{"percentage without a reference fee", "percentage", 64, rateBase(0, fees, "institutional"), -1},
+		{"unknown type", "per diem", 900, 250, -1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := effectiveRate(tc.negotiatedType, tc.rate, tc.base)
+			switch {
+			case tc.want < 0 && got != nil:
+				t.Errorf("effectiveRate = %v, want not comparable", *got)
+			case tc.want >= 0 && (got == nil || *got != tc.want):
+				t.Errorf("effectiveRate = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MaxRadiusMiles is the largest supported search radius
+const MaxRadiusMiles = 100
+
+// metersPerMile converts ST_Distance_Sphere meters to miles
+const metersPerMile = 1609.344
+
+// ErrUnknownZIP is returned when a radius search starts from a ZIP code that
+// is not in zip_centroids
+var ErrUnknownZIP = errors.New("unknown ZIP code")
+
+// GeoFilter limits results to providers within a radius of a ZIP code.
+// Providers are placed at the centroid of the five-digit ZIP of their address.
+type GeoFilter struct {
+	ZIP         string
+	RadiusMiles float64
+}
+
+// nearbyArea is a resolved GeoFilter: its origin and the ZIP codes in range
+type nearbyArea struct {
+	lat, lon float64
+	zips     []string
+}
+
+// nearby resolves a radius filter. The spatial index on zip_centroids
+// prefilters by bounding box; the exact great-circle distance decides.
+func (s *Store) nearby(ctx context.Context, near *GeoFilter) (*nearbyArea, error) {
+	if near == nil {
+		return nil, nil
+	}
+
+	area := &nearbyArea{}
+	err := s.db.QueryRowContext(ctx, "SELECT latitude, longitude FROM zip_centroids WHERE zip = ?", near.ZIP).
+		Scan(&area.lat, &area.lon)
+	if err == sql.ErrNoRows {
+		return nil, ErrUnknownZIP
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ZIP code: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT zip FROM zip_centroids
+		WHERE MBRContains(ST_GeomFromText(?), location)
+			AND ST_Distance_Sphere(location, POINT(?, ?)) <= ?
+	`, boundingBox(area.lat, area.lon, near.RadiusMiles), area.lon, area.lat, near.RadiusMiles*metersPerMile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find nearby ZIP codes: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var zip string
+		if err := rows.Scan(&zip); err != nil {
+			return nil, fmt.Errorf("failed to scan ZIP code: %v", err)
+		}
+		area.zips = append(area.zips, zip)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read nearby ZIP codes: %v", err)
+	}
+	if len(area.zips) == 0 {
+		area.zips = []string{near.ZIP}
+	}
+
+	return area, nil
+}
+
+// distanceSQL returns the expression for the distance in miles from the
+// origin to the zip_centroids row aliased z, with its arguments
+func (a *nearbyArea) distanceSQL() (string, []interface{}) {
+	return fmt.Sprintf("ST_Distance_Sphere(z.location, POINT(?, ?)) / %g", metersPerMile), []interface{}{a.lon, a.lat}
+}
+
+// zipArgs returns the nearby ZIP codes as query arguments
+func (a *nearbyArea) zipArgs() []interface{} {
+	args := make([]interface{}, len(a.zips))
+	for i, zip := range a.zips {
+		args[i] = zip
+	}
+	return args
+}
+
+// boundingBox returns the WKT polygon around a point that contains every
+// point within radius miles, in the longitude/latitude order of
+// zip_centroids.location
+func boundingBox(lat, lon, radius float64) string {
+	const milesPerDegree = 69.0
+	dLat := radius / milesPerDegree
+	dLon := radius / (milesPerDegree * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+
+	minLon, maxLon := lon-dLon, lon+dLon
+	minLat, maxLat := lat-dLat, lat+dLat
+	return fmt.Sprintf("POLYGON((%f %f, %f %f, %f %f, %f %f, %f %f))",
+		minLon, minLat, maxLon, minLat, maxLon, maxLat, minLon, maxLat, minLon, minLat)
+}
+
+// roundDistance rounds a distance in miles to one decimal
+func roundDistance(miles float64) float64 {
+	return math.Round(miles*10) / 10
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RunChange is a run whose data became visible: it completed, or it was
+// activated or replaced as the active version of its reporting entity. A
+// change with a LoadID instead is a load made outside runs, such as
+// reference fees or the provider directory.
+type RunChange struct {
+	RunID               int64
+	LoadID              int64
+	ReportingEntityName string
+	At                  time.Time
+}
+
+// BillingCode identifies a billing code
+type BillingCode struct {
+	Type string
+	Code string
+}
+
+// RunChanges returns the runs and loads that changed at or after since, by the
+// database clock, and the database time of the check to pass as since next
+// time. Callers overlap successive windows slightly and skip the runs they
+// have seen, since timestamps only have second precision.
+func (s *Store) RunChanges(ctx context.Context, since time.Time) ([]RunChange, time.Time, error) {
+	var now time.Time
+	if err := s.db.QueryRowContext(ctx, "SELECT NOW()").Scan(&now); err != nil {
+		return nil, now, fmt.Errorf("failed to read database time: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, 0, COALESCE(reporting_entity_name, ''), finished_at
+		FROM ingestion_runs
+		WHERE status = 'completed' AND finished_at >= ?
+		UNION ALL
+		SELECT v.run_id, 0, v.reporting_entity_name, v.activated_at
+		FROM active_file_versions v
+		WHERE v.activated_at >= ?
+		UNION ALL
+		SELECT v.previous_run_id, 0, v.reporting_entity_name, v.activated_at
+		FROM active_file_versions v
+		WHERE v.activated_at >= ? AND v.previous_run_id IS NOT NULL
+		UNION ALL
+		SELECT 0, id, '', loaded_at
+		FROM data_loads
+		WHERE loaded_at >= ?
+	`, since, since, since, since)
+	if err != nil {
+		return nil, now, fmt.Errorf("failed to query run changes: %v", err)
+	}
+	defer rows.Close()
+
+	var changes []RunChange
+	for rows.Next() {
+		var change RunChange
+		if err := rows.Scan(&change.RunID, &change.LoadID, &change.ReportingEntityName, &change.At); err != nil {
+			return nil, now, fmt.Errorf("failed to scan run change: %v", err)
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, now, fmt.Errorf("failed to read run changes: %v", err)
+	}
+	return changes, now, nil
+}
+
+// RunBillingCodes returns the distinct billing codes of the services runs
+// loaded and of the rates they inserted, extended or closed: history mode
+// extends the services and rates of earlier runs. Runs whose data was
+// deleted contribute nothing.
+func (s *Store) RunBillingCodes(ctx context.Context, runIDs []int64) ([]BillingCode, error) {
+	if len(runIDs) == 0 {
+		return nil, nil
+	}
+
+	in := placeholders(len(runIDs), "?")
+	var args []interface{}
+	for i := 0; i < 4; i++ {
+		args = append(args, int64Args(runIDs)...)
+	}
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT billing_code_type, billing_code FROM insurance_services WHERE run_id IN (%[1]s)
+		UNION
+		SELECT s.billing_code_type, s.billing_code
+		FROM negotiated_rates r
+		JOIN insurance_services s ON s.id = r.service_id
+		WHERE r.run_id IN (%[1]s) OR r.last_seen_run_id IN (%[1]s) OR r.closed_run_id IN (%[1]s)
+	`, in), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query billing codes of runs: %v", err)
+	}
+	defer rows.Close()
+
+	var codes []BillingCode
+	for rows.Next() {
+		var code BillingCode
+		if err := rows.Scan(&code.Type, &code.Code); err != nil {
+			return nil, fmt.Errorf("failed to scan billing code: %v", err)
+		}
+		codes = append(codes, code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read billing codes of runs: %v", err)
+	}
+	return codes, nil
+}
+
+// LoadBillingCodes returns the distinct billing codes changed by loads made
+// outside runs, and whether one of them changed every billing code
+func (s *Store) LoadBillingCodes(ctx context.Context, loadIDs []int64) ([]BillingCode, bool, error) {
+	if len(loadIDs) == 0 {
+		return nil, false, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT DISTINCT l.all_codes, c.billing_code_type, c.billing_code
+		FROM data_loads l
+		LEFT JOIN data_load_codes c ON c.load_id = l.id
+		WHERE l.id IN (%s)
+	`, placeholders(len(loadIDs), "?")), int64Args(loadIDs)...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query billing codes of loads: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		codes []BillingCode
+		all   bool
+	)
+	for rows.Next() {
+		var (
+			allCodes           bool
+			codeType, codeName sql.NullString
+		)
+		if err := rows.Scan(&allCodes, &codeType, &codeName); err != nil {
+			return nil, false, fmt.Errorf("failed to scan billing code: %v", err)
+		}
+		all = all || allCodes
+		if codeType.Valid {
+			codes = append(codes, BillingCode{Type: codeType.String, Code: codeName.String})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("failed to read billing codes of loads: %v", err)
+	}
+	return codes, all, nil
+}
@@ -0,0 +1,337 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"healthcare-saver-ingest/internal/medicare"
+)
+
+// Address is the postal address of a provider
+type Address struct {
+	Street string `json:"street"`
+	City   string `json:"city"`
+	State  string `json:"state"`
+	Zip    string `json:"zip"`
+}
+
+// ContactInfo holds the contact details of a provider
+type ContactInfo struct {
+	Phone   string `json:"phone"`
+	Email   string `json:"email"`
+	Website string `json:"website"`
+}
+
+// Provider is a provider of the directory, matching the Provider interface
+// of the web app
+type Provider struct {
+	ID                     int         `json:"id"`
+	Name                   string      `json:"name"`
+	NPI                    string      `json:"npi"`
+	Address                Address     `json:"address"`
+	ContactInfo            ContactInfo `json:"contactInfo"`
+	CMSCertificationNumber string      `json:"cmsCertificationNumber"`
+	CreatedAt              time.Time   `json:"createdAt"`
+	UpdatedAt              time.Time   `json:"updatedAt"`
+}
+
+// StandardCharge is the pricing of one service at a provider, matching the
+// StandardCharge interface of the web app. Payer files carry no gross charges
+// or cash prices, so those are null.
+type StandardCharge struct {
+	ID                int              `json:"id"`
+	ProviderID        int              `json:"providerId"`
+	ServiceID         int              `json:"serviceId"`
+	GrossCharge       *float64         `json:"grossCharge"`
+	CashPrice         *float64         `json:"cashPrice"`
+	MinNegotiatedRate *float64         `json:"minNegotiatedRate"`
+	MaxNegotiatedRate *float64         `json:"maxNegotiatedRate"`
+	EffectiveDate     string           `json:"effectiveDate"`
+	ExpirationDate    string           `json:"expirationDate,omitempty"`
+	BillingCodeType   string           `json:"billingCodeType"`
+	BillingCode       string           `json:"billingCode"`
+	ServiceName       string           `json:"serviceName"`
+	NegotiatedRates   []NegotiatedRate `json:"negotiatedRates"`
+}
+
+// NegotiatedRate is one payer's rate within a standard charge, matching the
+// NegotiatedRate interface of the web app
+type NegotiatedRate struct {
+	ID               int64    `json:"id"`
+	StandardChargeID int      `json:"standardChargeId"`
+	PayerName        string   `json:"payerName"`
+	PlanName         string   `json:"planName"`
+	NegotiatedRate   float64  `json:"negotiatedRate"`
+	NegotiatedType   string   `json:"negotiatedType" enum:"NegotiatedType"`
+	EffectiveRate    *float64 `json:"effectiveRate"` // percentage rates applied to the reference fee, nil without one
+	Comparable       bool     `json:"comparable"`
+	MedicareAmount   *float64 `json:"medicareAmount"` // in the provider's PFS locality when known, else national
+	PctOfMedicare    *float64 `json:"pctOfMedicare"`
+	BillingClass     string   `json:"billingClass" enum:"BillingClass"`
+	ExpirationDate   string   `json:"expirationDate"`
+}
+
+// ProviderFilter narrows the provider list
+type ProviderFilter struct {
+	State string
+	City  string
+	Code  string // only providers with current rates for this billing code
+}
+
+// ProviderPage is one page of providers
+type ProviderPage struct {
+	Results  []Provider `json:"results"`
+	Total    int        `json:"total"`
+	Page     int        `json:"page"`
+	PageSize int        `json:"pageSize"`
+}
+
+// ProviderRates is one page of the standard charges of a provider
+type ProviderRates struct {
+	Provider        Provider         `json:"provider"`
+	StandardCharges []StandardCharge `json:"standardCharges"`
+	Total           int              `json:"total"`
+	Page            int              `json:"page"`
+	PageSize        int              `json:"pageSize"`
+}
+
+// providerColumns are the columns scanned by scanProvider
+const providerColumns = `p.id, p.name, p.npi, p.address, p.contact_info,
+	COALESCE(p.cms_certification_number, ''), p.created_at, p.updated_at`
+
+// providerRates joins a provider's NPI to the current rates of the provider
+// groups it belongs to. provider_group_id is only unique within a run, and a
+// rate's groups are those of the last run that published it.
+const providerRates = `
+	FROM provider_group_npis pn
+	JOIN negotiated_rate_provider_groups pg ON pg.provider_group_id = pn.provider_group_id
+	JOIN active_negotiated_rates r ON r.id = pg.negotiated_rate_id AND r.last_seen_run_id = pn.run_id
+	JOIN active_insurance_services s ON s.id = r.service_id
+`
+
+// scanProvider scans the columns listed in providerColumns followed by the
+// given extra columns
+func scanProvider(row rowScanner, extra ...interface{}) (Provider, error) {
+	var (
+		provider                 Provider
+		addressJSON, contactJSON []byte
+	)
+	dest := []interface{}{&provider.ID, &provider.Name, &provider.NPI, &addressJSON, &contactJSON,
+		&provider.CMSCertificationNumber, &provider.CreatedAt, &provider.UpdatedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return provider, err
+	}
+
+	if err := json.Unmarshal(addressJSON, &provider.Address); err != nil {
+		return provider, fmt.Errorf("failed to parse address of provider %s: %v", provider.NPI, err)
+	}
+	if err := json.Unmarshal(contactJSON, &provider.ContactInfo); err != nil {
+		return provider, fmt.Errorf("failed to parse contact info of provider %s: %v", provider.NPI, err)
+	}
+	return provider, nil
+}
+
+// Providers lists providers of the directory by name
+func (s *Store) Providers(ctx context.Context, filter ProviderFilter, page, pageSize int) (*ProviderPage, error) {
+	var (
+		conditions = []string{"1 = 1"}
+		args       []interface{}
+	)
+	if filter.State != "" {
+		conditions = append(conditions, "p.address_state = ?")
+		args = append(args, strings.ToUpper(filter.State))
+	}
+	if filter.City != "" {
+		conditions = append(conditions, "p.address_city = ?")
+		args = append(args, filter.City)
+	}
+	if filter.Code != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 `+providerRates+`
+			WHERE pn.npi = p.npi AND s.billing_code = ? AND r.valid_to IS NULL)`)
+		args = append(args, filter.Code)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+providerColumns+`, COUNT(*) OVER ()
+		FROM providers p
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY p.name, p.id
+		LIMIT ? OFFSET ?
+	`, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query providers: %v", err)
+	}
+	defer rows.Close()
+
+	result := &ProviderPage{Results: []Provider{}, Page: page, PageSize: pageSize}
+	for rows.Next() {
+		provider, err := scanProvider(rows, &result.Total)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan provider: %v", err)
+		}
+		result.Results = append(result.Results, provider)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read providers: %v", err)
+	}
+
+	return result, nil
+}
+
+// Provider returns a provider by NPI or ErrNotFound
+func (s *Store) Provider(ctx context.Context, npi string) (*Provider, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+providerColumns+` FROM providers p WHERE p.npi = ?`, npi)
+	provider, err := scanProvider(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider: %v", err)
+	}
+	return &provider, nil
+}
+
+// chargeKey identifies a standard charge within a page
+type chargeKey struct {
+	codeType string
+	code     string
+}
+
+// ProviderRates returns one page of a provider's standard charges, one per
+// billing code, with the current rate of every payer and plan. code limits
+// the result to a single billing code when not empty.
+func (s *Store) ProviderRates(ctx context.Context, npi, code string, page, pageSize int) (*ProviderRates, error) {
+	provider, err := s.Provider(ctx, npi)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ProviderRates{Provider: *provider, StandardCharges: []StandardCharge{}, Page: page, PageSize: pageSize}
+
+	where := "pn.npi = ? AND r.valid_to IS NULL"
+	args := []interface{}{npi}
+	if code != "" {
+		where += " AND s.billing_code = ?"
+		args = append(args, code)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.billing_code_type, s.billing_code, MIN(s.id), MIN(s.name),
+			MIN(`+effectiveRateSQL("s", "r")+`), MAX(`+effectiveRateSQL("s", "r")+`),
+			COALESCE(DATE_FORMAT(MIN(r.valid_from), '%Y-%m-%d'), ''),
+			DATE_FORMAT(MAX(r.expiration_date), '%Y-%m-%d'),
+			COUNT(*) OVER ()
+	`+providerRates+`
+		WHERE `+where+`
+		GROUP BY s.billing_code_type, s.billing_code
+		ORDER BY s.billing_code_type, s.billing_code
+		LIMIT ? OFFSET ?
+	`, append(args, pageSize, (page-1)*pageSize)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query standard charges: %v", err)
+	}
+	defer rows.Close()
+
+	index := make(map[chargeKey]int)
+	for rows.Next() {
+		var (
+			charge   StandardCharge
+			min, max sql.NullFloat64
+		)
+		err := rows.Scan(&charge.BillingCodeType, &charge.BillingCode, &charge.ServiceID, &charge.ServiceName,
+			&min, &max, &charge.EffectiveDate, &charge.ExpirationDate, &result.Total)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan standard charge: %v", err)
+		}
+		charge.ID = charge.ServiceID
+		charge.ProviderID = provider.ID
+		charge.MinNegotiatedRate = nullFloat(min)
+		charge.MaxNegotiatedRate = nullFloat(max)
+		charge.NegotiatedRates = []NegotiatedRate{}
+
+		index[chargeKey{charge.BillingCodeType, charge.BillingCode}] = len(result.StandardCharges)
+		result.StandardCharges = append(result.StandardCharges, charge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read standard charges: %v", err)
+	}
+
+	if len(result.StandardCharges) == 0 {
+		return result, nil
+	}
+
+	if err := s.loadChargeRates(ctx, npi, result.StandardCharges, index); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// loadChargeRates fills the negotiated rates of a page of standard charges
+func (s *Store) loadChargeRates(ctx context.Context, npi string, charges []StandardCharge, index map[chargeKey]int) error {
+	locality, err := medicare.ProviderLocality(ctx, s.db, npi)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{locality, npi}
+	for _, charge := range charges {
+		args = append(args, charge.BillingCodeType, charge.BillingCode)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT r.id, s.billing_code_type, s.billing_code,
+			COALESCE(ir.reporting_entity_name, ''), COALESCE(ir.plan_name, ''),
+			r.negotiated_rate, r.negotiated_type, `+effectiveRateSQL("s", "r")+` AS effective_rate,
+			`+medicare.AmountSQL("s.billing_code_type", "s.billing_code", "r.billing_class", "NULLIF(?, '')")+`,
+			r.billing_class, DATE_FORMAT(r.expiration_date, '%Y-%m-%d')
+	`+providerRates+`
+		LEFT JOIN ingestion_runs ir ON ir.id = r.run_id
+		WHERE pn.npi = ? AND r.valid_to IS NULL
+			AND (s.billing_code_type, s.billing_code) IN (`+placeholders(len(charges), "(?, ?)")+`)
+		ORDER BY effective_rate IS NULL, effective_rate, r.negotiated_rate, r.id
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query provider rates: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rate      NegotiatedRate
+			key       chargeKey
+			effective sql.NullFloat64
+		)
+		err := rows.Scan(&rate.ID, &key.codeType, &key.code, &rate.PayerName, &rate.PlanName,
+			&rate.NegotiatedRate, &rate.NegotiatedType, &effective, &rate.MedicareAmount, &rate.BillingClass, &rate.ExpirationDate)
+		if err != nil {
+			return fmt.Errorf("failed to scan provider rate: %v", err)
+		}
+		i, ok := index[key]
+		if !ok {
+			continue
+		}
+		rate.PayerName = payerName(rate.PayerName)
+		rate.EffectiveRate = nullFloat(effective)
+		rate.Comparable = effective.Valid
+		rate.PctOfMedicare = medicare.Pct(rate.EffectiveRate, rate.MedicareAmount)
+		rate.StandardChargeID = charges[i].ID
+		charges[i].NegotiatedRates = append(charges[i].NegotiatedRates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read provider rates: %v", err)
+	}
+
+	return nil
+}
+
+// nullFloat converts a nullable column to a JSON-friendly pointer
+func nullFloat(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
@@ -0,0 +1,500 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"healthcare-saver-ingest/internal/aggregate"
+	"healthcare-saver-ingest/internal/medicare"
+)
+
+// RateFilter narrows the rates of a billing code
+type RateFilter struct {
+	Payer           string // reporting entity name
+	Plan            string // plan name or plan id
+	ProviderGroupID int
+	RunID           int64  // run of ProviderGroupID: group ids are only unique within a file
+	NPI             string // provider NPI, resolved through the run's provider groups
+	PlaceOfService  string
+	State           string     // two-letter state of the providers, by the provider directory
+	Near            *GeoFilter // providers within a radius of a ZIP code
+}
+
+// aggregated reports whether the statistics of the rates matching a filter
+// are kept in rate_aggregates, which only break rates down by payer and state
+func (f RateFilter) aggregated() bool {
+	return f.Plan == "" && f.ProviderGroupID == 0 && f.NPI == "" && f.PlaceOfService == "" && f.Near == nil
+}
+
+// CodeRate is a single negotiated rate of a billing code
+type CodeRate struct {
+	ID                 int64    `json:"id"`
+	PayerName          string   `json:"payerName"`
+	PlanName           string   `json:"planName"`
+	PlanID             string   `json:"planId"`
+	NegotiatedType     string   `json:"negotiatedType" enum:"NegotiatedType"`
+	NegotiatedRate     float64  `json:"negotiatedRate"`
+	EffectiveRate      *float64 `json:"effectiveRate"` // dollars, null for percentage rates without a reference fee
+	Comparable         bool     `json:"comparable"`
+	MedicareAmount     *float64 `json:"medicareAmount"` // Medicare amount of the billing class, null when not priced
+	PctOfMedicare      *float64 `json:"pctOfMedicare"`  // effective rate as a percent of the Medicare amount
+	ExpirationDate     string   `json:"expirationDate"`
+	BillingClass       string   `json:"billingClass" enum:"BillingClass"`
+	ProviderReferences []int    `json:"providerReferences"`
+	ServiceCodes       []string `json:"serviceCodes"`
+	Distance           *float64 `json:"distance,omitempty"` // miles to the nearest provider, with a near filter
+}
+
+// RateGroupStats are the statistics of one billing class and negotiated type.
+// Dollar groups of billing codes Medicare prices also carry the statistics
+// as percents of the Medicare amount.
+type RateGroupStats struct {
+	BillingClass   string     `json:"billingClass" enum:"BillingClass"`
+	NegotiatedType string     `json:"negotiatedType" enum:"NegotiatedType"`
+	Stats          RateStats  `json:"stats"`
+	MedicareAmount *float64   `json:"medicareAmount"`
+	PctOfMedicare  *RateStats `json:"pctOfMedicare"`
+}
+
+// CodeRates is every negotiated rate of a billing code with its distribution
+type CodeRates struct {
+	BillingCodeType string           `json:"billingCodeType"`
+	BillingCode     string           `json:"billingCode"`
+	Name            string           `json:"name"`
+	Groups          []RateGroupStats `json:"groups"`
+	// StatsRefreshedAt is when the statistics of groups were precomputed,
+	// or null when they were computed from the rates for this request
+	StatsRefreshedAt *time.Time `json:"statsRefreshedAt"`
+	MedicareLocality string     `json:"medicareLocality"` // PFS locality of the npi's provider, "" for national Medicare amounts
+	Rates            []CodeRate `json:"rates"`
+	Total            int        `json:"total"` // rates counted by the statistics of groups
+	// NextCursor is the after parameter of the next page, "" on the last page
+	NextCursor string `json:"nextCursor"`
+	PageSize   int    `json:"pageSize"`
+}
+
+// rateConditions builds the WHERE conditions and arguments for the current
+// rates of a billing code. The query must alias active_insurance_services as s,
+// active_negotiated_rates as r and ingestion_runs as ir. area is the resolved
+// filter.Near, or nil. Provider groups resolve against the roster of the last
+// run that published a rate, which history mode moves forward.
+func rateConditions(codeType, code string, filter RateFilter, area *nearbyArea) (string, []interface{}) {
+	conditions := []string{"s.billing_code_type = ?", "s.billing_code = ?", "r.valid_to IS NULL"}
+	args := []interface{}{codeType, code}
+
+	if filter.Payer != "" {
+		conditions = append(conditions, "ir.reporting_entity_name = ?")
+		args = append(args, filter.Payer)
+	}
+	if filter.Plan != "" {
+		conditions = append(conditions, "(ir.plan_name = ? OR ir.plan_id = ?)")
+		args = append(args, filter.Plan, filter.Plan)
+	}
+	if filter.ProviderGroupID != 0 {
+		conditions = append(conditions, `r.last_seen_run_id = ? AND EXISTS (SELECT 1 FROM negotiated_rate_provider_groups pg
+			WHERE pg.negotiated_rate_id = r.id AND pg.provider_group_id = ?)`)
+		args = append(args, filter.RunID, filter.ProviderGroupID)
+	}
+	if filter.NPI != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM negotiated_rate_provider_groups pg
+			JOIN provider_group_npis pn ON pn.provider_group_id = pg.provider_group_id AND pn.run_id = r.last_seen_run_id
+			WHERE pg.negotiated_rate_id = r.id AND pn.npi = ?)`)
+		args = append(args, filter.NPI)
+	}
+	if filter.PlaceOfService != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM negotiated_rate_places_of_service pos
+			WHERE pos.negotiated_rate_id = r.id AND pos.place_of_service = ?)`)
+		args = append(args, filter.PlaceOfService)
+	}
+	if filter.State != "" {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM negotiated_rate_provider_groups pg
+			JOIN provider_group_npis pn ON pn.provider_group_id = pg.provider_group_id AND pn.run_id = r.last_seen_run_id
+			JOIN providers p ON p.npi = pn.npi
+			WHERE pg.negotiated_rate_id = r.id AND p.address_state = ?)`)
+		args = append(args, filter.State)
+	}
+	if area != nil {
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (SELECT 1 FROM negotiated_rate_provider_groups pg
+			JOIN provider_group_npis pn ON pn.provider_group_id = pg.provider_group_id AND pn.run_id = r.last_seen_run_id
+			JOIN providers p ON p.npi = pn.npi
+			WHERE pg.negotiated_rate_id = r.id AND p.address_zip5 IN (%s))`, placeholders(len(area.zips), "?")))
+		args = append(args, area.zipArgs()...)
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// rateFrom joins a service's rates to the run that loaded them
+const rateFrom = `
+	FROM active_insurance_services s
+	JOIN active_negotiated_rates r ON r.service_id = s.id
+	LEFT JOIN ingestion_runs ir ON ir.id = r.run_id
+`
+
+// ErrNotFound is returned when the requested entity does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrInvalidCursor is returned for a page cursor CodeRates did not issue
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CodeRates returns the page of the current rates of a billing code after a
+// cursor ("" for the first page), lowest effective rate first and
+// non-comparable rates last, with statistics over all matching rates by
+// billing class and negotiated type. The statistics, and the total they
+// count, come from rate_aggregates when the filter allows, with approximate
+// percentiles. Medicare amounts are national unless the filter names a
+// provider with a known locality. It returns ErrNotFound for unknown billing
+// codes and ErrInvalidCursor for malformed cursors.
+func (s *Store) CodeRates(ctx context.Context, codeType, code string, filter RateFilter, after string, pageSize int) (*CodeRates, error) {
+	result := &CodeRates{
+		BillingCodeType: codeType,
+		BillingCode:     code,
+		Groups:          []RateGroupStats{},
+		Rates:           []CodeRate{},
+		PageSize:        pageSize,
+	}
+
+	var cursor *rateCursor
+	if after != "" {
+		c, err := parseRateCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		cursor = &c
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT billing_code_type, name FROM active_insurance_services
+		WHERE billing_code_type = ? AND billing_code = ?
+		ORDER BY id LIMIT 1
+	`, codeType, code).Scan(&result.BillingCodeType, &result.Name)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find billing code: %v", err)
+	}
+
+	area, err := s.nearby(ctx, filter.Near)
+	if err != nil {
+		return nil, err
+	}
+	where, args := rateConditions(codeType, code, filter, area)
+
+	groups, refreshedAt, err := s.aggregateGroupStats(ctx, codeType, code, filter)
+	if err != nil {
+		return nil, err
+	}
+	if groups == nil {
+		groups, err = s.rateGroupStats(ctx, where, args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	result.Groups = groups
+	result.StatsRefreshedAt = refreshedAt
+	for _, group := range groups {
+		result.Total += group.Stats.Count
+	}
+
+	if filter.NPI != "" {
+		if result.MedicareLocality, err = medicare.ProviderLocality(ctx, s.db, filter.NPI); err != nil {
+			return nil, err
+		}
+	}
+	amounts, err := medicare.Amounts(ctx, s.db, codeType, code, result.MedicareLocality)
+	if err != nil {
+		return nil, err
+	}
+	for i, group := range result.Groups {
+		amount := medicare.Lookup(amounts, group.BillingClass)
+		if amount != nil && group.NegotiatedType == "negotiated" {
+			pct := group.Stats.percentOf(*amount)
+			result.Groups[i].MedicareAmount, result.Groups[i].PctOfMedicare = amount, &pct
+		}
+	}
+
+	// The distance of a rate is that of its nearest provider in range
+	distance, queryArgs := "NULL", []interface{}{}
+	if area != nil {
+		distanceExpr, distanceArgs := area.distanceSQL()
+		distance = fmt.Sprintf(`(SELECT MIN(%s) FROM negotiated_rate_provider_groups pg
+			JOIN provider_group_npis pn ON pn.provider_group_id = pg.provider_group_id AND pn.run_id = r.last_seen_run_id
+			JOIN providers p ON p.npi = pn.npi
+			JOIN zip_centroids z ON z.zip = p.address_zip5
+			WHERE pg.negotiated_rate_id = r.id AND p.address_zip5 IN (%s))`, distanceExpr, placeholders(len(area.zips), "?"))
+		queryArgs = append(append(queryArgs, distanceArgs...), area.zipArgs()...)
+	}
+	queryArgs = append(queryArgs, args...)
+
+	// Pages are read by keyset on the sort order: a rate without an
+	// effective rate sorts as 0 behind the IS NULL flag
+	effectiveRate := effectiveRateSQL("s", "r")
+	if cursor != nil {
+		where += ` AND (` + effectiveRate + ` IS NULL, COALESCE(` + effectiveRate + `, 0), r.negotiated_rate, r.id) > (?, ?, ?, ?)`
+		queryArgs = append(queryArgs, cursor.args()...)
+	}
+	queryArgs = append(queryArgs, pageSize+1)
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.id, COALESCE(ir.reporting_entity_name, ''), COALESCE(ir.plan_name, ''), COALESCE(ir.plan_id, ''),
+			r.negotiated_type, r.negotiated_rate, `+effectiveRate+` AS effective_rate,
+			DATE_FORMAT(r.expiration_date, '%Y-%m-%d'), r.billing_class,
+			r.provider_references, r.service_codes, `+distance+`
+	`+rateFrom+`
+		WHERE `+where+`
+		ORDER BY effective_rate IS NULL, effective_rate, r.negotiated_rate, r.id
+		LIMIT ?
+	`, queryArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rates: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		rate, err := scanCodeRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Rates) == pageSize {
+			last := result.Rates[pageSize-1]
+			result.NextCursor = rateCursor{last.EffectiveRate, last.NegotiatedRate, last.ID}.String()
+			break
+		}
+		rate.MedicareAmount = medicare.Lookup(amounts, rate.BillingClass)
+		rate.PctOfMedicare = medicare.Pct(rate.EffectiveRate, rate.MedicareAmount)
+		result.Rates = append(result.Rates, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rates: %v", err)
+	}
+
+	return result, nil
+}
+
+// rateGroupStats computes rate statistics by billing class and negotiated type
+func (s *Store) rateGroupStats(ctx context.Context, where string, args []interface{}) ([]RateGroupStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.billing_class, r.negotiated_type, r.negotiated_rate
+	`+rateFrom+`
+		WHERE `+where+`
+		ORDER BY r.billing_class, r.negotiated_type
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rate distribution: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		groups []RateGroupStats
+		values []float64
+	)
+	flush := func() {
+		if len(values) > 0 {
+			groups[len(groups)-1].Stats = ComputeStats(values)
+			values = nil
+		}
+	}
+
+	for rows.Next() {
+		var billingClass, negotiatedType string
+		var rate float64
+		if err := rows.Scan(&billingClass, &negotiatedType, &rate); err != nil {
+			return nil, fmt.Errorf("failed to scan rate: %v", err)
+		}
+
+		if len(groups) == 0 || groups[len(groups)-1].BillingClass != billingClass || groups[len(groups)-1].NegotiatedType != negotiatedType {
+			flush()
+			groups = append(groups, RateGroupStats{BillingClass: billingClass, NegotiatedType: negotiatedType})
+		}
+		values = append(values, rate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rate distribution: %v", err)
+	}
+	flush()
+
+	if groups == nil {
+		groups = []RateGroupStats{}
+	}
+	return groups, nil
+}
+
+// aggregateGroupStats merges the precomputed statistics of the rates of a
+// billing code matching a filter by billing class and negotiated type. It
+// returns nil groups when the filter is not aggregated or the code has no
+// aggregates yet, and the time of the oldest aggregate merged.
+func (s *Store) aggregateGroupStats(ctx context.Context, codeType, code string, filter RateFilter) ([]RateGroupStats, *time.Time, error) {
+	if !filter.aggregated() {
+		return nil, nil, nil
+	}
+
+	query := `
+		SELECT billing_class, negotiated_type, rate_count, min_rate, max_rate, sum_rate, digest, refreshed_at
+		FROM rate_aggregates
+		WHERE billing_code_type = ? AND billing_code = ? AND state = ?`
+	args := []interface{}{codeType, code, filter.State}
+	if filter.Payer != "" {
+		query += " AND payer = ?"
+		args = append(args, filter.Payer)
+	}
+	rows, err := s.db.QueryContext(ctx, query+" ORDER BY billing_class, negotiated_type", args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query rate aggregates: %v", err)
+	}
+	defer rows.Close()
+
+	var (
+		groups      []RateGroupStats
+		sketch      *aggregate.Sketch
+		refreshedAt *time.Time
+	)
+	flush := func() {
+		if sketch != nil {
+			groups[len(groups)-1].Stats = sketchStats(sketch)
+		}
+	}
+
+	for rows.Next() {
+		var (
+			billingClass, negotiatedType string
+			count                        int64
+			min, max, sum                float64
+			digest                       []byte
+			refreshed                    time.Time
+		)
+		if err := rows.Scan(&billingClass, &negotiatedType, &count, &min, &max, &sum, &digest, &refreshed); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan rate aggregate: %v", err)
+		}
+		part, err := aggregate.ParseSketch(count, min, max, sum, digest)
+		if err != nil {
+			return nil, nil, err
+		}
+		if refreshedAt == nil || refreshed.Before(*refreshedAt) {
+			refreshedAt = &refreshed
+		}
+
+		if len(groups) == 0 || groups[len(groups)-1].BillingClass != billingClass || groups[len(groups)-1].NegotiatedType != negotiatedType {
+			flush()
+			groups = append(groups, RateGroupStats{BillingClass: billingClass, NegotiatedType: negotiatedType})
+			sketch = aggregate.NewSketch()
+		}
+		sketch.Merge(part)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read rate aggregates: %v", err)
+	}
+	flush()
+
+	return groups, refreshedAt, nil
+}
+
+// sketchStats summarizes a sketch like ComputeStats, with approximate percentiles
+func sketchStats(sketch *aggregate.Sketch) RateStats {
+	return RateStats{
+		Count:  int(sketch.Count),
+		Min:    round2(sketch.Min),
+		Max:    round2(sketch.Max),
+		Mean:   round2(sketch.Mean()),
+		Median: round2(sketch.Quantile(0.50)),
+		P10:    round2(sketch.Quantile(0.10)),
+		P25:    round2(sketch.Quantile(0.25)),
+		P75:    round2(sketch.Quantile(0.75)),
+		P90:    round2(sketch.Quantile(0.90)),
+	}
+}
+
+// rateCursor is the position of the last rate of a page in the order of
+// CodeRates
+type rateCursor struct {
+	effectiveRate  *float64
+	negotiatedRate float64
+	id             int64
+}
+
+// String encodes the cursor as "effective:negotiated:id", with an empty
+// effective rate for non-comparable rates
+func (c rateCursor) String() string {
+	effective := ""
+	if c.effectiveRate != nil {
+		effective = strconv.FormatFloat(*c.effectiveRate, 'f', -1, 64)
+	}
+	key := effective + ":" + strconv.FormatFloat(c.negotiatedRate, 'f', -1, 64) + ":" + strconv.FormatInt(c.id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// parseRateCursor decodes a cursor of rateCursor.String
+func parseRateCursor(value string) (rateCursor, error) {
+	var c rateCursor
+	key, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	parts := strings.Split(string(key), ":")
+	if len(parts) != 3 {
+		return c, ErrInvalidCursor
+	}
+	if parts[0] != "" {
+		effective, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return c, ErrInvalidCursor
+		}
+		c.effectiveRate = &effective
+	}
+	if c.negotiatedRate, err = strconv.ParseFloat(parts[1], 64); err != nil {
+		return c, ErrInvalidCursor
+	}
+	if c.id, err = strconv.ParseInt(parts[2], 10, 64); err != nil {
+		return c, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// args returns the cursor as the arguments of the keyset condition of
+// CodeRates
+func (c rateCursor) args() []interface{} {
+	if c.effectiveRate == nil {
+		return []interface{}{true, 0, c.negotiatedRate, c.id}
+	}
+	return []interface{}{false, *c.effectiveRate, c.negotiatedRate, c.id}
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanCodeRate scans the columns selected by CodeRates
+func scanCodeRate(row rowScanner) (CodeRate, error) {
+	var (
+		rate                               CodeRate
+		providerRefsJSON, serviceCodesJSON []byte
+	)
+	err := row.Scan(&rate.ID, &rate.PayerName, &rate.PlanName, &rate.PlanID,
+		&rate.NegotiatedType, &rate.NegotiatedRate, &rate.EffectiveRate, &rate.ExpirationDate, &rate.BillingClass,
+		&providerRefsJSON, &serviceCodesJSON, &rate.Distance)
+	if err != nil {
+		return rate, fmt.Errorf("failed to scan rate: %v", err)
+	}
+	if rate.Distance != nil {
+		*rate.Distance = roundDistance(*rate.Distance)
+	}
+	rate.Comparable = rate.EffectiveRate != nil
+
+	rate.PayerName = payerName(rate.PayerName)
+	if err := json.Unmarshal(providerRefsJSON, &rate.ProviderReferences); err != nil {
+		return rate, fmt.Errorf("failed to parse provider references of rate %d: %v", rate.ID, err)
+	}
+	if err := json.Unmarshal(serviceCodesJSON, &rate.ServiceCodes); err != nil {
+		return rate, fmt.Errorf("failed to parse service codes of rate %d: %v", rate.ID, err)
+	}
+
+	return rate, nil
+}
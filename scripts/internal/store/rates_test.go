@@ -0,0 +1,36 @@
+package store
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func TestRateCursor(t *testing.T) {
+	effective := 160.25
+
+	for _, tc := range []struct {
+		name   string
+		cursor rateCursor
+		args   []interface{}
+	}{
+		{"comparable rate", rateCursor{&effective, 64, 12}, []interface{}{false, 160.25, 64.0, int64(12)}},
+		{"non-comparable rates sort last", rateCursor{nil, 120, 7}, []interface{}{true, 0, 120.0, int64(7)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseRateCursor(tc.cursor.String())
+			if err != nil {
+				t.Fatalf("parseRateCursor: %v", err)
+			}
+			if !reflect.DeepEqual(got.args(), tc.args) {
+				t.Errorf("args = %v, want %v", got.args(), tc.args)
+			}
+		})
+	}
+
+	for _, value := range []string{"!", base64.RawURLEncoding.EncodeToString([]byte("1:2")), base64.RawURLEncoding.EncodeToString([]byte("x:2:3"))} {
+		if _, err := parseRateCursor(value); err != ErrInvalidCursor {
+			t.Errorf("parseRateCursor(%q) = %v, want ErrInvalidCursor", value, err)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Market is the geography of a market report; the zero value is national
+type Market struct {
+	State string     // two-letter state of the providers
+	Near  *GeoFilter // providers within a radius of a ZIP code
+}
+
+// String describes the market for report titles
+func (m Market) String() string {
+	var parts []string
+	if m.State != "" {
+		parts = append(parts, m.State)
+	}
+	if m.Near != nil {
+		parts = append(parts, fmt.Sprintf("within %g miles of %s", m.Near.RadiusMiles, m.Near.ZIP))
+	}
+	if len(parts) == 0 {
+		return "National"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ReportService is a service of a market report
+type ReportService struct {
+	Category        string
+	BillingCodeType string
+	BillingCode     string
+	Description     string
+}
+
+// MarketRow is the price dispersion and market concentration of one service
+// and billing class in a market. Dispersion covers the comparable current
+// rates (effective dollars); concentration covers contracts, the distinct
+// payer and provider NPI pairs with a current rate, since the files carry
+// no volumes.
+type MarketRow struct {
+	Category        string   `json:"category"`
+	BillingCodeType string   `json:"billingCodeType"`
+	BillingCode     string   `json:"billingCode"`
+	Description     string   `json:"description"`
+	BillingClass    string   `json:"billingClass"` // "" when the service has no rates in the market
+	RateCount       int      `json:"rateCount"`
+	PayerCount      int      `json:"payerCount"`
+	ProviderCount   int      `json:"providerCount"` // provider organizations (TINs) with contracts
+	ContractCount   int      `json:"contractCount"`
+	Min             float64  `json:"min"`
+	Median          float64  `json:"median"`
+	Max             float64  `json:"max"`
+	Mean            float64  `json:"mean"`
+	MaxMinRatio     *float64 `json:"maxMinRatio"` // highest over lowest rate, null when the lowest is 0
+	CV              *float64 `json:"cv"`          // coefficient of variation (population), null without rates
+	PayerHHI        *float64 `json:"payerHhi"`    // Herfindahl-Hirschman index of payer contract shares, 0-10000
+	ProviderHHI     *float64 `json:"providerHhi"` // HHI of provider organization contract shares
+}
+
+// MarketReport computes one row per service and billing class in a market,
+// in the order of services. Services without rates in the market get an
+// empty row, so the report shows the gaps.
+func (s *Store) MarketReport(ctx context.Context, services []ReportService, market Market) ([]MarketRow, error) {
+	area, err := s.nearby(ctx, market.Near)
+	if err != nil {
+		return nil, err
+	}
+
+	var report []MarketRow
+	for _, service := range services {
+		rows, err := s.marketRows(ctx, service, market, area)
+		if err != nil {
+			return nil, fmt.Errorf("failed to report %s %s: %v", service.BillingCodeType, service.BillingCode, err)
+		}
+		report = append(report, rows...)
+	}
+	return report, nil
+}
+
+// marketRows computes the rows of one service
+func (s *Store) marketRows(ctx context.Context, service ReportService, market Market, area *nearbyArea) ([]MarketRow, error) {
+	where, args := rateConditions(service.BillingCodeType, service.BillingCode, RateFilter{State: market.State}, area)
+	effective := effectiveRateSQL("s", "r")
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.billing_class, COALESCE(ir.reporting_entity_name, ''), `+effective+`
+	`+rateFrom+`
+		WHERE `+where+` AND `+effective+` IS NOT NULL
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rates: %v", err)
+	}
+	defer rows.Close()
+
+	values := make(map[string][]float64)
+	payers := make(map[string]map[string]bool)
+	for rows.Next() {
+		var billingClass, payer string
+		var rate float64
+		if err := rows.Scan(&billingClass, &payer, &rate); err != nil {
+			return nil, fmt.Errorf("failed to scan rate: %v", err)
+		}
+		values[billingClass] = append(values[billingClass], rate)
+		if payers[billingClass] == nil {
+			payers[billingClass] = make(map[string]bool)
+		}
+		payers[billingClass][payer] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rates: %v", err)
+	}
+
+	contracts, err := s.marketContracts(ctx, service, market, area)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make([]string, 0, len(values))
+	for billingClass := range values {
+		classes = append(classes, billingClass)
+	}
+	for billingClass := range contracts {
+		if _, ok := values[billingClass]; !ok {
+			classes = append(classes, billingClass)
+		}
+	}
+	sort.Strings(classes)
+
+	newRow := func(billingClass string) MarketRow {
+		return MarketRow{
+			Category:        service.Category,
+			BillingCodeType: service.BillingCodeType,
+			BillingCode:     service.BillingCode,
+			Description:     service.Description,
+			BillingClass:    billingClass,
+		}
+	}
+	if len(classes) == 0 {
+		return []MarketRow{newRow("")}, nil
+	}
+
+	report := make([]MarketRow, 0, len(classes))
+	for _, billingClass := range classes {
+		row := newRow(billingClass)
+		if classValues := values[billingClass]; len(classValues) > 0 {
+			stats := ComputeStats(classValues)
+			row.RateCount = stats.Count
+			row.PayerCount = len(payers[billingClass])
+			row.Min, row.Median, row.Max, row.Mean = stats.Min, stats.Median, stats.Max, stats.Mean
+			if stats.Min > 0 {
+				ratio := round2(stats.Max / stats.Min)
+				row.MaxMinRatio = &ratio
+			}
+			if cv := coefficientOfVariation(classValues); !math.IsNaN(cv) {
+				row.CV = &cv
+			}
+		}
+
+		if c := contracts[billingClass]; c != nil && c.total > 0 {
+			row.ContractCount = c.total
+			row.ProviderCount = len(c.byProvider)
+			payerHHI, providerHHI := hhi(c.byPayer, c.total), hhi(c.byProvider, c.total)
+			row.PayerHHI, row.ProviderHHI = &payerHHI, &providerHHI
+		}
+		report = append(report, row)
+	}
+	return report, nil
+}
+
+// contractShares counts the contracts of a billing class by payer and by
+// provider organization
+type contractShares struct {
+	total      int
+	byPayer    map[string]int
+	byProvider map[string]int
+}
+
+// marketContracts counts the distinct payer and NPI pairs with a current rate
+// of a service by billing class, for the NPIs practicing in the market. An
+// NPI billing under several TINs counts once per TIN.
+func (s *Store) marketContracts(ctx context.Context, service ReportService, market Market, area *nearbyArea) (map[string]*contractShares, error) {
+	conditions := []string{"s.billing_code_type = ?", "s.billing_code = ?", "r.valid_to IS NULL"}
+	args := []interface{}{service.BillingCodeType, service.BillingCode}
+	join := ""
+	if market.State != "" || area != nil {
+		join = "JOIN providers p ON p.npi = pn.npi"
+	}
+	if market.State != "" {
+		conditions = append(conditions, "p.address_state = ?")
+		args = append(args, market.State)
+	}
+	if area != nil {
+		conditions = append(conditions, fmt.Sprintf("p.address_zip5 IN (%s)", placeholders(len(area.zips), "?")))
+		args = append(args, area.zipArgs()...)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.billing_class, COALESCE(ir.reporting_entity_name, ''), pn.tin_value, COUNT(DISTINCT pn.npi)
+	`+rateFrom+`
+		JOIN negotiated_rate_provider_groups pg ON pg.negotiated_rate_id = r.id
+		JOIN provider_group_npis pn ON pn.provider_group_id = pg.provider_group_id AND pn.run_id = r.last_seen_run_id
+		`+join+`
+		WHERE `+strings.Join(conditions, " AND ")+`
+		GROUP BY 1, 2, 3
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query contracts: %v", err)
+	}
+	defer rows.Close()
+
+	contracts := make(map[string]*contractShares)
+	for rows.Next() {
+		var billingClass, payer, tin string
+		var count int
+		if err := rows.Scan(&billingClass, &payer, &tin, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan contracts: %v", err)
+		}
+		c := contracts[billingClass]
+		if c == nil {
+			c = &contractShares{byPayer: make(map[string]int), byProvider: make(map[string]int)}
+			contracts[billingClass] = c
+		}
+		c.total += count
+		c.byPayer[payer] += count
+		c.byProvider[tin] += count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read contracts: %v", err)
+	}
+	return contracts, nil
+}
+
+// coefficientOfVariation returns the population standard deviation of values
+// over their mean, to three decimals, or NaN when the mean is 0
+func coefficientOfVariation(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if mean == 0 {
+		return math.NaN()
+	}
+
+	squares := 0.0
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return math.Round(math.Sqrt(squares/float64(len(values)))/mean*1000) / 1000
+}
+
+// hhi returns the Herfindahl-Hirschman index of shares given as counts out
+// of total: the sum of squared percentage shares, from near 0 (fragmented)
+// to 10000 (one participant)
+func hhi(counts map[string]int, total int) float64 {
+	index := 0.0
+	for _, count := range counts {
+		share := float64(count) / float64(total) * 100
+		index += share * share
+	}
+	return math.Round(index)
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCoefficientOfVariation(t *testing.T) {
+	for _, tc := range []struct {
+		values []float64
+		want   float64
+	}{
+		{[]float64{100, 100, 100}, 0},
+		{[]float64{50, 150}, 0.5},
+		{[]float64{1, 2, 3, 4}, 0.447},
+		{[]float64{1000}, 0},
+	} {
+		if got := coefficientOfVariation(tc.values); got != tc.want {
+			t.Errorf("coefficientOfVariation(%v) = %v, want %v", tc.values, got, tc.want)
+		}
+	}
+
+	for _, values := range [][]float64{{0, 0}, {-50, 50}} {
+		if got := coefficientOfVariation(values); !math.IsNaN(got) {
+			t.Errorf("coefficientOfVariation(%v) = %v, want NaN for a zero mean", values, got)
+		}
+	}
+}
+
+func TestHHI(t *testing.T) {
+	for _, tc := range []struct {
+		counts map[string]int
+		want   float64
+	}{
+		{map[string]int{"Aetna": 40}, 10000},
+		{map[string]int{"Aetna": 20, "Cigna": 20}, 5000},
+		{map[string]int{"Aetna": 30, "Cigna": 10}, 6250},
+		{map[string]int{"Aetna": 1, "Cigna": 1, "UnitedHealthcare": 1}, 3333},
+		{map[string]int{"a": 1, "b": 1, "c": 1, "d": 1, "e": 1, "f": 1, "g": 1, "h": 1, "i": 1, "j": 1}, 1000},
+	} {
+		total := 0
+		for _, count := range tc.counts {
+			total += count
+		}
+		if got := hhi(tc.counts, total); got != tc.want {
+			t.Errorf("hhi(%v, %d) = %v, want %v", tc.counts, total, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SearchParams filters and pages a price search
+type SearchParams struct {
+	Query    string
+	Sort     string // relevance, price, -price, name, -name or code
+	Page     int    // 1-based
+	PageSize int
+	Near     *GeoFilter // providers within a radius of a ZIP code
+}
+
+// searchSorts maps the sort parameter to an ORDER BY clause over the grouped
+// search rows; every clause ends with the group key so pages are stable
+var searchSorts = map[string]string{
+	"relevance": "relevance DESC, min_rate ASC, s.billing_code, pg.provider_group_id, r.last_seen_run_id",
+	"price":     "min_rate ASC, s.billing_code, pg.provider_group_id, r.last_seen_run_id",
+	"-price":    "min_rate DESC, s.billing_code, pg.provider_group_id, r.last_seen_run_id",
+	"name":      "name ASC, s.billing_code, pg.provider_group_id, r.last_seen_run_id",
+	"-name":     "name DESC, s.billing_code, pg.provider_group_id, r.last_seen_run_id",
+	"code":      "s.billing_code_type, s.billing_code, pg.provider_group_id, r.last_seen_run_id",
+	// distance is only valid with a near filter
+	"distance": "distance ASC, min_rate ASC, s.billing_code, pg.provider_group_id, r.last_seen_run_id",
+}
+
+// DefaultSearchSort is used when no sort is given
+const DefaultSearchSort = "relevance"
+
+// ValidSearchSort reports whether sort is a supported search sort
+func ValidSearchSort(sort string) bool {
+	_, ok := searchSorts[sort]
+	return ok
+}
+
+// PayerRate is the negotiated rate of one payer
+type PayerRate struct {
+	PayerName string  `json:"payerName"`
+	Rate      float64 `json:"rate"`
+}
+
+// SearchResult is one service at one provider group with its rates. Provider
+// group ids are only unique within a file, so a result is the group of one
+// run, the one whose roster resolves it. It matches the SearchResult
+// interface of the home page.
+type SearchResult struct {
+	ID              string      `json:"id"`
+	ServiceName     string      `json:"serviceName"`
+	Description     string      `json:"description"`
+	CashPrice       *float64    `json:"cashPrice"`
+	NegotiatedRates []PayerRate `json:"negotiatedRates"`
+	Hospital        string      `json:"hospital"`
+	Location        string      `json:"location"`
+
+	BillingCodeType string   `json:"billingCodeType"`
+	BillingCode     string   `json:"billingCode"`
+	ProviderGroupID int      `json:"providerGroupId"`
+	RunID           int64    `json:"runId"` // the run of the provider group
+	Relevance       float64  `json:"relevance"`
+	Distance        *float64 `json:"distance,omitempty"` // miles to the nearest provider, with a near filter
+}
+
+// SearchPage is one page of search results. CorrectedQuery is set when words
+// of the query were spell-corrected; results match either spelling.
+type SearchPage struct {
+	Results        []SearchResult `json:"results"`
+	Total          int            `json:"total"`
+	Page           int            `json:"page"`
+	PageSize       int            `json:"pageSize"`
+	CorrectedQuery string         `json:"correctedQuery,omitempty"`
+}
+
+// searchKey identifies a search result
+type searchKey struct {
+	codeType        string
+	code            string
+	providerGroupID int
+	runID           int64
+}
+
+// Relevance weights of the ways a service can match a query
+const (
+	codeMatchScore      = 1000.0 // the query is the billing code
+	termMatchWeight     = 2.0    // lay terms are curated, so they outweigh names
+	substringMatchScore = 0.5    // fallback for words below the full-text token size
+)
+
+// minTokenSize is InnoDB's default innodb_ft_min_token_size; shorter words are
+// not in the full-text index and are matched as substrings instead
+const minTokenSize = 3
+
+// Search finds services by billing code, name, description or lay term and
+// returns one result per service and provider group, scored by full-text
+// relevance. Misspelled words are corrected against the vocabulary loaded by
+// Reload. Only rates of the current period with a dollar amount are used,
+// percentage rates through the reference fee schedule; each payer's rate is
+// its lowest one.
+// Cash prices are not part of payer files, so CashPrice is always nil.
+func (s *Store) Search(ctx context.Context, params SearchParams) (*SearchPage, error) {
+	orderBy, ok := searchSorts[params.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sort %q", params.Sort)
+	}
+
+	area, err := s.nearby(ctx, params.Near)
+	if err != nil {
+		return nil, err
+	}
+
+	text, corrected := s.correctQuery(params.Query)
+
+	// Match against the base tables so the full-text indexes are used; the
+	// join to the active views below keeps only visible services
+	matches := fmt.Sprintf(`
+		SELECT id AS service_id, %g AS score FROM insurance_services WHERE billing_code = ?
+		UNION ALL
+		SELECT id, MATCH(name, description) AGAINST (? IN NATURAL LANGUAGE MODE)
+		FROM insurance_services
+		WHERE MATCH(name, description) AGAINST (? IN NATURAL LANGUAGE MODE)
+		UNION ALL
+		SELECT fs.id, %g * MATCH(t.term) AGAINST (? IN NATURAL LANGUAGE MODE)
+		FROM service_terms t
+		JOIN insurance_services fs
+			ON fs.billing_code_type = t.billing_code_type AND fs.billing_code = t.billing_code
+		WHERE MATCH(t.term) AGAINST (? IN NATURAL LANGUAGE MODE)`, codeMatchScore, termMatchWeight)
+	args := []interface{}{params.Query, text, text, text, text}
+
+	if hasShortWord(params.Query) {
+		like := "%" + escapeLike(params.Query) + "%"
+		matches += fmt.Sprintf(`
+		UNION ALL
+		SELECT id, %g FROM insurance_services WHERE name LIKE ? OR description LIKE ?`, substringMatchScore)
+		args = append(args, like, like)
+	}
+	// Within a radius, only provider groups with a provider in range match,
+	// and each result shows its nearest provider
+	nearbyCTE, nearbyJoin := "", ""
+	nearest := "NULL AS hospital, NULL AS location, NULL AS distance"
+	if area != nil {
+		cte, cteArgs := nearbyGroupsCTE(area)
+		nearbyCTE, nearbyJoin = ", "+cte, nearbyGroupsJoin
+		nearest = `SUBSTRING_INDEX(GROUP_CONCAT(ng.name ORDER BY ng.distance SEPARATOR '\n'), '\n', 1) AS hospital,
+			SUBSTRING_INDEX(GROUP_CONCAT(ng.location ORDER BY ng.distance SEPARATOR '\n'), '\n', 1) AS location,
+			MIN(ng.distance) AS distance`
+		args = append(args, cteArgs...)
+	}
+	args = append(args, params.PageSize, (params.Page-1)*params.PageSize)
+
+	effective := effectiveRateSQL("s", "r")
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		WITH matches AS (%s
+		), scored AS (
+			SELECT service_id, SUM(score) AS score FROM matches GROUP BY service_id
+		)%s
+		SELECT s.billing_code_type, s.billing_code, pg.provider_group_id, r.last_seen_run_id,
+			MIN(s.name) AS name, MIN(s.description), MIN(%s) AS min_rate,
+			MAX(m.score) AS relevance, %s, COUNT(*) OVER () AS total
+		FROM scored m
+		JOIN active_insurance_services s ON s.id = m.service_id
+		JOIN active_negotiated_rates r ON r.service_id = s.id
+		JOIN negotiated_rate_provider_groups pg ON pg.negotiated_rate_id = r.id%s
+		WHERE %s IS NOT NULL AND r.valid_to IS NULL
+		GROUP BY s.billing_code_type, s.billing_code, pg.provider_group_id, r.last_seen_run_id
+		ORDER BY %s
+		LIMIT ? OFFSET ?
+	`, matches, nearbyCTE, effective, nearest, nearbyJoin, effective, orderBy), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search services: %v", err)
+	}
+	defer rows.Close()
+
+	page := &SearchPage{Results: []SearchResult{}, Page: params.Page, PageSize: params.PageSize, CorrectedQuery: corrected}
+	index := make(map[searchKey]int)
+
+	for rows.Next() {
+		var (
+			result      SearchResult
+			description *string
+			minRate     float64
+			hospital    *string
+			location    *string
+		)
+		err := rows.Scan(&result.BillingCodeType, &result.BillingCode, &result.ProviderGroupID, &result.RunID,
+			&result.ServiceName, &description, &minRate, &result.Relevance,
+			&hospital, &location, &result.Distance, &page.Total)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %v", err)
+		}
+		if description != nil {
+			result.Description = *description
+		}
+		result.ID = fmt.Sprintf("%s-%s-%d-%d", result.BillingCodeType, result.BillingCode, result.ProviderGroupID, result.RunID)
+		result.NegotiatedRates = []PayerRate{}
+		result.Hospital = providerGroupName(result.ProviderGroupID)
+		if hospital != nil {
+			result.Hospital = *hospital
+		}
+		if location != nil {
+			result.Location = *location
+		}
+		if result.Distance != nil {
+			*result.Distance = roundDistance(*result.Distance)
+		}
+
+		index[searchKey{result.BillingCodeType, result.BillingCode, result.ProviderGroupID, result.RunID}] = len(page.Results)
+		page.Results = append(page.Results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search results: %v", err)
+	}
+
+	if len(page.Results) == 0 {
+		return page, nil
+	}
+
+	if err := s.loadPayerRates(ctx, page.Results, index, area); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// loadPayerRates fills the per-payer rates of a page of search results. With
+// a radius filter only the rates of provider groups in range are used.
+func (s *Store) loadPayerRates(ctx context.Context, results []SearchResult, index map[searchKey]int, area *nearbyArea) error {
+	var args []interface{}
+	nearbyCTE, nearbyJoin := "", ""
+	if area != nil {
+		cte, cteArgs := nearbyGroupsCTE(area)
+		nearbyCTE, nearbyJoin = "WITH "+cte, nearbyGroupsJoin
+		args = append(args, cteArgs...)
+	}
+	for _, result := range results {
+		args = append(args, result.BillingCodeType, result.BillingCode, result.ProviderGroupID, result.RunID)
+	}
+
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
+		%[1]s
+		SELECT s.billing_code_type, s.billing_code, pg.provider_group_id, r.last_seen_run_id,
+			COALESCE(ir.reporting_entity_name, '') AS payer, MIN(%[4]s) AS rate
+		FROM active_insurance_services s
+		JOIN active_negotiated_rates r ON r.service_id = s.id
+		JOIN negotiated_rate_provider_groups pg ON pg.negotiated_rate_id = r.id%[2]s
+		LEFT JOIN ingestion_runs ir ON ir.id = r.run_id
+		WHERE (s.billing_code_type, s.billing_code, pg.provider_group_id, r.last_seen_run_id) IN (%[3]s)
+			AND %[4]s IS NOT NULL AND r.valid_to IS NULL
+		GROUP BY s.billing_code_type, s.billing_code, pg.provider_group_id, r.last_seen_run_id, payer
+		ORDER BY rate
+	`, nearbyCTE, nearbyJoin, placeholders(len(results), "(?, ?, ?, ?)"), effectiveRateSQL("s", "r")), args...)
+	if err != nil {
+		return fmt.Errorf("failed to load payer rates: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			key   searchKey
+			payer string
+			rate  float64
+		)
+		if err := rows.Scan(&key.codeType, &key.code, &key.providerGroupID, &key.runID, &payer, &rate); err != nil {
+			return fmt.Errorf("failed to scan payer rate: %v", err)
+		}
+		if i, ok := index[key]; ok {
+			results[i].NegotiatedRates = append(results[i].NegotiatedRates, PayerRate{PayerName: payerName(payer), Rate: rate})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read payer rates: %v", err)
+	}
+
+	return nil
+}
+
+// nearbyGroupsCTE returns the nearby_groups common table expression: the
+// providers in range with their run-scoped provider groups and distances
+func nearbyGroupsCTE(area *nearbyArea) (string, []interface{}) {
+	distance, args := area.distanceSQL()
+	return fmt.Sprintf(`nearby_groups AS (
+			SELECT pn.run_id, pn.provider_group_id, p.name,
+				CONCAT_WS(', ', p.address_city, p.address_state) AS location, %s AS distance
+			FROM providers p
+			JOIN zip_centroids z ON z.zip = p.address_zip5
+			JOIN provider_group_npis pn ON pn.npi = p.npi
+			WHERE p.address_zip5 IN (%s)
+		)`, distance, placeholders(len(area.zips), "?")), append(args, area.zipArgs()...)
+}
+
+// nearbyGroupsJoin restricts the rates r and provider groups pg of a query to
+// the nearby_groups in range
+const nearbyGroupsJoin = `
+		JOIN nearby_groups ng ON ng.run_id = r.last_seen_run_id AND ng.provider_group_id = pg.provider_group_id`
+
+// correctQuery spell-corrects the words of query. It returns the full-text
+// search text, which holds the original words followed by their corrections,
+// and the corrected query, which is empty when nothing was corrected.
+func (s *Store) correctQuery(query string) (text, corrected string) {
+	vocab := s.vocab.Load()
+	if vocab == nil {
+		return query, ""
+	}
+
+	words := searchWords(query)
+	extra := make([]string, 0, len(words))
+	changed := false
+	for i, word := range words {
+		if c := vocab.correct(word); c != word {
+			words[i] = c
+			extra = append(extra, c)
+			changed = true
+		}
+	}
+	if !changed {
+		return query, ""
+	}
+	return query + " " + strings.Join(extra, " "), strings.Join(words, " ")
+}
+
+// hasShortWord reports whether query has a word too short for the full-text
+// index, e.g. "ct" or "er"
+func hasShortWord(query string) bool {
+	for _, word := range searchWords(query) {
+		if len([]rune(word)) < minTokenSize {
+			return true
+		}
+	}
+	return false
+}
+
+// escapeLike escapes the LIKE wildcards in a user supplied string
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"math"
+	"sort"
+)
+
+// RateStats summarizes a distribution of rates
+type RateStats struct {
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P10    float64 `json:"p10"`
+	P25    float64 `json:"p25"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+}
+
+// ComputeStats summarizes values; the slice is sorted in place
+func ComputeStats(values []float64) RateStats {
+	if len(values) == 0 {
+		return RateStats{}
+	}
+
+	sort.Float64s(values)
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+
+	return RateStats{
+		Count:  len(values),
+		Min:    values[0],
+		Max:    values[len(values)-1],
+		Mean:   round2(sum / float64(len(values))),
+		Median: round2(Percentile(values, 50)),
+		P10:    round2(Percentile(values, 10)),
+		P25:    round2(Percentile(values, 25)),
+		P75:    round2(Percentile(values, 75)),
+		P90:    round2(Percentile(values, 90)),
+	}
+}
+
+// Percentile returns the p-th percentile (0-100) of sorted values using
+// linear interpolation between the closest ranks
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// round2 rounds to cents
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// percentOf expresses dollar statistics as percents of amount, to one decimal
+func (st RateStats) percentOf(amount float64) RateStats {
+	pct := func(v float64) float64 { return math.Round(v/amount*1000) / 10 }
+	return RateStats{
+		Count:  st.Count,
+		Min:    pct(st.Min),
+		Max:    pct(st.Max),
+		Mean:   pct(st.Mean),
+		Median: pct(st.Median),
+		P10:    pct(st.P10),
+		P25:    pct(st.P25),
+		P75:    pct(st.P75),
+		P90:    pct(st.P90),
+	}
+}
@@ -0,0 +1,80 @@
+// Package store is the read-side repository over the tables written by the
+// ingestion tool. It only reads through the active_* views, so staged runs
+// are never visible before they are activated.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// Store runs read queries against the ingested data
+type Store struct {
+	db *sql.DB
+
+	// The in-memory indexes are swapped in whole by Reload and nil until
+	// the first one
+	vocab   atomic.Pointer[vocabulary]
+	suggest atomic.Pointer[prefixIndex]
+}
+
+// New creates a store on an open database
+func New(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Ping checks that the database is reachable
+func (s *Store) Ping() error {
+	return s.db.Ping()
+}
+
+// Reload rebuilds the in-memory search indexes from the active data. It is
+// called at startup and periodically, so newly activated runs are picked up.
+func (s *Store) Reload(ctx context.Context) error {
+	vocab, err := s.loadVocabulary(ctx)
+	if err != nil {
+		return err
+	}
+	suggest, err := s.loadPrefixIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.vocab.Store(vocab)
+	s.suggest.Store(suggest)
+	return nil
+}
+
+// Close closes the database connection
+func (s *Store) Close() error {
+	if s.db != nil {
+		return s.db.Close()
+	}
+	return nil
+}
+
+// placeholders returns n comma-separated placeholders of the given form,
+// e.g. placeholders(2, "(?, ?)") = "(?, ?), (?, ?)"
+func placeholders(n int, form string) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = form
+	}
+	return strings.Join(parts, ", ")
+}
+
+// payerName returns the display name of a reporting entity
+func payerName(reportingEntity string) string {
+	if reportingEntity == "" {
+		return "Unknown payer"
+	}
+	return reportingEntity
+}
+
+// providerGroupName returns the display name of an unresolved provider group
+func providerGroupName(providerGroupID int) string {
+	return fmt.Sprintf("Provider group %d", providerGroupID)
+}
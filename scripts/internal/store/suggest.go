@@ -0,0 +1,172 @@
+package store
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// MaxSuggestions is the most suggestions one lookup returns
+const MaxSuggestions = 20
+
+// scanThreshold is the largest key range a lookup scans; prefixes matching
+// more keys are answered from their precomputed top suggestions
+const scanThreshold = 512
+
+// Suggestion is one typeahead entry: a service name with its billing code
+type Suggestion struct {
+	Text            string `json:"text"`
+	BillingCodeType string `json:"billingCodeType"`
+	BillingCode     string `json:"billingCode"`
+	RateCount       int    `json:"rateCount"`
+}
+
+// prefixKey is one searchable key of a suggestion: its billing code, its name,
+// or its name from the start of a later word ("brain w/o dye" for
+// "MRI BRAIN W/O DYE")
+type prefixKey struct {
+	key  string
+	item int32
+}
+
+// prefixIndex answers prefix lookups over the sorted keys of all suggestions.
+// Lookups binary-search the key range of the prefix; ranges too large to
+// scan within the latency budget have their best suggestions precomputed.
+type prefixIndex struct {
+	items []Suggestion // sorted by rank, so a lower index is a better item
+	keys  []prefixKey  // sorted by key
+	top   map[string][]int32
+}
+
+// newPrefixIndex indexes items, which must be sorted by rank
+func newPrefixIndex(items []Suggestion) *prefixIndex {
+	idx := &prefixIndex{items: items, top: make(map[string][]int32)}
+
+	for i, item := range items {
+		idx.keys = append(idx.keys, prefixKey{strings.ToLower(item.BillingCode), int32(i)})
+
+		name := strings.Join(strings.Fields(strings.ToLower(item.Text)), " ")
+		idx.keys = append(idx.keys, prefixKey{name, int32(i)})
+		for j := 1; j < len(name); j++ {
+			if name[j-1] == ' ' && name[j] != ' ' {
+				idx.keys = append(idx.keys, prefixKey{name[j:], int32(i)})
+			}
+		}
+	}
+	slices.SortFunc(idx.keys, func(a, b prefixKey) int {
+		if c := strings.Compare(a.key, b.key); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.item, b.item)
+	})
+
+	idx.precompute(0, len(idx.keys), 1)
+	return idx
+}
+
+// precompute stores the top suggestions of every prefix of the given length
+// whose range within keys[lo:hi] is too large to scan, then recurses into
+// the longer prefixes of those ranges
+func (idx *prefixIndex) precompute(lo, hi, length int) {
+	for start := lo; start < hi; {
+		if len(idx.keys[start].key) < length {
+			start++
+			continue
+		}
+		prefix := idx.keys[start].key[:length]
+		end := start + sort.Search(hi-start, func(i int) bool {
+			return !strings.HasPrefix(idx.keys[start+i].key, prefix)
+		})
+		if end-start > scanThreshold {
+			idx.top[prefix] = idx.best(start, end, MaxSuggestions)
+			idx.precompute(start, end, length+1)
+		}
+		start = end
+	}
+}
+
+// best returns the best distinct items of keys[lo:hi]. Since items are sorted
+// by rank, these are the smallest item numbers, kept in a bounded sorted list.
+func (idx *prefixIndex) best(lo, hi, limit int) []int32 {
+	items := make([]int32, 0, limit)
+	for _, k := range idx.keys[lo:hi] {
+		if len(items) == limit && k.item >= items[limit-1] {
+			continue
+		}
+		i, found := slices.BinarySearch(items, k.item)
+		if found {
+			continue
+		}
+		if len(items) == limit {
+			items = items[:limit-1]
+		}
+		items = slices.Insert(items, i, k.item)
+	}
+	return items
+}
+
+// lookup returns up to limit suggestions whose code, name or a word of the
+// name starts with prefix
+func (idx *prefixIndex) lookup(prefix string, limit int) []Suggestion {
+	prefix = strings.ToLower(prefix)
+
+	var items []int32
+	if top, ok := idx.top[prefix]; ok {
+		items = top
+	} else {
+		lo := sort.Search(len(idx.keys), func(i int) bool { return idx.keys[i].key >= prefix })
+		hi := lo + sort.Search(len(idx.keys)-lo, func(i int) bool {
+			return !strings.HasPrefix(idx.keys[lo+i].key, prefix)
+		})
+		items = idx.best(lo, hi, limit)
+	}
+
+	suggestions := make([]Suggestion, 0, min(limit, len(items)))
+	for _, item := range items[:min(limit, len(items))] {
+		suggestions = append(suggestions, idx.items[item])
+	}
+	return suggestions
+}
+
+// Suggest returns up to limit typeahead suggestions for prefix, most rated
+// first. It is served from memory and returns nothing until the first Reload.
+func (s *Store) Suggest(prefix string, limit int) []Suggestion {
+	idx := s.suggest.Load()
+	if idx == nil {
+		return []Suggestion{}
+	}
+	return idx.lookup(strings.Join(strings.Fields(prefix), " "), min(limit, MaxSuggestions))
+}
+
+// loadPrefixIndex builds the suggestion index from the distinct names and
+// billing codes of the active services, ranked by their current rate count
+func (s *Store) loadPrefixIndex(ctx context.Context) (*prefixIndex, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.billing_code_type, s.billing_code, s.name, COUNT(r.id) AS rate_count
+		FROM active_insurance_services s
+		LEFT JOIN active_negotiated_rates r ON r.service_id = s.id AND r.valid_to IS NULL
+		GROUP BY s.billing_code_type, s.billing_code, s.name
+		ORDER BY rate_count DESC, CHAR_LENGTH(s.name), s.name, s.billing_code
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load suggestions: %v", err)
+	}
+	defer rows.Close()
+
+	var items []Suggestion
+	for rows.Next() {
+		var item Suggestion
+		if err := rows.Scan(&item.BillingCodeType, &item.BillingCode, &item.Text, &item.RateCount); err != nil {
+			return nil, fmt.Errorf("failed to scan suggestion: %v", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read suggestions: %v", err)
+	}
+
+	return newPrefixIndex(items), nil
+}
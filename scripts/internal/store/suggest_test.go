@@ -0,0 +1,157 @@
+package store
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// suggestWords make up the names of the benchmark vocabulary, in the style of
+// the service names of payer files
+var suggestWords = strings.Fields(`
+	mri ct xray ultrasound brain knee hip shoulder spine lumbar cervical thoracic
+	chest abdomen pelvis with without contrast dye office outpatient inpatient
+	visit new established patient level evaluation management injection
+	therapy physical occupational speech blood test panel metabolic complete
+	count lipid thyroid hemoglobin urinalysis culture biopsy excision repair
+	removal replacement arthroscopy arthroplasty total partial colonoscopy
+	endoscopy upper lower screening diagnostic anesthesia emergency department
+	critical care hospital observation nursing facility home health ambulance
+	transport ground air vaccine immunization administration drug infusion
+	hydration chemotherapy radiation treatment delivery planning sleep study
+	echocardiogram electrocardiogram stress cardiac catheterization stent
+	angioplasty bypass graft valve pacemaker dialysis kidney liver gallbladder
+	appendix hernia cataract lens eye ear nose throat tonsil dental crown
+	filling cleaning extraction root canal delivery vaginal cesarean newborn
+	prenatal postpartum mammogram bone density fracture cast splint wound
+	debridement skin lesion mohs surgery laparoscopic open initial subsequent`)
+
+// benchmarkSuggestions returns a vocabulary of n services sorted by rank:
+// names of two to seven words drawn with a skew towards common words, and
+// CPT-like five digit codes
+func benchmarkSuggestions(n int) []Suggestion {
+	r := rand.New(rand.NewSource(1))
+	items := make([]Suggestion, n)
+	for i := range items {
+		words := make([]string, 2+r.Intn(6))
+		for j := range words {
+			// The square skews draws towards the start of the list
+			f := r.Float64()
+			words[j] = suggestWords[int(f*f*float64(len(suggestWords)))]
+		}
+		items[i] = Suggestion{
+			Text:            strings.ToUpper(strings.Join(words, " ")),
+			BillingCodeType: "CPT",
+			BillingCode:     fmt.Sprintf("%05d", r.Intn(100000)),
+			RateCount:       n - i,
+		}
+	}
+	return items
+}
+
+// benchmarkPrefixes returns the prefixes users type: the first one to
+// twelve characters of names, words within names and codes
+func benchmarkPrefixes(items []Suggestion, n int) []string {
+	r := rand.New(rand.NewSource(2))
+	prefixes := make([]string, n)
+	for i := range prefixes {
+		item := items[r.Intn(len(items))]
+		text := strings.ToLower(item.Text)
+		switch r.Intn(3) {
+		case 0:
+			text = item.BillingCode
+		case 1:
+			words := strings.Fields(text)
+			text = strings.Join(words[r.Intn(len(words)):], " ")
+		}
+		prefixes[i] = text[:1+r.Intn(min(12, len(text)))]
+	}
+	return prefixes
+}
+
+func TestPrefixIndexLookup(t *testing.T) {
+	idx := newPrefixIndex([]Suggestion{
+		{Text: "MRI BRAIN WITHOUT DYE", BillingCode: "70551", RateCount: 30},
+		{Text: "CT HEAD  WITHOUT DYE", BillingCode: "70450", RateCount: 20},
+		{Text: "MRI BRAIN WITH DYE", BillingCode: "70552", RateCount: 10},
+	})
+
+	for _, tc := range []struct {
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"mri br", 10, []string{"70551", "70552"}},
+		{"MRI BRAIN WITH", 10, []string{"70551", "70552"}},
+		{"without", 10, []string{"70551", "70450"}},
+		{"head without", 10, []string{"70450"}},
+		{"7055", 1, []string{"70551"}},
+		{"dye", 2, []string{"70551", "70450"}},
+		{"rain", 10, nil},
+	} {
+		var got []string
+		for _, s := range idx.lookup(tc.prefix, tc.limit) {
+			got = append(got, s.BillingCode)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("lookup(%q, %d) = %v, want %v", tc.prefix, tc.limit, got, tc.want)
+		}
+	}
+}
+
+// TestPrefixIndexBound checks what keeps lookups fast: every prefix either has
+// precomputed suggestions, which match a scan, or a key range small enough to
+// scan
+func TestPrefixIndexBound(t *testing.T) {
+	items := benchmarkSuggestions(20000)
+	idx := newPrefixIndex(items)
+
+	for _, prefix := range benchmarkPrefixes(items, 5000) {
+		lo, _ := slices.BinarySearchFunc(idx.keys, prefix, func(k prefixKey, p string) int {
+			return strings.Compare(k.key, p)
+		})
+		hi := lo
+		for hi < len(idx.keys) && strings.HasPrefix(idx.keys[hi].key, prefix) {
+			hi++
+		}
+		top, ok := idx.top[prefix]
+		if !ok {
+			if hi-lo > scanThreshold {
+				t.Fatalf("prefix %q scans %d keys without precomputed suggestions", prefix, hi-lo)
+			}
+			continue
+		}
+		if want := idx.best(lo, hi, MaxSuggestions); !slices.Equal(top, want) {
+			t.Fatalf("precomputed suggestions of %q = %v, a scan finds %v", prefix, top, want)
+		}
+	}
+}
+
+// BenchmarkPrefixIndexLookup looks up typed prefixes in an index of 100,000
+// services, more than the distinct names of the billing codes payers publish,
+// and reports the 99th percentile lookup time, which must stay well under the
+// 10ms typeahead budget
+func BenchmarkPrefixIndexLookup(b *testing.B) {
+	items := benchmarkSuggestions(100000)
+	idx := newPrefixIndex(items)
+	prefixes := benchmarkPrefixes(items, 10000)
+	durations := make([]time.Duration, 0, b.N)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		idx.lookup(prefixes[i%len(prefixes)], 10)
+		durations = append(durations, time.Since(start))
+	}
+	b.StopTimer()
+
+	slices.Sort(durations)
+	p99 := durations[len(durations)*99/100]
+	b.ReportMetric(float64(p99.Nanoseconds()), "p99-ns")
+	if p99 > 10*time.Millisecond {
+		b.Errorf("p99 lookup time %v over the 10ms budget", p99)
+	}
+}
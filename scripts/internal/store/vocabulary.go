@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// minCorrectLength is the shortest query word that is spell-corrected; shorter
+// words are too ambiguous (e.g. "ct", "mri", "ekg")
+const minCorrectLength = 4
+
+// vocabulary is the set of words in service names, descriptions and lay terms,
+// used to correct typos in search queries before they reach the full-text index
+type vocabulary struct {
+	counts   map[string]int
+	byLength map[int][]string
+}
+
+// newVocabulary creates an empty vocabulary
+func newVocabulary() *vocabulary {
+	return &vocabulary{counts: make(map[string]int), byLength: make(map[int][]string)}
+}
+
+// add counts every word of text
+func (v *vocabulary) add(text string) {
+	for _, word := range searchWords(text) {
+		if v.counts[word] == 0 {
+			n := len([]rune(word))
+			v.byLength[n] = append(v.byLength[n], word)
+		}
+		v.counts[word]++
+	}
+}
+
+// correct returns the most frequent known word closest to word, or word
+// itself when it is known, short or has no close match. Words of up to 7
+// letters allow one edit, longer ones two.
+func (v *vocabulary) correct(word string) string {
+	n := len([]rune(word))
+	if n < minCorrectLength || v.counts[word] > 0 {
+		return word
+	}
+
+	maxDistance := 1
+	if n >= 8 {
+		maxDistance = 2
+	}
+
+	best, bestDistance, bestCount := word, maxDistance+1, 0
+	for length := n - maxDistance; length <= n+maxDistance; length++ {
+		for _, candidate := range v.byLength[length] {
+			d := editDistance(word, candidate, maxDistance)
+			if d > maxDistance {
+				continue
+			}
+			if d < bestDistance || (d == bestDistance && v.counts[candidate] > bestCount) {
+				best, bestDistance, bestCount = candidate, d, v.counts[candidate]
+			}
+		}
+	}
+	return best
+}
+
+// searchWords splits text into lower-case words of letters and digits
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// editDistance is the optimal string alignment distance between a and b
+// (insertions, deletions, substitutions and adjacent transpositions). It
+// stops early and returns limit+1 once the distance exceeds limit.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+// loadVocabulary builds the vocabulary from the active services and the lay
+// terms
+func (s *Store) loadVocabulary(ctx context.Context) (*vocabulary, error) {
+	v := newVocabulary()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT name, COALESCE(description, '') FROM active_insurance_services
+		UNION
+		SELECT DISTINCT term, '' FROM service_terms
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load vocabulary: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, description string
+		if err := rows.Scan(&name, &description); err != nil {
+			return nil, fmt.Errorf("failed to scan vocabulary: %v", err)
+		}
+		v.add(name)
+		v.add(description)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %v", err)
+	}
+
+	return v, nil
+}
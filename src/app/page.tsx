@@ -1,26 +1,43 @@
 "use client";
 
 import { ArrowRight, Building2, DollarSign, Search, Shield, TrendingUp, Users } from "lucide-react";
-import { useCallback, useMemo, useState } from "react";
+import { useCallback, useEffect, useMemo, useState } from "react";
+import { createClient, type SearchResult, type Suggestion } from "@/lib/api";
 
-interface SearchResult {
-  id: string;
-  serviceName: string;
-  description: string;
-  cashPrice: number;
-  negotiatedRates: {
-    payerName: string;
-    rate: number;
-  }[];
-  hospital: string;
-  location: string;
-}
+const api = createClient({ baseUrl: process.env.NEXT_PUBLIC_API_URL ?? "http://localhost:8080" });
 
 export default function Home() {
   const [searchQuery, setSearchQuery] = useState("");
   const [isSearching, setIsSearching] = useState(false);
   const [searchResults, setSearchResults] = useState<SearchResult[]>([]);
   const [hasSearched, setHasSearched] = useState(false);
+  const [suggestions, setSuggestions] = useState<Suggestion[]>([]);
+
+  // Fetch typeahead suggestions shortly after the user stops typing
+  useEffect(() => {
+    const prefix = searchQuery.trim();
+    if (!prefix) {
+      setSuggestions([]);
+      return;
+    }
+
+    const controller = new AbortController();
+    const timer = setTimeout(async () => {
+      try {
+        const body = await api.suggest({ prefix, limit: 8 }, { signal: controller.signal });
+        setSuggestions(body.suggestions);
+      } catch (error) {
+        if ((error as Error).name !== "AbortError") {
+          console.error(error);
+        }
+      }
+    }, 150);
+
+    return () => {
+      clearTimeout(timer);
+      controller.abort();
+    };
+  }, [searchQuery]);
 
   const handleSearch = useCallback(async () => {
     if (!searchQuery.trim()) return;
@@ -28,56 +45,15 @@ export default function Home() {
     setIsSearching(true);
     setHasSearched(true);
     
-    // Simulate API call with mock data
-    setTimeout(() => {
-      const mockResults: SearchResult[] = [
-        {
-          id: "1",
-          serviceName: "MRI Brain without Contrast",
-          description: "Magnetic resonance imaging of the brain without contrast material",
-          cashPrice: 850,
-          negotiatedRates: [
-            { payerName: "Blue Cross Blue Shield", rate: 650 },
-            { payerName: "Aetna", rate: 720 },
-            { payerName: "Cigna", rate: 680 },
-            { payerName: "UnitedHealth", rate: 700 }
-          ],
-          hospital: "Memorial Hospital",
-          location: "New York, NY"
-        },
-        {
-          id: "2",
-          serviceName: "CT Scan Chest",
-          description: "Computed tomography scan of the chest",
-          cashPrice: 1200,
-          negotiatedRates: [
-            { payerName: "Blue Cross Blue Shield", rate: 950 },
-            { payerName: "Aetna", rate: 1100 },
-            { payerName: "Cigna", rate: 980 },
-            { payerName: "UnitedHealth", rate: 1050 }
-          ],
-          hospital: "City Medical Center",
-          location: "Los Angeles, CA"
-        },
-        {
-          id: "3",
-          serviceName: "Echocardiogram",
-          description: "Ultrasound examination of the heart",
-          cashPrice: 650,
-          negotiatedRates: [
-            { payerName: "Blue Cross Blue Shield", rate: 480 },
-            { payerName: "Aetna", rate: 520 },
-            { payerName: "Cigna", rate: 490 },
-            { payerName: "UnitedHealth", rate: 510 }
-          ],
-          hospital: "Regional Heart Institute",
-          location: "Chicago, IL"
-        }
-      ];
-      
-      setSearchResults(mockResults);
+    try {
+      const page = await api.search({ q: searchQuery.trim() });
+      setSearchResults(page.results);
+    } catch (error) {
+      console.error(error);
+      setSearchResults([]);
+    } finally {
       setIsSearching(false);
-    }, 1000);
+    }
   }, [searchQuery]);
 
   const handleKeyPress = useCallback((e: React.KeyboardEvent) => {
@@ -164,8 +140,19 @@ export default function Home() {
                     value={searchQuery}
                     onChange={(e) => setSearchQuery(e.target.value)}
                     onKeyPress={handleKeyPress}
+                    list="search-suggestions"
+                    autoComplete="off"
                     className="w-full pl-12 pr-4 py-4 bg-white border-2 border-gray-200 rounded-lg text-gray-900 placeholder-gray-500 focus:outline-none focus:ring-2 focus:ring-blue-500 focus:border-blue-500"
                   />
+                  <datalist id="search-suggestions">
+                    {suggestions.map((suggestion) => (
+                      <option
+                        key={`${suggestion.billingCodeType}-${suggestion.billingCode}-${suggestion.text}`}
+                        value={suggestion.text}
+                        label={`${suggestion.billingCodeType} ${suggestion.billingCode}`}
+                      />
+                    ))}
+                  </datalist>
                 </div>
                 <button
                   onClick={handleSearch}
@@ -267,7 +254,7 @@ export default function Home() {
                             <div className="flex justify-between items-center">
                               <span className="text-lg text-gray-700 font-medium">Cash Price:</span>
                               <span className="text-3xl font-black text-green-600">
-                                ${result.cashPrice.toLocaleString()}
+                                {result.cashPrice !== null ? `$${result.cashPrice.toLocaleString()}` : "N/A"}
                               </span>
                             </div>
                             
@@ -283,11 +270,13 @@ export default function Home() {
                               ))}
                             </div>
                             
-                            <div className="border-t-2 border-gray-200 pt-4">
-                              <div className="text-sm text-gray-500 font-medium">
-                                Potential savings: <span className="text-green-600 font-bold">${(result.cashPrice - Math.min(...result.negotiatedRates.map(r => r.rate))).toLocaleString()}</span>
+                            {result.cashPrice !== null && result.negotiatedRates.length > 0 && (
+                              <div className="border-t-2 border-gray-200 pt-4">
+                                <div className="text-sm text-gray-500 font-medium">
+                                  Potential savings: <span className="text-green-600 font-bold">${(result.cashPrice - Math.min(...result.negotiatedRates.map(r => r.rate))).toLocaleString()}</span>
+                                </div>
                               </div>
-                            </div>
+                            )}
                           </div>
                         </div>
                       </div>
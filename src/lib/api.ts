@@ -0,0 +1,585 @@
+// Code generated from the OpenAPI document of the price API; DO NOT EDIT.
+// Regenerate with `npm run api:generate`.
+
+export interface Address {
+  city: string;
+  state: string;
+  street: string;
+  zip: string;
+}
+
+export interface Benefits {
+  coinsurancePercent?: number;
+  copay?: number;
+  deductibleRemaining?: number;
+  outOfPocketRemaining?: number | null;
+}
+
+/** Whether a rate is for the professional or the facility part of a service */
+export type BillingClass = "professional" | "institutional";
+
+export interface CodeRate {
+  billingClass: BillingClass;
+  comparable: boolean;
+  distance?: number | null;
+  effectiveRate: number | null;
+  expirationDate: string;
+  id: number;
+  medicareAmount: number | null;
+  negotiatedRate: number;
+  negotiatedType: NegotiatedType;
+  payerName: string;
+  pctOfMedicare: number | null;
+  planId: string;
+  planName: string;
+  providerReferences: number[];
+  serviceCodes: string[];
+}
+
+export interface CodeRates {
+  billingCode: string;
+  billingCodeType: string;
+  groups: RateGroupStats[];
+  medicareLocality: string;
+  name: string;
+  nextCursor: string;
+  pageSize: number;
+  rates: CodeRate[];
+  statsRefreshedAt: string | null;
+  total: number;
+}
+
+export interface ContactInfo {
+  email: string;
+  phone: string;
+  website: string;
+}
+
+export interface CostRange {
+  high: number;
+  low: number;
+  typical: number;
+}
+
+export interface CostShare {
+  coinsurance: number;
+  copay: number;
+  deductible: number;
+}
+
+export interface Episode {
+  ancillaries: EpisodeComponent[];
+  category: string;
+  description: string;
+  id: string;
+  name: string;
+  primary: EpisodeComponent;
+}
+
+export interface EpisodeComponent {
+  billingClass: BillingClass;
+  billingCode: string;
+  billingCodeType: string;
+  description: string;
+  optional: boolean;
+  units: number;
+}
+
+export interface EpisodeLine {
+  amount: number | null;
+  billingClass: BillingClass;
+  billingCode: string;
+  billingCodeType: string;
+  description: string;
+  optional: boolean;
+  rate: number | null;
+  rateCount: number;
+  source: EpisodeRateSource;
+  units: number;
+}
+
+export interface EpisodePrice {
+  complete: boolean;
+  estimated: string[];
+  lines: EpisodeLine[];
+  missing: string[];
+  payerName: string;
+  providerName: string;
+  tin: string;
+  total: number;
+}
+
+export interface EpisodePrices {
+  episode: Episode;
+  market: string;
+  page: number;
+  pageSize: number;
+  prices: EpisodePrice[];
+  stats: RateStats;
+  total: number;
+}
+
+/** Whose rates price an episode component: the provider's, the payer's market median or none */
+export type EpisodeRateSource = "provider" | "payer_median" | "none";
+
+export interface EpisodesResponse {
+  episodes: Episode[];
+}
+
+export interface ErrorResponse {
+  error: string;
+}
+
+export interface Estimate {
+  allowed: CostRange;
+  costShare: CostShare;
+  lines: EstimateLine[];
+  memberCost: CostRange;
+  notes: string[];
+  planPays: CostRange;
+}
+
+export interface EstimateItem {
+  billingClass?: BillingClass;
+  billingCode: string;
+  billingCodeType: string;
+  units?: number;
+}
+
+export interface EstimateLine {
+  allowed: CostRange | null;
+  billingClass?: BillingClass;
+  billingCode: string;
+  billingCodeType: string;
+  costShare: CostShare | null;
+  memberCost: CostRange | null;
+  nonComparableRates: number;
+  planPays: CostRange | null;
+  rateCount: number;
+  serviceName: string;
+  units: number;
+}
+
+export interface EstimateRequest {
+  base?: number;
+  benefits?: Benefits;
+  items: EstimateItem[];
+  npi?: string;
+  payer?: string;
+  plan?: string;
+  providerGroupId?: number;
+  runId?: number;
+}
+
+export interface GraphQLError {
+  extensions?: Record<string, unknown>;
+  locations?: GraphQLLocation[];
+  message: string;
+  path?: unknown[];
+}
+
+export interface GraphQLLocation {
+  column: number;
+  line: number;
+}
+
+export interface GraphQLRequest {
+  operationName?: string;
+  query: string;
+  variables?: Record<string, unknown>;
+}
+
+export interface GraphQLResponse {
+  data?: Record<string, unknown>;
+  errors?: GraphQLError[];
+  extensions?: Record<string, unknown>;
+}
+
+export interface NegotiatedRate {
+  billingClass: BillingClass;
+  comparable: boolean;
+  effectiveRate: number | null;
+  expirationDate: string;
+  id: number;
+  medicareAmount: number | null;
+  negotiatedRate: number;
+  negotiatedType: NegotiatedType;
+  payerName: string;
+  pctOfMedicare: number | null;
+  planName: string;
+  standardChargeId: number;
+}
+
+/** Whether a rate is a dollar amount or a percentage of billed charges */
+export type NegotiatedType = "negotiated" | "percentage";
+
+export interface PayerComparison {
+  billingClass: BillingClass;
+  comparable: boolean;
+  effectiveRate: number | null;
+  expirationDate: string;
+  expired: boolean;
+  medicareAmount: number | null;
+  negotiatedRate: number;
+  negotiatedType: NegotiatedType;
+  payerName: string;
+  pctOfMedicare: number | null;
+  planId: string;
+  planName: string;
+  rank: number | null;
+  rateCount: number;
+}
+
+export interface PayerComparisonResult {
+  asOf: string;
+  base: number | null;
+  billingCode: string;
+  billingCodeType: string;
+  medicareLocality: string;
+  name: string;
+  npi: string;
+  payers: PayerComparison[];
+  referenceFees: Record<string, number>;
+}
+
+export interface PayerRate {
+  payerName: string;
+  rate: number;
+}
+
+export interface Provider {
+  address: Address;
+  cmsCertificationNumber: string;
+  contactInfo: ContactInfo;
+  createdAt: string;
+  id: number;
+  name: string;
+  npi: string;
+  updatedAt: string;
+}
+
+export interface ProviderPage {
+  page: number;
+  pageSize: number;
+  results: Provider[];
+  total: number;
+}
+
+export interface ProviderRates {
+  page: number;
+  pageSize: number;
+  provider: Provider;
+  standardCharges: StandardCharge[];
+  total: number;
+}
+
+export interface RateGroupStats {
+  billingClass: BillingClass;
+  medicareAmount: number | null;
+  negotiatedType: NegotiatedType;
+  pctOfMedicare: RateStats | null;
+  stats: RateStats;
+}
+
+export interface RateStats {
+  count: number;
+  max: number;
+  mean: number;
+  median: number;
+  min: number;
+  p10: number;
+  p25: number;
+  p75: number;
+  p90: number;
+}
+
+export interface SearchPage {
+  correctedQuery?: string;
+  page: number;
+  pageSize: number;
+  results: SearchResult[];
+  total: number;
+}
+
+export interface SearchResult {
+  billingCode: string;
+  billingCodeType: string;
+  cashPrice: number | null;
+  description: string;
+  distance?: number | null;
+  hospital: string;
+  id: string;
+  location: string;
+  negotiatedRates: PayerRate[];
+  providerGroupId: number;
+  relevance: number;
+  runId: number;
+  serviceName: string;
+}
+
+export interface StandardCharge {
+  billingCode: string;
+  billingCodeType: string;
+  cashPrice: number | null;
+  effectiveDate: string;
+  expirationDate?: string;
+  grossCharge: number | null;
+  id: number;
+  maxNegotiatedRate: number | null;
+  minNegotiatedRate: number | null;
+  negotiatedRates: NegotiatedRate[];
+  providerId: number;
+  serviceId: number;
+  serviceName: string;
+}
+
+export interface SuggestResponse {
+  prefix: string;
+  suggestions: Suggestion[];
+}
+
+export interface Suggestion {
+  billingCode: string;
+  billingCodeType: string;
+  rateCount: number;
+  text: string;
+}
+
+/** Parameters of comparePayers */
+export interface ComparePayersParams {
+  /** Date expiration dates are checked against, today by default */
+  as_of?: string;
+  /** Dollar amount percentage rates apply to */
+  base?: number;
+  /** Billing code, e.g. 70551 */
+  code: string;
+  /** National Provider Identifier */
+  npi: string;
+  /** Billing code type, e.g. CPT */
+  type: string;
+}
+
+/** Parameters of codeRates */
+export interface CodeRatesParams {
+  /** nextCursor of the previous page */
+  after?: string;
+  /** Billing code, e.g. 70551 */
+  code: string;
+  /** ZIP code to search around */
+  near?: string;
+  /** Rates of provider groups with this NPI */
+  npi?: string;
+  /** Results per page */
+  page_size?: number;
+  /** Reporting entity name */
+  payer?: string;
+  /** CMS place of service code */
+  place_of_service?: string;
+  /** Plan name or id */
+  plan?: string;
+  /** Provider group id of the payer's file, with run */
+  provider_group?: number;
+  /** Radius around near in miles */
+  radius?: number;
+  /** Ingestion run of provider_group */
+  run?: number;
+  /** Two-letter state of the providers */
+  state?: string;
+  /** Billing code type, e.g. CPT */
+  type: string;
+}
+
+/** Parameters of episodePrices */
+export interface EpisodePricesParams {
+  /** Episode id, e.g. knee-replacement */
+  id: string;
+  /** ZIP code to search around */
+  near?: string;
+  /** Page number, from 1 */
+  page?: number;
+  /** Results per page */
+  page_size?: number;
+  /** Reporting entity name */
+  payer?: string;
+  /** Radius around near in miles */
+  radius?: number;
+  /** Two-letter state of the providers */
+  state?: string;
+}
+
+/** Parameters of listProviders */
+export interface ListProvidersParams {
+  /** City */
+  city?: string;
+  /** Only providers with a rate for this billing code */
+  code?: string;
+  /** Page number, from 1 */
+  page?: number;
+  /** Results per page */
+  page_size?: number;
+  /** Two-letter state */
+  state?: string;
+}
+
+/** Parameters of getProvider */
+export interface GetProviderParams {
+  /** National Provider Identifier */
+  npi: string;
+}
+
+/** Parameters of providerRates */
+export interface ProviderRatesParams {
+  /** Only this billing code */
+  code?: string;
+  /** National Provider Identifier */
+  npi: string;
+  /** Page number, from 1 */
+  page?: number;
+  /** Results per page */
+  page_size?: number;
+}
+
+/** Parameters of search */
+export interface SearchParams {
+  /** ZIP code to search around */
+  near?: string;
+  /** Page number, from 1 */
+  page?: number;
+  /** Results per page */
+  page_size?: number;
+  /** Search text */
+  q: string;
+  /** Radius around near in miles */
+  radius?: number;
+  /** Result order; distance requires near */
+  sort?: "relevance" | "price" | "-price" | "name" | "-name" | "code" | "distance";
+}
+
+/** Parameters of suggest */
+export interface SuggestParams {
+  /** Number of suggestions */
+  limit?: number;
+  /** Text typed so far */
+  prefix: string;
+}
+
+/** Options of createClient */
+export interface ClientOptions {
+  /** Base URL of the API, e.g. http://localhost:8080 */
+  baseUrl: string;
+  /** API key, for servers that require one */
+  apiKey?: string;
+  /** fetch implementation, the global fetch by default */
+  fetch?: typeof fetch;
+}
+
+/** Thrown for responses with an error status */
+export class ApiError extends Error {
+  readonly status: number;
+  readonly body: ErrorResponse | undefined;
+  /** Seconds to wait before retrying, from Retry-After */
+  readonly retryAfter: number | undefined;
+
+  constructor(status: number, body: ErrorResponse | undefined, retryAfter: number | undefined) {
+    super(body?.error ?? `request failed with status ${status}`);
+    this.name = "ApiError";
+    this.status = status;
+    this.body = body;
+    this.retryAfter = retryAfter;
+  }
+}
+
+type Query = Record<string, string | number | boolean | undefined>;
+
+/** Creates a client of the price API */
+export function createClient(options: ClientOptions) {
+  const doFetch = options.fetch ?? fetch;
+
+  async function request<T>(method: string, path: string, query: Query, body: unknown, init?: RequestInit): Promise<T> {
+    const params = new URLSearchParams();
+    for (const [name, value] of Object.entries(query)) {
+      if (value !== undefined) {
+        params.set(name, String(value));
+      }
+    }
+    const search = params.toString();
+
+    const headers = new Headers(init?.headers);
+    if (options.apiKey) {
+      headers.set("Authorization", `Bearer ${options.apiKey}`);
+    }
+    if (body !== undefined) {
+      headers.set("Content-Type", "application/json");
+    }
+
+    const response = await doFetch(`${options.baseUrl}${path}${search ? `?${search}` : ""}`, {
+      ...init,
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    if (!response.ok) {
+      const error = (await response.json().catch(() => undefined)) as ErrorResponse | undefined;
+      const retryAfter = response.headers.get("Retry-After");
+      throw new ApiError(response.status, error, retryAfter ? Number(retryAfter) : undefined);
+    }
+    return (await response.json()) as T;
+  }
+
+  return {
+    /** Report whether the database is reachable */
+    health: (init?: RequestInit) =>
+      request<Record<string, string>>("GET", "/healthz", {}, undefined, init),
+    /** Rank payers and plans by their rate for a billing code at one provider */
+    comparePayers: (params: ComparePayersParams, init?: RequestInit) =>
+      request<PayerComparisonResult>("GET", `/v1/codes/${encodeURIComponent(String(params.type))}/${encodeURIComponent(String(params.code))}/compare`, { npi: params.npi, base: params.base, as_of: params.as_of }, undefined, init),
+    /** Current negotiated rates of a billing code with their distribution */
+    codeRates: (params: CodeRatesParams, init?: RequestInit) =>
+      request<CodeRates>("GET", `/v1/codes/${encodeURIComponent(String(params.type))}/${encodeURIComponent(String(params.code))}/rates`, { payer: params.payer, plan: params.plan, provider_group: params.provider_group, run: params.run, npi: params.npi, place_of_service: params.place_of_service, state: params.state, near: params.near, radius: params.radius, after: params.after, page_size: params.page_size }, undefined, init),
+    /** Episodes of care, the bundles of billing codes patients shop for */
+    listEpisodes: (init?: RequestInit) =>
+      request<EpisodesResponse>("GET", "/v1/episodes", {}, undefined, init),
+    /**
+     * Price an episode of care for every payer and provider organization
+     *
+     * Requires a market (state or near) or a payer. Components a provider has no rate for are priced at the payer's median rate in the market, which makes the price an estimate rather than complete.
+     */
+    episodePrices: (params: EpisodePricesParams, init?: RequestInit) =>
+      request<EpisodePrices>("GET", `/v1/episodes/${encodeURIComponent(String(params.id))}/prices`, { payer: params.payer, state: params.state, near: params.near, radius: params.radius, page: params.page, page_size: params.page_size }, undefined, init),
+    /**
+     * Estimate the out-of-pocket cost of an episode of billing codes at one provider
+     *
+     * Specify exactly one of npi or providerGroupId with its runId.
+     */
+    estimate: (body: EstimateRequest, init?: RequestInit) =>
+      request<Estimate>("POST", "/v1/estimate", {}, body, init),
+    /**
+     * Run a GraphQL query
+     *
+     * Queries that do not validate or are too complex are rejected with 400 and GraphQL errors.
+     */
+    graphql: (body: GraphQLRequest, init?: RequestInit) =>
+      request<GraphQLResponse>("POST", "/v1/graphql", {}, body, init),
+    /** This OpenAPI document */
+    openapi: (init?: RequestInit) =>
+      request<Record<string, unknown>>("GET", "/v1/openapi.json", {}, undefined, init),
+    /** List providers of the directory */
+    listProviders: (params: ListProvidersParams = {}, init?: RequestInit) =>
+      request<ProviderPage>("GET", "/v1/providers", { state: params.state, city: params.city, code: params.code, page: params.page, page_size: params.page_size }, undefined, init),
+    /** A provider of the directory */
+    getProvider: (params: GetProviderParams, init?: RequestInit) =>
+      request<Provider>("GET", `/v1/providers/${encodeURIComponent(String(params.npi))}`, {}, undefined, init),
+    /** Standard charges of a provider with every payer's rates */
+    providerRates: (params: ProviderRatesParams, init?: RequestInit) =>
+      request<ProviderRates>("GET", `/v1/providers/${encodeURIComponent(String(params.npi))}/rates`, { code: params.code, page: params.page, page_size: params.page_size }, undefined, init),
+    /** Search services by name, description, lay term or billing code */
+    search: (params: SearchParams, init?: RequestInit) =>
+      request<SearchPage>("GET", "/v1/search", { q: params.q, sort: params.sort, page: params.page, page_size: params.page_size, near: params.near, radius: params.radius }, undefined, init),
+    /** Typeahead suggestions of service names and billing codes */
+    suggest: (params: SuggestParams, init?: RequestInit) =>
+      request<SuggestResponse>("GET", "/v1/suggest", { prefix: params.prefix, limit: params.limit }, undefined, init),
+  };
+}
+
+/** A client of the price API */
+export type Client = ReturnType<typeof createClient>;
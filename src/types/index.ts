@@ -1,23 +1,7 @@
-export interface Provider {
-    id: number;
-    name: string;
-    npi: string;
-    address: {
-      street: string;
-      city: string;
-      state: string;
-      zip: string;
-    };
-    contactInfo: {
-      phone: string;
-      email: string;
-      website: string;
-    };
-    cmsCertificationNumber: string;
-    createdAt: Date;
-    updatedAt: Date;
-  }
-  
+// Types served by the Go API are generated from its OpenAPI document, so
+// they cannot drift from the Go structs; see src/lib/api.ts.
+export type { BillingClass, NegotiatedRate, NegotiatedType, Provider, StandardCharge } from "@/lib/api";
+
   export interface Service {
     id: number;
     code: string;
@@ -26,27 +10,6 @@ export interface Provider {
     category: string;
   }
   
-  export interface StandardCharge {
-    id: number;
-    providerId: number;
-    serviceId: number;
-    grossCharge: number;
-    cashPrice: number;
-    minNegotiatedRate: number;
-    maxNegotiatedRate: number;
-    effectiveDate: Date;
-    expirationDate?: Date;
-  }
-  
-  export interface NegotiatedRate {
-    id: number;
-    standardChargeId: number;
-    payerName: string;
-    planName: string;
-    negotiatedRate: number;
-    billingClass: 'inpatient' | 'outpatient';
-  }
-  
   export interface ComplianceStatus {
     providerId: number;
     hasMRFFile: boolean;
@@ -54,4 +17,4 @@ export interface Provider {
     mrfAccessibilityScore: number;
     lastChecked: Date;
     overallScore: number;
-  }
\ No newline at end of file
+  }